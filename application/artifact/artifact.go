@@ -0,0 +1,73 @@
+// Package artifact keeps submission artifacts off the ledger. A store uploads
+// the blob and returns a content-addressed reference to record on-chain,
+// downloads are checked against the SHA-256 digest of that reference.
+package artifact
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+)
+
+// Ref references an artifact, it matches the Artifact of the submission
+// chaincode.
+type Ref struct {
+	SHA256    string `json:"sha256"`
+	Size      uint64 `json:"size"`
+	MediaType string `json:"mediaType"`
+	URI       string `json:"uri"`
+}
+
+// Store uploads and downloads artifacts
+type Store interface {
+	// Put uploads the content of r and returns a reference to it
+	Put(r io.Reader, mediaType string) (*Ref, error)
+	// Get downloads the artifact ref points to. Reading it fails with
+	// ErrDigestMismatch when the content doesn't match ref.
+	Get(ref *Ref) (io.ReadCloser, error)
+}
+
+// ErrDigestMismatch is returned when downloaded content doesn't match the
+// size or digest of its reference.
+var ErrDigestMismatch = errors.New("artifact content does not match its reference")
+
+// Verify wraps rc so that reading it to the end fails with ErrDigestMismatch
+// unless the content matches the size and digest of ref. Store
+// implementations use it to check downloads.
+func Verify(rc io.ReadCloser, ref *Ref) io.ReadCloser {
+	return &verifier{rc: rc, ref: ref, hash: sha256.New()}
+}
+
+type verifier struct {
+	rc   io.ReadCloser
+	ref  *Ref
+	hash hash.Hash
+	size uint64
+}
+
+func (v *verifier) Read(p []byte) (int, error) {
+	n, err := v.rc.Read(p)
+	v.hash.Write(p[:n])
+	v.size += uint64(n)
+
+	if v.size > v.ref.Size {
+		return n, fmt.Errorf("%w: %s is larger than %d bytes", ErrDigestMismatch, v.ref.SHA256, v.ref.Size)
+	}
+	if err == io.EOF {
+		if v.size != v.ref.Size {
+			return n, fmt.Errorf("%w: %s has %d bytes, expected %d", ErrDigestMismatch, v.ref.SHA256, v.size, v.ref.Size)
+		}
+		if digest := hex.EncodeToString(v.hash.Sum(nil)); digest != v.ref.SHA256 {
+			return n, fmt.Errorf("%w: expected %s, got %s", ErrDigestMismatch, v.ref.SHA256, digest)
+		}
+	}
+
+	return n, err
+}
+
+func (v *verifier) Close() error {
+	return v.rc.Close()
+}
@@ -0,0 +1,90 @@
+package artifact
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+)
+
+// FileStore keeps artifacts in a local directory, named by their digest. It
+// is meant for tests and single-machine setups.
+type FileStore struct {
+	dir string
+}
+
+// NewFileStore returns a store keeping artifacts in dir, creating it when
+// needed.
+func NewFileStore(dir string) (*FileStore, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	err = os.MkdirAll(filepath.Join(dir, "sha256"), 0750)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create artifact directory: %v", err)
+	}
+
+	return &FileStore{dir: dir}, nil
+}
+
+// Put copies r into the store. Content is first written to a temporary file
+// and only moved to its final name once the digest is known.
+func (s *FileStore) Put(r io.Reader, mediaType string) (*Ref, error) {
+	tmp, err := ioutil.TempFile(s.dir, "upload-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create upload file: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(tmp, hash), r)
+	if err != nil {
+		tmp.Close()
+		return nil, fmt.Errorf("failed to write artifact: %v", err)
+	}
+	err = tmp.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to write artifact: %v", err)
+	}
+
+	digest := hex.EncodeToString(hash.Sum(nil))
+	err = os.Rename(tmp.Name(), s.path(digest))
+	if err != nil {
+		return nil, fmt.Errorf("failed to store artifact %s: %v", digest, err)
+	}
+
+	uri := url.URL{Scheme: "file", Path: filepath.ToSlash(s.path(digest))}
+	return &Ref{
+		SHA256:    digest,
+		Size:      uint64(size),
+		MediaType: mediaType,
+		URI:       uri.String(),
+	}, nil
+}
+
+// Get opens the artifact with the digest of ref. The URI of ref is not used,
+// so references recorded by another FileStore resolve as long as the
+// content is present.
+func (s *FileStore) Get(ref *Ref) (io.ReadCloser, error) {
+	digest, err := hex.DecodeString(ref.SHA256)
+	if err != nil || len(digest) != sha256.Size {
+		return nil, fmt.Errorf("artifact digest %q is not a hex SHA-256 digest", ref.SHA256)
+	}
+
+	file, err := os.Open(s.path(ref.SHA256))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open artifact %s: %v", ref.SHA256, err)
+	}
+
+	return Verify(file, ref), nil
+}
+
+func (s *FileStore) path(digest string) string {
+	return filepath.Join(s.dir, "sha256", digest)
+}
@@ -0,0 +1,110 @@
+package artifact
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+const testContent = "interface eth0\n ip address 10.0.0.1/24\n"
+
+// newTestStore returns a store in a temporary directory and a function
+// removing it
+func newTestStore(t *testing.T) (*FileStore, func()) {
+	dir, err := ioutil.TempDir("", "artifact-")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store, err := NewFileStore(dir)
+	if err != nil {
+		os.RemoveAll(dir)
+		t.Fatal(err)
+	}
+
+	return store, func() { os.RemoveAll(dir) }
+}
+
+// put stores testContent and returns its reference
+func put(t *testing.T, store *FileStore) *Ref {
+	t.Helper()
+
+	ref, err := store.Put(strings.NewReader(testContent), "text/plain")
+	if err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	return ref
+}
+
+// get reads the artifact ref points to
+func get(t *testing.T, store *FileStore, ref *Ref) ([]byte, error) {
+	t.Helper()
+
+	rc, err := store.Get(ref)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	defer rc.Close()
+
+	return ioutil.ReadAll(rc)
+}
+
+func TestFileStoreRoundTrip(t *testing.T) {
+	store, cleanup := newTestStore(t)
+	defer cleanup()
+
+	ref := put(t, store)
+	digest := sha256.Sum256([]byte(testContent))
+	if ref.SHA256 != hex.EncodeToString(digest[:]) {
+		t.Errorf("got digest %q", ref.SHA256)
+	}
+	if ref.Size != uint64(len(testContent)) || ref.MediaType != "text/plain" || !strings.HasPrefix(ref.URI, "file://") {
+		t.Errorf("got reference %+v", ref)
+	}
+
+	content, err := get(t, store, ref)
+	if err != nil {
+		t.Fatalf("reading the artifact: %v", err)
+	}
+	if string(content) != testContent {
+		t.Errorf("got content %q, want %q", content, testContent)
+	}
+}
+
+func TestFileStoreDetectsTampering(t *testing.T) {
+	store, cleanup := newTestStore(t)
+	defer cleanup()
+
+	ref := put(t, store)
+	tampered := strings.Replace(testContent, "10.0.0.1", "10.6.6.6", 1)
+	err := ioutil.WriteFile(store.path(ref.SHA256), []byte(tampered), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = get(t, store, ref)
+	if !errors.Is(err, ErrDigestMismatch) || !strings.Contains(err.Error(), "expected "+ref.SHA256) {
+		t.Errorf("got %v reading a tampered artifact", err)
+	}
+}
+
+func TestFileStoreDetectsTruncation(t *testing.T) {
+	store, cleanup := newTestStore(t)
+	defer cleanup()
+
+	ref := put(t, store)
+	err := os.Truncate(store.path(ref.SHA256), int64(len(testContent)/2))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = get(t, store, ref)
+	if !errors.Is(err, ErrDigestMismatch) || !strings.Contains(err.Error(), "bytes, expected") {
+		t.Errorf("got %v reading a truncated artifact", err)
+	}
+}
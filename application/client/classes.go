@@ -0,0 +1,124 @@
+package client
+
+// Classes calls the ClassContract
+type Classes struct {
+	contract Contract
+}
+
+// List walks every class
+func (c *Classes) List() *ClassIterator {
+	return newClassIterator(func(bookmark string) (*ClassPage, error) {
+		return c.ListPage(0, bookmark)
+	})
+}
+
+// ListPage returns a page of every class
+func (c *Classes) ListPage(pageSize int, bookmark string) (*ClassPage, error) {
+	var page ClassPage
+	err := evaluatePage(c.contract, &page, "GetAllClassses", pageSize, bookmark)
+	if err != nil {
+		return nil, err
+	}
+
+	return &page, nil
+}
+
+// Get returns a class
+func (c *Classes) Get(id string) (*Class, error) {
+	var class Class
+	err := evaluate(c.contract, &class, "ReadClass", id)
+	if err != nil {
+		return nil, err
+	}
+
+	return &class, nil
+}
+
+// Create creates a class owned by the identity of the client
+func (c *Classes) Create(id, name, content string) (*Class, error) {
+	err := submit(c.contract, nil, "CreateClass", id, name, content, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return c.Get(id)
+}
+
+// Update replaces the name and content of a class
+func (c *Classes) Update(id, name, content string) (*Class, error) {
+	err := submit(c.contract, nil, "UpdateClass", id, name, content)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.Get(id)
+}
+
+// Transfer hands a class over to another owner
+func (c *Classes) Transfer(id, owner string) (*Class, error) {
+	err := submit(c.contract, nil, "TransferClass", id, owner)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.Get(id)
+}
+
+// Delete deletes a class. policy is DeleteRestrict to refuse while the class
+// has labs, or DeleteCascade to delete its labs along.
+func (c *Classes) Delete(id, policy string) error {
+	return submit(c.contract, nil, "DeleteClass", id, policy)
+}
+
+// Enroll adds a student to the roster of a class
+func (c *Classes) Enroll(classID, student string) error {
+	return submit(c.contract, nil, "EnrollStudent", classID, student)
+}
+
+// Drop removes a student from the roster of a class
+func (c *Classes) Drop(classID, student string) error {
+	return submit(c.contract, nil, "DropStudent", classID, student)
+}
+
+// Roster walks the students of a class
+func (c *Classes) Roster(classID string) *StudentIterator {
+	return newStudentIterator(func(bookmark string) (*StudentPage, error) {
+		return c.RosterPage(classID, 0, bookmark)
+	})
+}
+
+// RosterPage returns a page of the students of a class
+func (c *Classes) RosterPage(classID string, pageSize int, bookmark string) (*StudentPage, error) {
+	var page StudentPage
+	err := evaluatePage(c.contract, &page, "ListRoster", pageSize, bookmark, classID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &page, nil
+}
+
+// ForStudent walks the classes a student is enrolled in
+func (c *Classes) ForStudent(student string) *ClassIterator {
+	return newClassIterator(func(bookmark string) (*ClassPage, error) {
+		return c.ForStudentPage(student, 0, bookmark)
+	})
+}
+
+// ForStudentPage returns a page of the classes a student is enrolled in
+func (c *Classes) ForStudentPage(student string, pageSize int, bookmark string) (*ClassPage, error) {
+	var page ClassPage
+	err := evaluatePage(c.contract, &page, "ListClassesForStudent", pageSize, bookmark, student)
+	if err != nil {
+		return nil, err
+	}
+
+	return &page, nil
+}
+
+// History returns every version of a class, newest last
+func (c *Classes) History(id string) ([]*ClassHistory, error) {
+	var history []*ClassHistory
+	err := evaluate(c.contract, &history, "GetClassHistory", id)
+	return history, err
+}
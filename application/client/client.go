@@ -0,0 +1,392 @@
+// Package client talks to the labplatform chaincode through the Fabric
+// gateway. A Pool keeps one long-lived gateway connection per identity, the
+// typed contract clients decode the results into the structs of this
+// package.
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/core/config"
+	"github.com/hyperledger/fabric-sdk-go/pkg/gateway"
+)
+
+// Config describes how to reach the labplatform chaincode. DefaultConfig
+// returns the settings of the Fabric test network.
+type Config struct {
+	// ConnectionProfile is the path of the connection profile of the org
+	ConnectionProfile string
+	// Channel is the channel the chaincode is deployed on
+	Channel string
+	// Chaincode is the name the chaincode is deployed under
+	Chaincode string
+	// WalletPath is the directory of the file system wallet
+	WalletPath string
+	// Identity is the wallet label of the default identity
+	Identity string
+	// MSPID and CredentialPath are used to add the default identity to the
+	// wallet when it is missing. CredentialPath is its msp directory, with
+	// the certificate in signcerts and the key in keystore.
+	MSPID          string
+	CredentialPath string
+	// DiscoveryAsLocalhost maps discovered peers to localhost, for networks
+	// running in docker on the same machine
+	DiscoveryAsLocalhost bool
+}
+
+// DefaultConfig returns the config of User1 of Org1 on the test network,
+// with paths relative to the directory of an application in application/.
+func DefaultConfig() Config {
+	org := filepath.Join("..", "..", "..", "test-network", "organizations", "peerOrganizations", "org1.example.com")
+	return Config{
+		ConnectionProfile:    filepath.Join(org, "connection-org1.yaml"),
+		Channel:              "mychannel",
+		Chaincode:            "labplatform",
+		WalletPath:           "wallet",
+		Identity:             "appUser",
+		MSPID:                "Org1MSP",
+		CredentialPath:       filepath.Join(org, "users", "User1@org1.example.com", "msp"),
+		DiscoveryAsLocalhost: true,
+	}
+}
+
+// Contract is the part of a gateway contract the clients use. Tests swap in
+// their own implementation with NewWithContracts. The Transient variants pass
+// private data in the transient map, which is kept out of the transaction.
+type Contract interface {
+	EvaluateTransaction(name string, args ...string) ([]byte, error)
+	SubmitTransaction(name string, args ...string) ([]byte, error)
+	EvaluateTransient(name string, transient map[string][]byte, args ...string) ([]byte, error)
+	SubmitTransient(name string, transient map[string][]byte, args ...string) ([]byte, error)
+}
+
+// gatewayContract adds the Transient variants to a gateway contract
+type gatewayContract struct {
+	*gateway.Contract
+}
+
+// EvaluateTransient evaluates a transaction with a transient map
+func (c gatewayContract) EvaluateTransient(name string, transient map[string][]byte, args ...string) ([]byte, error) {
+	txn, err := c.CreateTransaction(name, gateway.WithTransient(transient))
+	if err != nil {
+		return nil, err
+	}
+
+	return txn.Evaluate(args...)
+}
+
+// SubmitTransient submits a transaction with a transient map
+func (c gatewayContract) SubmitTransient(name string, transient map[string][]byte, args ...string) ([]byte, error) {
+	txn, err := c.CreateTransaction(name, gateway.WithTransient(transient))
+	if err != nil {
+		return nil, err
+	}
+
+	return txn.Submit(args...)
+}
+
+// Client calls the contracts of the chaincode as one identity
+type Client struct {
+	contract func(name string) Contract
+	gateway  *gateway.Gateway
+	// open opens another gateway connection as the identity of the client
+	open func(options ...gateway.Option) (*gateway.Gateway, *gateway.Network, error)
+}
+
+// NewWithContracts returns a client calling the contracts returned by
+// contract, which is passed the contract name.
+func NewWithContracts(contract func(name string) Contract) *Client {
+	return &Client{contract: contract}
+}
+
+// Connect opens a connection as the default identity of config. The client
+// has to be closed.
+func Connect(cfg Config) (*Client, error) {
+	wallet, err := openWallet(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return connect(cfg, wallet, cfg.Identity)
+}
+
+// Close closes the gateway connection of the client
+func (c *Client) Close() {
+	if c.gateway != nil {
+		c.gateway.Close()
+	}
+}
+
+// Events is a gateway connection delivering the chaincode events of the
+// channel, see Client.Events
+type Events struct {
+	*gateway.Network
+	gateway *gateway.Gateway
+}
+
+// Close closes the gateway connection of the events
+func (e *Events) Close() {
+	e.gateway.Close()
+}
+
+// Events opens another connection as the identity of the client, delivering
+// the events from block blockNum on. The events have to be closed.
+func (c *Client) Events(blockNum uint64) (*Events, error) {
+	if c.open == nil {
+		return nil, fmt.Errorf("client has no gateway connection to receive events")
+	}
+
+	gw, network, err := c.open(gateway.WithBlockNum(blockNum))
+	if err != nil {
+		return nil, err
+	}
+
+	return &Events{Network: network, gateway: gw}, nil
+}
+
+// Classes returns the client of the ClassContract
+func (c *Client) Classes() *Classes {
+	return &Classes{contract: c.contract("class")}
+}
+
+// Labs returns the client of the LabContract
+func (c *Client) Labs() *Labs {
+	return &Labs{contract: c.contract("lab")}
+}
+
+// Instances returns the client of the InstanceContract
+func (c *Client) Instances() *Instances {
+	return &Instances{contract: c.contract("instance")}
+}
+
+// Submissions returns the client of the SubmissionContract
+func (c *Client) Submissions() *Submissions {
+	return &Submissions{contract: c.contract("submission")}
+}
+
+// Contract returns a contract of the chaincode for transactions the typed
+// clients don't cover.
+func (c *Client) Contract(name string) Contract {
+	return c.contract(name)
+}
+
+// Pool keeps a connection per identity open for the lifetime of the
+// application, so requests don't pay for a new gateway connection each.
+type Pool struct {
+	config  Config
+	wallet  *gateway.Wallet
+	mu      sync.Mutex
+	clients map[string]*Client
+}
+
+// NewPool returns a pool for config. Connections are opened on first use.
+func NewPool(cfg Config) (*Pool, error) {
+	wallet, err := openWallet(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Pool{
+		config:  cfg,
+		wallet:  wallet,
+		clients: make(map[string]*Client),
+	}, nil
+}
+
+// Get returns the client of the identity with the given wallet label,
+// connecting it when needed. An empty label selects the default identity.
+// Clients of a pool are closed by Close, not by their users.
+func (p *Pool) Get(identity string) (*Client, error) {
+	if identity == "" {
+		identity = p.config.Identity
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if client, ok := p.clients[identity]; ok {
+		return client, nil
+	}
+
+	client, err := connect(p.config, p.wallet, identity)
+	if err != nil {
+		return nil, err
+	}
+	p.clients[identity] = client
+
+	return client, nil
+}
+
+// Close closes every connection of the pool
+func (p *Pool) Close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for identity, client := range p.clients {
+		client.Close()
+		delete(p.clients, identity)
+	}
+}
+
+// openWallet opens the wallet of config and adds the default identity to it
+// when missing.
+func openWallet(cfg Config) (*gateway.Wallet, error) {
+	if cfg.DiscoveryAsLocalhost {
+		err := os.Setenv("DISCOVERY_AS_LOCALHOST", "true")
+		if err != nil {
+			return nil, fmt.Errorf("error setting DISCOVERY_AS_LOCALHOST environemnt variable: %v", err)
+		}
+	}
+
+	wallet, err := gateway.NewFileSystemWallet(cfg.WalletPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create wallet: %v", err)
+	}
+
+	if !wallet.Exists(cfg.Identity) && cfg.CredentialPath != "" {
+		err = populateWallet(wallet, cfg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to populate wallet contents: %v", err)
+		}
+	}
+
+	return wallet, nil
+}
+
+// connect opens a gateway connection as identity
+func connect(cfg Config, wallet *gateway.Wallet, identity string) (*Client, error) {
+	if !wallet.Exists(identity) {
+		return nil, fmt.Errorf("identity %s is not in the wallet", identity)
+	}
+
+	open := func(options ...gateway.Option) (*gateway.Gateway, *gateway.Network, error) {
+		return openNetwork(cfg, wallet, identity, options...)
+	}
+	gw, network, err := open()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Client{
+		contract: func(name string) Contract {
+			return gatewayContract{network.GetContractWithName(cfg.Chaincode, name)}
+		},
+		gateway: gw,
+		open:    open,
+	}, nil
+}
+
+// openNetwork connects to the gateway as identity and returns the network of
+// the channel of cfg
+func openNetwork(cfg Config, wallet *gateway.Wallet, identity string, options ...gateway.Option) (*gateway.Gateway, *gateway.Network, error) {
+	gw, err := gateway.Connect(
+		gateway.WithConfig(config.FromFile(filepath.Clean(cfg.ConnectionProfile))),
+		gateway.WithIdentity(wallet, identity),
+		options...,
+	)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to connect to gateway: %v", err)
+	}
+
+	network, err := gw.GetNetwork(cfg.Channel)
+	if err != nil {
+		gw.Close()
+		return nil, nil, fmt.Errorf("failed to get network: %v", err)
+	}
+
+	return gw, network, nil
+}
+
+// evaluate evaluates a transaction and decodes its JSON result into v, when
+// v is not nil.
+func evaluate(contract Contract, v interface{}, name string, args ...string) error {
+	result, err := contract.EvaluateTransaction(name, args...)
+	if err != nil {
+		return fmt.Errorf("failed to evaluate transaction: %v", err)
+	}
+
+	return decode(result, v)
+}
+
+// evaluatePage evaluates a list transaction and decodes the page it returns
+// into v. List transactions take the page size and bookmark after args.
+func evaluatePage(contract Contract, v interface{}, name string, pageSize int, bookmark string, args ...string) error {
+	return evaluate(contract, v, name, append(args, strconv.Itoa(pageSize), bookmark)...)
+}
+
+// submit submits a transaction and decodes its JSON result into v, when v is
+// not nil.
+func submit(contract Contract, v interface{}, name string, args ...string) error {
+	result, err := contract.SubmitTransaction(name, args...)
+	if err != nil {
+		return fmt.Errorf("failed to Submit transaction: %v", err)
+	}
+
+	return decode(result, v)
+}
+
+// submitTransient submits a transaction passing each value of transient as
+// JSON in the transient map.
+func submitTransient(contract Contract, v interface{}, name string, transient map[string]interface{}, args ...string) error {
+	transientMap, err := encodeTransient(transient)
+	if err != nil {
+		return err
+	}
+
+	result, err := contract.SubmitTransient(name, transientMap, args...)
+	if err != nil {
+		return fmt.Errorf("failed to Submit transaction: %v", err)
+	}
+
+	return decode(result, v)
+}
+
+// evaluateTransient evaluates a transaction passing each value of transient
+// as JSON in the transient map.
+func evaluateTransient(contract Contract, v interface{}, name string, transient map[string]interface{}, args ...string) error {
+	transientMap, err := encodeTransient(transient)
+	if err != nil {
+		return err
+	}
+
+	result, err := contract.EvaluateTransient(name, transientMap, args...)
+	if err != nil {
+		return fmt.Errorf("failed to evaluate transaction: %v", err)
+	}
+
+	return decode(result, v)
+}
+
+// encodeTransient marshals the values of a transient map
+func encodeTransient(transient map[string]interface{}) (map[string][]byte, error) {
+	transientMap := make(map[string][]byte, len(transient))
+	for key, value := range transient {
+		valueBytes, err := json.Marshal(value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode transient %s: %v", key, err)
+		}
+		transientMap[key] = valueBytes
+	}
+
+	return transientMap, nil
+}
+
+// decode unmarshals a transaction result. Transactions returning nothing,
+// like an empty list, have an empty result.
+func decode(result []byte, v interface{}) error {
+	if v == nil || len(result) == 0 {
+		return nil
+	}
+
+	err := json.Unmarshal(result, v)
+	if err != nil {
+		return fmt.Errorf("failed to decode transaction result: %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,160 @@
+package client
+
+import "strconv"
+
+// Instances calls the InstanceContract
+type Instances struct {
+	contract Contract
+}
+
+// Create creates an instance of a lab. config is the environment the student
+// asks for, it has to fit the config of the lab. An empty owner creates the
+// instance for the identity of the client, staff owning the class may name a
+// student of the class instead. When the quota of the lab refuses the
+// instance, the error is the QuotaDenial.
+func (i *Instances) Create(instanceID, labID, classID, config, owner string) (*Instance, error) {
+	var denial *QuotaDenial
+	err := submit(i.contract, &denial, "CreateInstance", instanceID, labID, classID, config, owner)
+	if err != nil {
+		return nil, err
+	}
+	if denial != nil {
+		return nil, denial
+	}
+
+	return i.Get(instanceID)
+}
+
+// Get returns an instance
+func (i *Instances) Get(instanceID string) (*Instance, error) {
+	var instance Instance
+	err := evaluate(i.contract, &instance, "ReadInstance", instanceID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &instance, nil
+}
+
+// Delete deletes an instance owned by the identity of the client
+func (i *Instances) Delete(instanceID string) error {
+	return submit(i.contract, nil, "DeleteInstance", instanceID)
+}
+
+// UpdateUsedTime corrects the used time of an instance, in seconds
+func (i *Instances) UpdateUsedTime(instanceID string, usedTime uint64) (*Instance, error) {
+	err := submit(i.contract, nil, "UpdateInstanceUsedTime", instanceID, strconv.FormatUint(usedTime, 10))
+	if err != nil {
+		return nil, err
+	}
+
+	return i.Get(instanceID)
+}
+
+// ListByRange walks the instances with IDs from startKey up to, but not
+// including, endKey
+func (i *Instances) ListByRange(startKey, endKey string) *InstanceIterator {
+	return newInstanceIterator(func(bookmark string) (*InstancePage, error) {
+		return i.ListByRangePage(startKey, endKey, 0, bookmark)
+	})
+}
+
+// ListByRangePage returns a page of the instances with IDs from startKey up
+// to, but not including, endKey
+func (i *Instances) ListByRangePage(startKey, endKey string, pageSize int, bookmark string) (*InstancePage, error) {
+	return i.page("GetInstanceByRange", pageSize, bookmark, startKey, endKey)
+}
+
+// ListByClass walks the instances of a class
+func (i *Instances) ListByClass(classID string) *InstanceIterator {
+	return newInstanceIterator(func(bookmark string) (*InstancePage, error) {
+		return i.ListByClassPage(classID, 0, bookmark)
+	})
+}
+
+// ListByClassPage returns a page of the instances of a class
+func (i *Instances) ListByClassPage(classID string, pageSize int, bookmark string) (*InstancePage, error) {
+	return i.page("GetInstancesByClass", pageSize, bookmark, classID)
+}
+
+// ListByLab walks the instances of a lab
+func (i *Instances) ListByLab(labID string) *InstanceIterator {
+	return newInstanceIterator(func(bookmark string) (*InstancePage, error) {
+		return i.ListByLabPage(labID, 0, bookmark)
+	})
+}
+
+// ListByLabPage returns a page of the instances of a lab
+func (i *Instances) ListByLabPage(labID string, pageSize int, bookmark string) (*InstancePage, error) {
+	return i.page("GetInstancesByLab", pageSize, bookmark, labID)
+}
+
+// ListByOwner walks the instances of a student
+func (i *Instances) ListByOwner(owner string) *InstanceIterator {
+	return newInstanceIterator(func(bookmark string) (*InstancePage, error) {
+		return i.ListByOwnerPage(owner, 0, bookmark)
+	})
+}
+
+// ListByOwnerPage returns a page of the instances of a student
+func (i *Instances) ListByOwnerPage(owner string, pageSize int, bookmark string) (*InstancePage, error) {
+	return i.page("GetInstancesByOwner", pageSize, bookmark, owner)
+}
+
+// page evaluates a list transaction of instances
+func (i *Instances) page(name string, pageSize int, bookmark string, args ...string) (*InstancePage, error) {
+	var page InstancePage
+	err := evaluatePage(i.contract, &page, name, pageSize, bookmark, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &page, nil
+}
+
+// StartSession opens a session on an instance owned by the identity of the
+// client. When the usage quota of the lab is used up, the error is the
+// QuotaDenial.
+func (i *Instances) StartSession(instanceID string) error {
+	var denial *QuotaDenial
+	err := submit(i.contract, &denial, "StartSession", instanceID)
+	if err != nil {
+		return err
+	}
+	if denial != nil {
+		return denial
+	}
+
+	return nil
+}
+
+// StopSession closes the open session of an instance
+func (i *Instances) StopSession(instanceID string) error {
+	return submit(i.contract, nil, "StopSession", instanceID)
+}
+
+// Sessions returns the sessions of an instance, first session first
+func (i *Instances) Sessions(instanceID string) ([]*Session, error) {
+	var sessions []*Session
+	err := evaluate(i.contract, &sessions, "ListSessions", instanceID)
+	return sessions, err
+}
+
+// UsageReport returns the usage of a class per student and lab. labID and
+// owner narrow the report down when not empty.
+func (i *Instances) UsageReport(classID, labID, owner string) (*UsageReport, error) {
+	var report UsageReport
+	err := evaluate(i.contract, &report, "GetUsageReport", classID, labID, owner)
+	if err != nil {
+		return nil, err
+	}
+
+	return &report, nil
+}
+
+// History returns every version of an instance, newest last
+func (i *Instances) History(instanceID string) ([]*InstanceHistory, error) {
+	var history []*InstanceHistory
+	err := evaluate(i.contract, &history, "GetInstanceHistory", instanceID)
+	return history, err
+}
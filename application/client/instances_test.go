@@ -0,0 +1,255 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// call is a transaction received by a mockContract
+type call struct {
+	submit    bool
+	name      string
+	args      []string
+	transient map[string][]byte
+}
+
+// mockContract stands in for a gateway contract. It records the calls, with
+// the transient map of the Transient variants, and answers them from
+// results, keyed by transaction name, or fails them with err. A result of
+// type func([]string) interface{} is called with the arguments of each call,
+// for answers that depend on them like pages.
+type mockContract struct {
+	calls   []call
+	results map[string]interface{}
+	err     error
+}
+
+func (m *mockContract) respond(submit bool, name string, transient map[string][]byte, args []string) ([]byte, error) {
+	m.calls = append(m.calls, call{submit: submit, name: name, args: args, transient: transient})
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	result, ok := m.results[name]
+	if !ok {
+		return nil, nil
+	}
+	if respond, ok := result.(func([]string) interface{}); ok {
+		result = respond(args)
+	}
+
+	return json.Marshal(result)
+}
+
+func (m *mockContract) EvaluateTransaction(name string, args ...string) ([]byte, error) {
+	return m.respond(false, name, nil, args)
+}
+
+func (m *mockContract) SubmitTransaction(name string, args ...string) ([]byte, error) {
+	return m.respond(true, name, nil, args)
+}
+
+func (m *mockContract) EvaluateTransient(name string, transient map[string][]byte, args ...string) ([]byte, error) {
+	return m.respond(false, name, transient, args)
+}
+
+func (m *mockContract) SubmitTransient(name string, transient map[string][]byte, args ...string) ([]byte, error) {
+	return m.respond(true, name, transient, args)
+}
+
+// newMockClient returns a client whose contracts are all mock
+func newMockClient(mock *mockContract) *Client {
+	return NewWithContracts(func(name string) Contract {
+		return mock
+	})
+}
+
+var testInstance = &Instance{
+	ID:       "instance1",
+	ClassID:  "class1",
+	LabID:    "lab1",
+	Config:   `{"version":1,"image":"ubuntu:20.04"}`,
+	Owner:    "student1",
+	UsedTime: 3600,
+}
+
+func TestInstancesCreate(t *testing.T) {
+	mock := &mockContract{results: map[string]interface{}{"ReadInstance": testInstance}}
+	instances := newMockClient(mock).Instances()
+
+	instance, err := instances.Create("instance1", "lab1", "class1", testInstance.Config, "student1")
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if !reflect.DeepEqual(instance, testInstance) {
+		t.Errorf("Create returned %+v, want %+v", instance, testInstance)
+	}
+
+	want := []call{
+		{submit: true, name: "CreateInstance", args: []string{"instance1", "lab1", "class1", testInstance.Config, "student1"}},
+		{submit: false, name: "ReadInstance", args: []string{"instance1"}},
+	}
+	if !reflect.DeepEqual(mock.calls, want) {
+		t.Errorf("calls %+v, want %+v", mock.calls, want)
+	}
+}
+
+func TestInstancesCreateDenied(t *testing.T) {
+	denial := &QuotaDenial{Transaction: "CreateInstance", LabID: "lab1", Owner: "student1", Quota: "instances", Limit: 1, Used: 1}
+	mock := &mockContract{results: map[string]interface{}{"CreateInstance": denial}}
+	instances := newMockClient(mock).Instances()
+
+	_, err := instances.Create("instance1", "lab1", "class1", testInstance.Config, "")
+	var denied *QuotaDenial
+	if !errors.As(err, &denied) || !reflect.DeepEqual(denied, denial) {
+		t.Fatalf("Create returned %v, want the denial", err)
+	}
+	if len(mock.calls) != 1 {
+		t.Errorf("calls %+v, want CreateInstance only", mock.calls)
+	}
+}
+
+func TestInstancesDelete(t *testing.T) {
+	mock := &mockContract{}
+	instances := newMockClient(mock).Instances()
+
+	err := instances.Delete("instance1")
+	if err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+
+	want := []call{{submit: true, name: "DeleteInstance", args: []string{"instance1"}}}
+	if !reflect.DeepEqual(mock.calls, want) {
+		t.Errorf("calls %+v, want %+v", mock.calls, want)
+	}
+}
+
+func TestInstancesUpdateUsedTime(t *testing.T) {
+	mock := &mockContract{results: map[string]interface{}{"ReadInstance": testInstance}}
+	instances := newMockClient(mock).Instances()
+
+	_, err := instances.UpdateUsedTime("instance1", 7200)
+	if err != nil {
+		t.Fatalf("UpdateUsedTime: %v", err)
+	}
+
+	want := call{submit: true, name: "UpdateInstanceUsedTime", args: []string{"instance1", "7200"}}
+	if !reflect.DeepEqual(mock.calls[0], want) {
+		t.Errorf("call %+v, want %+v", mock.calls[0], want)
+	}
+}
+
+func TestInstancesList(t *testing.T) {
+	tests := []struct {
+		name string
+		list func(*Instances) *InstanceIterator
+		want call
+	}{
+		{
+			name: "range",
+			list: func(i *Instances) *InstanceIterator { return i.ListByRange("instance1", "instance9") },
+			want: call{name: "GetInstanceByRange", args: []string{"instance1", "instance9", "0", ""}},
+		},
+		{
+			name: "class",
+			list: func(i *Instances) *InstanceIterator { return i.ListByClass("class1") },
+			want: call{name: "GetInstancesByClass", args: []string{"class1", "0", ""}},
+		},
+		{
+			name: "lab",
+			list: func(i *Instances) *InstanceIterator { return i.ListByLab("lab1") },
+			want: call{name: "GetInstancesByLab", args: []string{"lab1", "0", ""}},
+		},
+		{
+			name: "owner",
+			list: func(i *Instances) *InstanceIterator { return i.ListByOwner("student1") },
+			want: call{name: "GetInstancesByOwner", args: []string{"student1", "0", ""}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			page := &InstancePage{Records: []*Instance{testInstance}, FetchedRecordsCount: 1}
+			mock := &mockContract{results: map[string]interface{}{test.want.name: page}}
+
+			instances, err := test.list(newMockClient(mock).Instances()).All()
+			if err != nil {
+				t.Fatalf("list: %v", err)
+			}
+			if !reflect.DeepEqual(instances, []*Instance{testInstance}) {
+				t.Errorf("list returned %+v", instances)
+			}
+			if !reflect.DeepEqual(mock.calls, []call{test.want}) {
+				t.Errorf("calls %+v, want %+v", mock.calls, []call{test.want})
+			}
+		})
+	}
+}
+
+func TestInstancesListEmpty(t *testing.T) {
+	mock := &mockContract{}
+
+	instances, err := newMockClient(mock).Instances().ListByLab("lab1").All()
+	if err != nil {
+		t.Fatalf("ListByLab: %v", err)
+	}
+	if len(instances) != 0 {
+		t.Errorf("ListByLab returned %+v, want none", instances)
+	}
+}
+
+func TestInstancesListPages(t *testing.T) {
+	second := *testInstance
+	second.ID = "instance2"
+	pages := map[string]*InstancePage{
+		"":          {Records: []*Instance{testInstance}, FetchedRecordsCount: 1, Bookmark: "instance2"},
+		"instance2": {Records: []*Instance{}, Bookmark: "instance3"},
+		"instance3": {Records: []*Instance{&second}, FetchedRecordsCount: 1},
+	}
+	mock := &mockContract{results: map[string]interface{}{
+		"GetInstancesByLab": func(args []string) interface{} { return pages[args[2]] },
+	}}
+
+	instances, err := newMockClient(mock).Instances().ListByLab("lab1").All()
+	if err != nil {
+		t.Fatalf("ListByLab: %v", err)
+	}
+	if !reflect.DeepEqual(instances, []*Instance{testInstance, &second}) {
+		t.Errorf("ListByLab returned %+v", instances)
+	}
+
+	var bookmarks []string
+	for _, call := range mock.calls {
+		bookmarks = append(bookmarks, call.args[2])
+	}
+	if !reflect.DeepEqual(bookmarks, []string{"", "instance2", "instance3"}) {
+		t.Errorf("fetched pages at bookmarks %q", bookmarks)
+	}
+}
+
+func TestInstancesListPageError(t *testing.T) {
+	mock := &mockContract{err: errors.New("page size 5000 is not between 1 and 1000")}
+
+	it := newMockClient(mock).Instances().ListByOwner("student1")
+	if it.Next() {
+		t.Fatal("Next succeeded, want an error")
+	}
+	if it.Err() == nil {
+		t.Error("Err returned nil after a failed page")
+	}
+}
+
+func TestInstancesError(t *testing.T) {
+	mock := &mockContract{err: errors.New("instance does not exist: instance1")}
+
+	_, err := newMockClient(mock).Instances().Get("instance1")
+	if err == nil {
+		t.Fatal("Get succeeded, want an error")
+	}
+	want := "failed to evaluate transaction: instance does not exist: instance1"
+	if err.Error() != want {
+		t.Errorf("error %q, want %q", err, want)
+	}
+}
@@ -0,0 +1,173 @@
+package client
+
+import (
+	"encoding/json"
+	"strconv"
+)
+
+// Labs calls the LabContract
+type Labs struct {
+	contract Contract
+}
+
+// List walks every lab
+func (l *Labs) List() *LabIterator {
+	return newLabIterator(func(bookmark string) (*LabPage, error) {
+		return l.ListPage(0, bookmark)
+	})
+}
+
+// ListPage returns a page of every lab
+func (l *Labs) ListPage(pageSize int, bookmark string) (*LabPage, error) {
+	var page LabPage
+	err := evaluatePage(l.contract, &page, "ReadLabs", pageSize, bookmark)
+	if err != nil {
+		return nil, err
+	}
+
+	return &page, nil
+}
+
+// ListByClass walks the labs of a class
+func (l *Labs) ListByClass(classID string) *LabIterator {
+	return newLabIterator(func(bookmark string) (*LabPage, error) {
+		return l.ListByClassPage(classID, 0, bookmark)
+	})
+}
+
+// ListByClassPage returns a page of the labs of a class
+func (l *Labs) ListByClassPage(classID string, pageSize int, bookmark string) (*LabPage, error) {
+	var page LabPage
+	err := evaluatePage(l.contract, &page, "GetLabsByClass", pageSize, bookmark, classID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &page, nil
+}
+
+// Get returns a lab
+func (l *Labs) Get(labID string) (*Lab, error) {
+	var lab Lab
+	err := evaluate(l.contract, &lab, "ReadLab", labID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &lab, nil
+}
+
+// Create creates a lab of a class owned by the identity of the client. The
+// times are RFC 3339 timestamps.
+func (l *Labs) Create(labID, classID, name, content, config, startTime, endTime string) (*Lab, error) {
+	err := submit(l.contract, nil, "CreateLab", labID, classID, name, content, config, startTime, endTime)
+	if err != nil {
+		return nil, err
+	}
+
+	return l.Get(labID)
+}
+
+// Update replaces the config, name, content and times of a lab
+func (l *Labs) Update(labID, config, name, content, startTime, endTime string) (*Lab, error) {
+	err := submit(l.contract, nil, "UpdateLab", labID, config, name, content, startTime, endTime)
+	if err != nil {
+		return nil, err
+	}
+
+	return l.Get(labID)
+}
+
+// UpdateContent replaces the content of a lab
+func (l *Labs) UpdateContent(labID, content string) (*Lab, error) {
+	err := submit(l.contract, nil, "UpdateLabContent", labID, content)
+	if err != nil {
+		return nil, err
+	}
+
+	return l.Get(labID)
+}
+
+// UpdateConfig replaces the config of a lab
+func (l *Labs) UpdateConfig(labID, config string) (*Lab, error) {
+	err := submit(l.contract, nil, "UpdateLabConfig", labID, config)
+	if err != nil {
+		return nil, err
+	}
+
+	return l.Get(labID)
+}
+
+// UpdateEndtime moves the end time of a lab
+func (l *Labs) UpdateEndtime(labID, endTime string) (*Lab, error) {
+	err := submit(l.contract, nil, "UpdateLabEndtime", labID, endTime)
+	if err != nil {
+		return nil, err
+	}
+
+	return l.Get(labID)
+}
+
+// UpdateLatePolicy sets whether submissions after the end time are accepted
+func (l *Labs) UpdateLatePolicy(labID, policy string) (*Lab, error) {
+	err := submit(l.contract, nil, "UpdateLabLatePolicy", labID, policy)
+	if err != nil {
+		return nil, err
+	}
+
+	return l.Get(labID)
+}
+
+// UpdateAttemptPolicy sets how many attempts students have and which one
+// counts
+func (l *Labs) UpdateAttemptPolicy(labID string, maxAttempts uint32, gradingPolicy string) (*Lab, error) {
+	err := submit(l.contract, nil, "UpdateLabAttemptPolicy", labID, strconv.FormatUint(uint64(maxAttempts), 10), gradingPolicy)
+	if err != nil {
+		return nil, err
+	}
+
+	return l.Get(labID)
+}
+
+// SetRubric replaces the rubric of a lab
+func (l *Labs) SetRubric(labID string, rubric []Criterion) (*Lab, error) {
+	rubricBytes, err := json.Marshal(rubric)
+	if err != nil {
+		return nil, err
+	}
+
+	err = submit(l.contract, nil, "SetLabRubric", labID, string(rubricBytes))
+	if err != nil {
+		return nil, err
+	}
+
+	return l.Get(labID)
+}
+
+// UpdateQuota replaces the quota of a lab
+func (l *Labs) UpdateQuota(labID string, quota Quota) (*Lab, error) {
+	quotaBytes, err := json.Marshal(quota)
+	if err != nil {
+		return nil, err
+	}
+
+	err = submit(l.contract, nil, "UpdateLabQuota", labID, string(quotaBytes))
+	if err != nil {
+		return nil, err
+	}
+
+	return l.Get(labID)
+}
+
+// Delete deletes a lab. policy is DeleteRestrict to refuse while the lab has
+// instances or submissions, or DeleteCascade to delete them along.
+func (l *Labs) Delete(labID, policy string) error {
+	return submit(l.contract, nil, "DeleteLab", labID, policy)
+}
+
+// History returns every version of a lab, newest last
+func (l *Labs) History(labID string) ([]*LabHistory, error) {
+	var history []*LabHistory
+	err := evaluate(l.contract, &history, "GetLabHistory", labID)
+	return history, err
+}
@@ -0,0 +1,340 @@
+package client
+
+// Every list transaction of the chaincode returns a page of records and the
+// bookmark of the next page, empty after the last page. A page size of 0
+// selects the default page size of the chaincode.
+
+// ClassPage is a page of classes
+type ClassPage struct {
+	Records             []*Class `json:"records"`
+	FetchedRecordsCount int32    `json:"fetchedRecordsCount"`
+	Bookmark            string   `json:"bookmark"`
+}
+
+// StudentPage is a page of the students of a roster
+type StudentPage struct {
+	Records             []string `json:"records"`
+	FetchedRecordsCount int32    `json:"fetchedRecordsCount"`
+	Bookmark            string   `json:"bookmark"`
+}
+
+// LabPage is a page of labs
+type LabPage struct {
+	Records             []*Lab `json:"records"`
+	FetchedRecordsCount int32  `json:"fetchedRecordsCount"`
+	Bookmark            string `json:"bookmark"`
+}
+
+// InstancePage is a page of instances
+type InstancePage struct {
+	Records             []*Instance `json:"records"`
+	FetchedRecordsCount int32       `json:"fetchedRecordsCount"`
+	Bookmark            string      `json:"bookmark"`
+}
+
+// SubmissionPage is a page of submissions
+type SubmissionPage struct {
+	Records             []*Submission `json:"records"`
+	FetchedRecordsCount int32         `json:"fetchedRecordsCount"`
+	Bookmark            string        `json:"bookmark"`
+}
+
+// pager fetches the pages of a list one after the other. fetch gets the
+// page at a bookmark and returns the bookmark of the next one.
+type pager struct {
+	fetch    func(bookmark string) (string, error)
+	bookmark string
+	done     bool
+	err      error
+}
+
+// next fetches the next page, false after the last page or on errors
+func (p *pager) next() bool {
+	if p.done || p.err != nil {
+		return false
+	}
+
+	bookmark, err := p.fetch(p.bookmark)
+	if err != nil {
+		p.err = err
+		return false
+	}
+
+	// a bookmark that doesn't move would fetch the same page forever
+	p.done = bookmark == "" || bookmark == p.bookmark
+	p.bookmark = bookmark
+
+	return true
+}
+
+// ClassIterator walks the classes of a list, fetching pages as needed:
+//
+//	it := c.Classes().List()
+//	for it.Next() {
+//		class := it.Class()
+//	}
+//	err := it.Err()
+type ClassIterator struct {
+	pager pager
+	page  *ClassPage
+	i     int
+}
+
+func newClassIterator(fetch func(bookmark string) (*ClassPage, error)) *ClassIterator {
+	it := &ClassIterator{}
+	it.pager.fetch = func(bookmark string) (string, error) {
+		page, err := fetch(bookmark)
+		if err != nil {
+			return "", err
+		}
+		it.page, it.i = page, -1
+		return page.Bookmark, nil
+	}
+
+	return it
+}
+
+// Next moves to the next class, false after the last one or on errors
+func (it *ClassIterator) Next() bool {
+	for it.page == nil || it.i+1 >= len(it.page.Records) {
+		if !it.pager.next() {
+			return false
+		}
+	}
+	it.i++
+
+	return true
+}
+
+// Class returns the current class
+func (it *ClassIterator) Class() *Class {
+	return it.page.Records[it.i]
+}
+
+// Err returns the error that stopped the iteration, if any
+func (it *ClassIterator) Err() error {
+	return it.pager.err
+}
+
+// All returns the remaining classes
+func (it *ClassIterator) All() ([]*Class, error) {
+	classes := []*Class{}
+	for it.Next() {
+		classes = append(classes, it.Class())
+	}
+
+	return classes, it.Err()
+}
+
+// StudentIterator walks the students of a roster, fetching pages as needed
+type StudentIterator struct {
+	pager pager
+	page  *StudentPage
+	i     int
+}
+
+func newStudentIterator(fetch func(bookmark string) (*StudentPage, error)) *StudentIterator {
+	it := &StudentIterator{}
+	it.pager.fetch = func(bookmark string) (string, error) {
+		page, err := fetch(bookmark)
+		if err != nil {
+			return "", err
+		}
+		it.page, it.i = page, -1
+		return page.Bookmark, nil
+	}
+
+	return it
+}
+
+// Next moves to the next student, false after the last one or on errors
+func (it *StudentIterator) Next() bool {
+	for it.page == nil || it.i+1 >= len(it.page.Records) {
+		if !it.pager.next() {
+			return false
+		}
+	}
+	it.i++
+
+	return true
+}
+
+// Student returns the client identity of the current student
+func (it *StudentIterator) Student() string {
+	return it.page.Records[it.i]
+}
+
+// Err returns the error that stopped the iteration, if any
+func (it *StudentIterator) Err() error {
+	return it.pager.err
+}
+
+// All returns the remaining students
+func (it *StudentIterator) All() ([]string, error) {
+	students := []string{}
+	for it.Next() {
+		students = append(students, it.Student())
+	}
+
+	return students, it.Err()
+}
+
+// LabIterator walks the labs of a list, fetching pages as needed
+type LabIterator struct {
+	pager pager
+	page  *LabPage
+	i     int
+}
+
+func newLabIterator(fetch func(bookmark string) (*LabPage, error)) *LabIterator {
+	it := &LabIterator{}
+	it.pager.fetch = func(bookmark string) (string, error) {
+		page, err := fetch(bookmark)
+		if err != nil {
+			return "", err
+		}
+		it.page, it.i = page, -1
+		return page.Bookmark, nil
+	}
+
+	return it
+}
+
+// Next moves to the next lab, false after the last one or on errors
+func (it *LabIterator) Next() bool {
+	for it.page == nil || it.i+1 >= len(it.page.Records) {
+		if !it.pager.next() {
+			return false
+		}
+	}
+	it.i++
+
+	return true
+}
+
+// Lab returns the current lab
+func (it *LabIterator) Lab() *Lab {
+	return it.page.Records[it.i]
+}
+
+// Err returns the error that stopped the iteration, if any
+func (it *LabIterator) Err() error {
+	return it.pager.err
+}
+
+// All returns the remaining labs
+func (it *LabIterator) All() ([]*Lab, error) {
+	labs := []*Lab{}
+	for it.Next() {
+		labs = append(labs, it.Lab())
+	}
+
+	return labs, it.Err()
+}
+
+// InstanceIterator walks the instances of a list, fetching pages as needed
+type InstanceIterator struct {
+	pager pager
+	page  *InstancePage
+	i     int
+}
+
+func newInstanceIterator(fetch func(bookmark string) (*InstancePage, error)) *InstanceIterator {
+	it := &InstanceIterator{}
+	it.pager.fetch = func(bookmark string) (string, error) {
+		page, err := fetch(bookmark)
+		if err != nil {
+			return "", err
+		}
+		it.page, it.i = page, -1
+		return page.Bookmark, nil
+	}
+
+	return it
+}
+
+// Next moves to the next instance, false after the last one or on errors
+func (it *InstanceIterator) Next() bool {
+	for it.page == nil || it.i+1 >= len(it.page.Records) {
+		if !it.pager.next() {
+			return false
+		}
+	}
+	it.i++
+
+	return true
+}
+
+// Instance returns the current instance
+func (it *InstanceIterator) Instance() *Instance {
+	return it.page.Records[it.i]
+}
+
+// Err returns the error that stopped the iteration, if any
+func (it *InstanceIterator) Err() error {
+	return it.pager.err
+}
+
+// All returns the remaining instances
+func (it *InstanceIterator) All() ([]*Instance, error) {
+	instances := []*Instance{}
+	for it.Next() {
+		instances = append(instances, it.Instance())
+	}
+
+	return instances, it.Err()
+}
+
+// SubmissionIterator walks the submissions of a list, fetching pages as
+// needed
+type SubmissionIterator struct {
+	pager pager
+	page  *SubmissionPage
+	i     int
+}
+
+func newSubmissionIterator(fetch func(bookmark string) (*SubmissionPage, error)) *SubmissionIterator {
+	it := &SubmissionIterator{}
+	it.pager.fetch = func(bookmark string) (string, error) {
+		page, err := fetch(bookmark)
+		if err != nil {
+			return "", err
+		}
+		it.page, it.i = page, -1
+		return page.Bookmark, nil
+	}
+
+	return it
+}
+
+// Next moves to the next submission, false after the last one or on errors
+func (it *SubmissionIterator) Next() bool {
+	for it.page == nil || it.i+1 >= len(it.page.Records) {
+		if !it.pager.next() {
+			return false
+		}
+	}
+	it.i++
+
+	return true
+}
+
+// Submission returns the current submission
+func (it *SubmissionIterator) Submission() *Submission {
+	return it.page.Records[it.i]
+}
+
+// Err returns the error that stopped the iteration, if any
+func (it *SubmissionIterator) Err() error {
+	return it.pager.err
+}
+
+// All returns the remaining submissions
+func (it *SubmissionIterator) All() ([]*Submission, error) {
+	submissions := []*Submission{}
+	for it.Next() {
+		submissions = append(submissions, it.Submission())
+	}
+
+	return submissions, it.Err()
+}
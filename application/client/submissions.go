@@ -0,0 +1,232 @@
+package client
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+)
+
+// saltLength is the number of random bytes of a generated grade salt
+const saltLength = 16
+
+// Submissions calls the SubmissionContract
+type Submissions struct {
+	contract Contract
+}
+
+// Create hands in the next attempt of owner for a lab under the given ID.
+// An empty owner is the identity of the client, staff owning the class may
+// name a student of the class instead. The artifact is passed in the
+// transient map.
+func (s *Submissions) Create(submissionID, labID, classID, owner string, artifact *Artifact) (*Submission, error) {
+	transient := map[string]interface{}{"artifact": artifact}
+	err := submitTransient(s.contract, nil, "CreateSubmission", transient, submissionID, labID, classID, owner)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.Get(submissionID)
+}
+
+// Submit hands in the next attempt of the identity of the client for a lab.
+// The transaction ID becomes the ID of the submission.
+func (s *Submissions) Submit(labID, classID string, artifact *Artifact) (*Submission, error) {
+	var submission Submission
+	transient := map[string]interface{}{"artifact": artifact}
+	err := submitTransient(s.contract, &submission, "SubmitAttempt", transient, labID, classID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &submission, nil
+}
+
+// Get returns a submission
+func (s *Submissions) Get(submissionID string) (*Submission, error) {
+	var submission Submission
+	err := evaluate(s.contract, &submission, "ReadSubmission", submissionID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &submission, nil
+}
+
+// Content returns the private content of a submission
+func (s *Submissions) Content(submissionID string) (*SubmissionContent, error) {
+	var content SubmissionContent
+	err := evaluate(s.contract, &content, "GetSubmissionContent", submissionID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &content, nil
+}
+
+// Delete deletes a submission
+func (s *Submissions) Delete(submissionID string) error {
+	return submit(s.contract, nil, "DeleteSubmission", submissionID)
+}
+
+// Grade scores a submission against the rubric of its lab. A random salt is
+// generated when details has none, it is returned by GetGrade later.
+func (s *Submissions) Grade(submissionID string, details *GradeDetails) error {
+	err := salt(details)
+	if err != nil {
+		return err
+	}
+
+	transient := map[string]interface{}{"grade": details}
+	return submitTransient(s.contract, nil, "GradeSubmission", transient, submissionID)
+}
+
+// UpdateScore grades a submission with a bare total instead of the rubric of
+// its lab. A random salt is generated when details has none.
+func (s *Submissions) UpdateScore(submissionID string, details *GradeDetails) error {
+	err := salt(details)
+	if err != nil {
+		return err
+	}
+
+	transient := map[string]interface{}{"grade": details}
+	return submitTransient(s.contract, nil, "UpdateSubmissionScore", transient, submissionID)
+}
+
+// ReleaseGrade makes the draft grade of a submission visible to its owner
+func (s *Submissions) ReleaseGrade(submissionID string) error {
+	return submit(s.contract, nil, "ReleaseGrade", submissionID)
+}
+
+// GetGrade returns the private grade of a submission
+func (s *Submissions) GetGrade(submissionID string) (*GradeDetails, error) {
+	var details GradeDetails
+	err := evaluate(s.contract, &details, "GetGrade", submissionID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &details, nil
+}
+
+// VerifyGrade returns true when details, including the salt, match the grade
+// recorded for a submission
+func (s *Submissions) VerifyGrade(submissionID string, details *GradeDetails) (bool, error) {
+	var ok bool
+	transient := map[string]interface{}{"grade": details}
+	err := evaluateTransient(s.contract, &ok, "VerifyGrade", transient, submissionID)
+	return ok, err
+}
+
+// ListByRange walks the submissions with IDs from startKey up to, but not
+// including, endKey
+func (s *Submissions) ListByRange(startKey, endKey string) *SubmissionIterator {
+	return newSubmissionIterator(func(bookmark string) (*SubmissionPage, error) {
+		return s.ListByRangePage(startKey, endKey, 0, bookmark)
+	})
+}
+
+// ListByRangePage returns a page of the submissions with IDs from startKey up
+// to, but not including, endKey
+func (s *Submissions) ListByRangePage(startKey, endKey string, pageSize int, bookmark string) (*SubmissionPage, error) {
+	return s.page("GetSubmissionByRange", pageSize, bookmark, startKey, endKey)
+}
+
+// ListByClass walks the submissions of a class
+func (s *Submissions) ListByClass(classID string) *SubmissionIterator {
+	return newSubmissionIterator(func(bookmark string) (*SubmissionPage, error) {
+		return s.ListByClassPage(classID, 0, bookmark)
+	})
+}
+
+// ListByClassPage returns a page of the submissions of a class
+func (s *Submissions) ListByClassPage(classID string, pageSize int, bookmark string) (*SubmissionPage, error) {
+	return s.page("GetSubmissionsByClass", pageSize, bookmark, classID)
+}
+
+// ListByLab walks the submissions of a lab
+func (s *Submissions) ListByLab(labID string) *SubmissionIterator {
+	return newSubmissionIterator(func(bookmark string) (*SubmissionPage, error) {
+		return s.ListByLabPage(labID, 0, bookmark)
+	})
+}
+
+// ListByLabPage returns a page of the submissions of a lab
+func (s *Submissions) ListByLabPage(labID string, pageSize int, bookmark string) (*SubmissionPage, error) {
+	return s.page("GetSubmissionsByLab", pageSize, bookmark, labID)
+}
+
+// ListByOwner walks the submissions of a student
+func (s *Submissions) ListByOwner(owner string) *SubmissionIterator {
+	return newSubmissionIterator(func(bookmark string) (*SubmissionPage, error) {
+		return s.ListByOwnerPage(owner, 0, bookmark)
+	})
+}
+
+// ListByOwnerPage returns a page of the submissions of a student
+func (s *Submissions) ListByOwnerPage(owner string, pageSize int, bookmark string) (*SubmissionPage, error) {
+	return s.page("GetSubmissionsByOwner", pageSize, bookmark, owner)
+}
+
+// page evaluates a list transaction of submissions
+func (s *Submissions) page(name string, pageSize int, bookmark string, args ...string) (*SubmissionPage, error) {
+	var page SubmissionPage
+	err := evaluatePage(s.contract, &page, name, pageSize, bookmark, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &page, nil
+}
+
+// Attempts returns the attempts of owner for a lab, first attempt first. An
+// empty owner lists the attempts of the identity of the client.
+func (s *Submissions) Attempts(labID, owner string) ([]*Submission, error) {
+	var submissions []*Submission
+	err := evaluate(s.contract, &submissions, "ListAttempts", labID, owner)
+	return submissions, err
+}
+
+// LatestAttempt returns the last attempt of owner for a lab
+func (s *Submissions) LatestAttempt(labID, owner string) (*Submission, error) {
+	var submission Submission
+	err := evaluate(s.contract, &submission, "GetLatestAttempt", labID, owner)
+	if err != nil {
+		return nil, err
+	}
+
+	return &submission, nil
+}
+
+// GradedAttempt returns the attempt of owner that counts for a lab according
+// to its grading policy
+func (s *Submissions) GradedAttempt(labID, owner string) (*Submission, error) {
+	var submission Submission
+	err := evaluate(s.contract, &submission, "GetGradedAttempt", labID, owner)
+	if err != nil {
+		return nil, err
+	}
+
+	return &submission, nil
+}
+
+// History returns every version of a submission, newest last
+func (s *Submissions) History(submissionID string) ([]*SubmissionHistory, error) {
+	var history []*SubmissionHistory
+	err := evaluate(s.contract, &history, "GetSubmissionHistory", submissionID)
+	return history, err
+}
+
+// salt fills in a random salt when details has none
+func salt(details *GradeDetails) error {
+	if details.Salt != "" {
+		return nil
+	}
+
+	saltBytes := make([]byte, saltLength)
+	_, err := rand.Read(saltBytes)
+	if err != nil {
+		return err
+	}
+	details.Salt = hex.EncodeToString(saltBytes)
+
+	return nil
+}
@@ -0,0 +1,273 @@
+package client
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+var testSubmission = &Submission{
+	ID:          "submission1",
+	ClassID:     "class1",
+	LabID:       "lab1",
+	Owner:       "student1",
+	Attempt:     1,
+	SubmittedAt: "2022-10-01T12:00:00Z",
+	Collection:  "Org1MSPPrivateCollection",
+}
+
+var testArtifact = &Artifact{
+	SHA256:    "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08",
+	Size:      4,
+	MediaType: "text/plain",
+	URI:       "file:///var/labplatform/artifacts/sha256/9f86d081",
+}
+
+// transientValue decodes the JSON value of key in the transient map of a call
+func transientValue(t *testing.T, c call, key string, v interface{}) {
+	t.Helper()
+
+	value, ok := c.transient[key]
+	if !ok {
+		t.Fatalf("%s: no %q in transient map %v", c.name, key, c.transient)
+	}
+	err := json.Unmarshal(value, v)
+	if err != nil {
+		t.Fatalf("%s: transient %q: %v", c.name, key, err)
+	}
+}
+
+func TestSubmissionsCreate(t *testing.T) {
+	mock := &mockContract{results: map[string]interface{}{"ReadSubmission": testSubmission}}
+	submissions := newMockClient(mock).Submissions()
+
+	submission, err := submissions.Create("submission1", "lab1", "class1", "student1", testArtifact)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if !reflect.DeepEqual(submission, testSubmission) {
+		t.Errorf("Create returned %+v, want %+v", submission, testSubmission)
+	}
+
+	if len(mock.calls) != 2 {
+		t.Fatalf("calls %+v, want CreateSubmission and ReadSubmission", mock.calls)
+	}
+	create := mock.calls[0]
+	if !create.submit || create.name != "CreateSubmission" || !reflect.DeepEqual(create.args, []string{"submission1", "lab1", "class1", "student1"}) {
+		t.Errorf("call %+v", create)
+	}
+	var artifact Artifact
+	transientValue(t, create, "artifact", &artifact)
+	if artifact != *testArtifact {
+		t.Errorf("transient artifact %+v, want %+v", artifact, testArtifact)
+	}
+	if len(create.transient) != 1 {
+		t.Errorf("transient map %v, want the artifact only", create.transient)
+	}
+	for _, arg := range create.args {
+		if arg == testArtifact.URI {
+			t.Error("artifact passed as an argument, out of the transient map")
+		}
+	}
+
+	read := call{submit: false, name: "ReadSubmission", args: []string{"submission1"}}
+	if !reflect.DeepEqual(mock.calls[1], read) {
+		t.Errorf("call %+v, want %+v", mock.calls[1], read)
+	}
+}
+
+func TestSubmissionsSubmit(t *testing.T) {
+	mock := &mockContract{results: map[string]interface{}{"SubmitAttempt": testSubmission}}
+	submissions := newMockClient(mock).Submissions()
+
+	submission, err := submissions.Submit("lab1", "class1", testArtifact)
+	if err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+	if !reflect.DeepEqual(submission, testSubmission) {
+		t.Errorf("Submit returned %+v, want %+v", submission, testSubmission)
+	}
+
+	if len(mock.calls) != 1 {
+		t.Fatalf("calls %+v, want SubmitAttempt only", mock.calls)
+	}
+	c := mock.calls[0]
+	if !c.submit || c.name != "SubmitAttempt" || !reflect.DeepEqual(c.args, []string{"lab1", "class1"}) {
+		t.Errorf("call %+v", c)
+	}
+	var artifact Artifact
+	transientValue(t, c, "artifact", &artifact)
+	if artifact != *testArtifact {
+		t.Errorf("transient artifact %+v, want %+v", artifact, testArtifact)
+	}
+}
+
+func TestSubmissionsGradeSalt(t *testing.T) {
+	tests := []struct {
+		name  string
+		grade func(*Submissions, *GradeDetails) error
+		want  string
+	}{
+		{"rubric", func(s *Submissions, d *GradeDetails) error { return s.Grade("submission1", d) }, "GradeSubmission"},
+		{"score", func(s *Submissions, d *GradeDetails) error { return s.UpdateScore("submission1", d) }, "UpdateSubmissionScore"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mock := &mockContract{}
+			submissions := newMockClient(mock).Submissions()
+
+			details := &GradeDetails{Scores: []CriterionScore{{Criterion: "routes", Points: 8}}, Total: 8, Feedback: "good"}
+			err := test.grade(submissions, details)
+			if err != nil {
+				t.Fatalf("%s: %v", test.want, err)
+			}
+			if len(details.Salt) != 2*saltLength {
+				t.Errorf("generated salt %q, want %d hex digits", details.Salt, 2*saltLength)
+			}
+
+			c := mock.calls[0]
+			if !c.submit || c.name != test.want || !reflect.DeepEqual(c.args, []string{"submission1"}) {
+				t.Errorf("call %+v", c)
+			}
+			var sent GradeDetails
+			transientValue(t, c, "grade", &sent)
+			if !reflect.DeepEqual(&sent, details) {
+				t.Errorf("transient grade %+v, want %+v", sent, details)
+			}
+
+			// a salt given by the caller is kept, so the grade can be verified
+			mock.calls = nil
+			err = test.grade(submissions, &GradeDetails{Salt: "0123456789abcdef", Total: 5})
+			if err != nil {
+				t.Fatalf("%s: %v", test.want, err)
+			}
+			transientValue(t, mock.calls[0], "grade", &sent)
+			if sent.Salt != "0123456789abcdef" {
+				t.Errorf("salt %q replaced", sent.Salt)
+			}
+		})
+	}
+}
+
+func TestSubmissionsVerifyGrade(t *testing.T) {
+	mock := &mockContract{results: map[string]interface{}{"VerifyGrade": true}}
+	submissions := newMockClient(mock).Submissions()
+
+	details := &GradeDetails{Salt: "0123456789abcdef", Total: 8}
+	ok, err := submissions.VerifyGrade("submission1", details)
+	if err != nil {
+		t.Fatalf("VerifyGrade: %v", err)
+	}
+	if !ok {
+		t.Error("VerifyGrade returned false")
+	}
+
+	c := mock.calls[0]
+	if c.submit || c.name != "VerifyGrade" || !reflect.DeepEqual(c.args, []string{"submission1"}) {
+		t.Errorf("call %+v", c)
+	}
+	var sent GradeDetails
+	transientValue(t, c, "grade", &sent)
+	if !reflect.DeepEqual(&sent, details) {
+		t.Errorf("transient grade %+v, want %+v", sent, details)
+	}
+}
+
+func TestSubmissionsCalls(t *testing.T) {
+	tests := []struct {
+		name string
+		run  func(*Submissions) error
+		want call
+	}{
+		{
+			name: "delete",
+			run:  func(s *Submissions) error { return s.Delete("submission1") },
+			want: call{submit: true, name: "DeleteSubmission", args: []string{"submission1"}},
+		},
+		{
+			name: "release",
+			run:  func(s *Submissions) error { return s.ReleaseGrade("submission1") },
+			want: call{submit: true, name: "ReleaseGrade", args: []string{"submission1"}},
+		},
+		{
+			name: "content",
+			run:  func(s *Submissions) error { _, err := s.Content("submission1"); return err },
+			want: call{name: "GetSubmissionContent", args: []string{"submission1"}},
+		},
+		{
+			name: "attempts",
+			run:  func(s *Submissions) error { _, err := s.Attempts("lab1", ""); return err },
+			want: call{name: "ListAttempts", args: []string{"lab1", ""}},
+		},
+		{
+			name: "graded attempt",
+			run:  func(s *Submissions) error { _, err := s.GradedAttempt("lab1", "student1"); return err },
+			want: call{name: "GetGradedAttempt", args: []string{"lab1", "student1"}},
+		},
+		{
+			name: "range page",
+			run:  func(s *Submissions) error { _, err := s.ListByRangePage("a", "z", 25, "m"); return err },
+			want: call{name: "GetSubmissionByRange", args: []string{"a", "z", "25", "m"}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mock := &mockContract{}
+			err := test.run(newMockClient(mock).Submissions())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(mock.calls, []call{test.want}) {
+				t.Errorf("calls %+v, want %+v", mock.calls, []call{test.want})
+			}
+		})
+	}
+}
+
+func TestSubmissionsListPages(t *testing.T) {
+	second := *testSubmission
+	second.ID = "submission2"
+	second.Attempt = 2
+	pages := map[string]*SubmissionPage{
+		"":            {Records: []*Submission{testSubmission}, FetchedRecordsCount: 1, Bookmark: "submission2"},
+		"submission2": {Records: []*Submission{&second}, FetchedRecordsCount: 1},
+	}
+
+	tests := []struct {
+		name string
+		list func(*Submissions) *SubmissionIterator
+		want string
+		args []string
+	}{
+		{"class", func(s *Submissions) *SubmissionIterator { return s.ListByClass("class1") }, "GetSubmissionsByClass", []string{"class1"}},
+		{"lab", func(s *Submissions) *SubmissionIterator { return s.ListByLab("lab1") }, "GetSubmissionsByLab", []string{"lab1"}},
+		{"owner", func(s *Submissions) *SubmissionIterator { return s.ListByOwner("student1") }, "GetSubmissionsByOwner", []string{"student1"}},
+		{"range", func(s *Submissions) *SubmissionIterator { return s.ListByRange("a", "z") }, "GetSubmissionByRange", []string{"a", "z"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mock := &mockContract{results: map[string]interface{}{
+				test.want: func(args []string) interface{} { return pages[args[len(args)-1]] },
+			}}
+
+			submissions, err := test.list(newMockClient(mock).Submissions()).All()
+			if err != nil {
+				t.Fatalf("list: %v", err)
+			}
+			if !reflect.DeepEqual(submissions, []*Submission{testSubmission, &second}) {
+				t.Errorf("list returned %+v", submissions)
+			}
+
+			want := []call{
+				{name: test.want, args: append(append([]string(nil), test.args...), "0", "")},
+				{name: test.want, args: append(append([]string(nil), test.args...), "0", "submission2")},
+			}
+			if !reflect.DeepEqual(mock.calls, want) {
+				t.Errorf("calls %+v, want %+v", mock.calls, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,205 @@
+package client
+
+import "fmt"
+
+// The types mirror the documents of the labplatform chaincode
+
+// Delete policies of DeleteClass and DeleteLab: restrict refuses to delete
+// while there are dependents, cascade deletes them along.
+const (
+	DeleteRestrict = "restrict"
+	DeleteCascade  = "cascade"
+)
+
+// Class is a course run by its owner
+type Class struct {
+	ID      string `json:"ID"`
+	Name    string `json:"name"`
+	Content string `json:"content"`
+	Owner   string `json:"owner"`
+	OrgMSP  string `json:"orgMSP"`
+}
+
+// Lab is an assignment of a class. Config is the environment of its
+// instances, see chaincode/labconfig.
+type Lab struct {
+	ID            string      `json:"ID"`
+	ClassID       string      `json:"classID"`
+	Name          string      `json:"name"`
+	Content       string      `json:"content"`
+	Config        string      `json:"config"`
+	StartTime     string      `json:"startTime"`
+	EndTime       string      `json:"endTime"`
+	LatePolicy    string      `json:"latePolicy"`
+	Rubric        []Criterion `json:"rubric,omitempty"`
+	MaxAttempts   uint32      `json:"maxAttempts"`
+	GradingPolicy string      `json:"gradingPolicy"`
+	Quota         *Quota      `json:"quota,omitempty"`
+	Owner         string      `json:"owner"`
+}
+
+// Criterion is a part of the rubric of a lab
+type Criterion struct {
+	Name      string `json:"name"`
+	MaxPoints uint32 `json:"maxPoints"`
+}
+
+// Quota limits the instances students run for a lab
+type Quota struct {
+	MaxInstancesPerStudent uint32   `json:"maxInstancesPerStudent"`
+	MaxUsageHours          uint32   `json:"maxUsageHours"`
+	AllowedConfigs         []string `json:"allowedConfigs,omitempty"`
+}
+
+// QuotaDenial is a CreateInstance or StartSession the quota of a lab refused.
+// The chaincode commits it, so that it stays on the ledger, and the client
+// returns it as error.
+type QuotaDenial struct {
+	Transaction string `json:"transaction"`
+	TxID        string `json:"txID"`
+	DeniedAt    string `json:"deniedAt"`
+	LabID       string `json:"labID"`
+	Owner       string `json:"owner"`
+	InstanceID  string `json:"instanceID,omitempty"`
+	Quota       string `json:"quota"`
+	Limit       uint64 `json:"limit"`
+	Used        uint64 `json:"used"`
+}
+
+func (d *QuotaDenial) Error() string {
+	return fmt.Sprintf("quota exceeded for lab %s: %s is %d, limit is %d", d.LabID, d.Quota, d.Used, d.Limit)
+}
+
+// ClassHistory is a version of a class. Class is nil for deletes.
+type ClassHistory struct {
+	TxID      string `json:"txID"`
+	Timestamp string `json:"timestamp"`
+	IsDelete  bool   `json:"isDelete"`
+	Class     *Class `json:"class,omitempty"`
+}
+
+// LabHistory is a version of a lab. Lab is nil for deletes.
+type LabHistory struct {
+	TxID      string `json:"txID"`
+	Timestamp string `json:"timestamp"`
+	IsDelete  bool   `json:"isDelete"`
+	Lab       *Lab   `json:"lab,omitempty"`
+}
+
+// Grade states of a submission
+const (
+	GradeDraft    = "draft"
+	GradeReleased = "released"
+	GradeRegraded = "regraded"
+)
+
+// Submission is the work a student handed in for a lab. The artifact and the
+// grade details are private data, see SubmissionContent and GradeDetails.
+type Submission struct {
+	ID          string `json:"ID"`
+	ClassID     string `json:"classID"`
+	LabID       string `json:"labID"`
+	Owner       string `json:"owner"`
+	Attempt     uint32 `json:"attempt"`
+	SubmittedAt string `json:"submittedAt"`
+	Late        bool   `json:"late"`
+	Collection  string `json:"collection"`
+	Grade       *Grade `json:"grade,omitempty"`
+}
+
+// Artifact references the work of a submission stored off-chain, see
+// application/artifact
+type Artifact struct {
+	SHA256    string `json:"sha256"`
+	Size      uint64 `json:"size"`
+	MediaType string `json:"mediaType"`
+	URI       string `json:"uri"`
+}
+
+// SubmissionContent is the private part of a submission
+type SubmissionContent struct {
+	Artifact *Artifact `json:"artifact"`
+}
+
+// Grade records who graded a submission and when. Regrade marks a draft
+// replacing a released grade, it is released as regraded.
+type Grade struct {
+	Grader   string `json:"grader"`
+	GradedAt string `json:"gradedAt"`
+	State    string `json:"state"`
+	Regrade  bool   `json:"regrade,omitempty"`
+}
+
+// GradeDetails is the private part of a grade. Salt keeps the hash on the
+// ledger from giving the grade away, Submissions.Grade fills it in when
+// empty.
+type GradeDetails struct {
+	Salt     string           `json:"salt"`
+	Scores   []CriterionScore `json:"scores,omitempty"`
+	Total    uint32           `json:"total"`
+	Feedback string           `json:"feedback"`
+}
+
+// CriterionScore is the points a submission earned for one rubric criterion
+type CriterionScore struct {
+	Criterion string `json:"criterion"`
+	Points    uint32 `json:"points"`
+}
+
+// SubmissionHistory is a version of a submission. Submission is nil for
+// deletes.
+type SubmissionHistory struct {
+	TxID       string      `json:"txID"`
+	Timestamp  string      `json:"timestamp"`
+	IsDelete   bool        `json:"isDelete"`
+	Submission *Submission `json:"submission,omitempty"`
+}
+
+// Instance is a lab environment of a student. UsedTime is the number of
+// seconds the instance ran in closed sessions, ActiveSession is the number of
+// the open session or 0.
+type Instance struct {
+	ID            string `json:"ID"`
+	ClassID       string `json:"classID"`
+	LabID         string `json:"labID"`
+	Config        string `json:"config"`
+	Owner         string `json:"owner"`
+	UsedTime      uint64 `json:"usedtime"`
+	ActiveSession uint32 `json:"activeSession"`
+}
+
+// Session is one interval an instance was running. StoppedAt is empty while
+// the session is open.
+type Session struct {
+	InstanceID string `json:"instanceID"`
+	Number     uint32 `json:"number"`
+	StartedAt  string `json:"startedAt"`
+	StoppedAt  string `json:"stoppedAt,omitempty"`
+	Seconds    uint64 `json:"seconds"`
+}
+
+// UsageEntry is the usage of the instances of one student for one lab,
+// deleted instances included
+type UsageEntry struct {
+	ClassID string `json:"classID"`
+	LabID   string `json:"labID"`
+	Owner   string `json:"owner"`
+	Seconds uint64 `json:"seconds"`
+}
+
+// UsageReport is the usage of a class as the usage quota counts it. Open
+// sessions are left out until they are stopped.
+type UsageReport struct {
+	ClassID      string        `json:"classID"`
+	GeneratedAt  string        `json:"generatedAt"`
+	Entries      []*UsageEntry `json:"entries"`
+	TotalSeconds uint64        `json:"totalSeconds"`
+}
+
+// InstanceHistory is a version of an instance. Instance is nil for deletes.
+type InstanceHistory struct {
+	TxID      string    `json:"txID"`
+	Timestamp string    `json:"timestamp"`
+	IsDelete  bool      `json:"isDelete"`
+	Instance  *Instance `json:"instance,omitempty"`
+}
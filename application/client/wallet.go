@@ -0,0 +1,42 @@
+package client
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/gateway"
+)
+
+// populateWallet adds the default identity of cfg to the wallet from its msp
+// directory
+func populateWallet(wallet *gateway.Wallet, cfg Config) error {
+	cert, err := readFirstFile(filepath.Join(cfg.CredentialPath, "signcerts"))
+	if err != nil {
+		return err
+	}
+
+	key, err := readFirstFile(filepath.Join(cfg.CredentialPath, "keystore"))
+	if err != nil {
+		return err
+	}
+
+	identity := gateway.NewX509Identity(cfg.MSPID, string(cert), string(key))
+
+	return wallet.Put(cfg.Identity, identity)
+}
+
+// readFirstFile reads the first file of dir. The signcerts and keystore
+// directories hold a single certificate or key, start_network.sh only adds
+// copies of it under a fixed name.
+func readFirstFile(dir string) ([]byte, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("%s folder is empty", dir)
+	}
+
+	return ioutil.ReadFile(filepath.Clean(filepath.Join(dir, files[0].Name())))
+}
@@ -0,0 +1,107 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// Checkpoint is the position of a listener: the block it is in and the
+// transactions of that block it already handled. Blocks before BlockNumber
+// are handled completely.
+type Checkpoint struct {
+	BlockNumber uint64   `json:"blockNumber"`
+	TxIDs       []string `json:"txIDs"`
+}
+
+// handled reports whether the event was handled before the checkpoint
+func (c *Checkpoint) handled(event *Event) bool {
+	if event.BlockNumber != c.BlockNumber {
+		return event.BlockNumber < c.BlockNumber
+	}
+
+	for _, txID := range c.TxIDs {
+		if txID == event.TxID {
+			return true
+		}
+	}
+
+	return false
+}
+
+// advance moves the checkpoint past event
+func (c *Checkpoint) advance(event *Event) {
+	if event.BlockNumber != c.BlockNumber {
+		c.BlockNumber = event.BlockNumber
+		c.TxIDs = nil
+	}
+
+	c.TxIDs = append(c.TxIDs, event.TxID)
+}
+
+// Checkpointer persists the checkpoint of a listener
+type Checkpointer interface {
+	// Load returns the saved checkpoint, the zero checkpoint when none was
+	// saved yet.
+	Load() (*Checkpoint, error)
+	// Save replaces the saved checkpoint
+	Save(checkpoint *Checkpoint) error
+}
+
+// FileCheckpointer keeps a checkpoint in a JSON file
+type FileCheckpointer struct {
+	path string
+}
+
+// NewFileCheckpointer returns a checkpointer writing to path
+func NewFileCheckpointer(path string) *FileCheckpointer {
+	return &FileCheckpointer{path: path}
+}
+
+// Load reads the checkpoint file
+func (c *FileCheckpointer) Load() (*Checkpoint, error) {
+	checkpointBytes, err := ioutil.ReadFile(filepath.Clean(c.path))
+	if os.IsNotExist(err) {
+		return &Checkpoint{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read checkpoint: %v", err)
+	}
+
+	var checkpoint Checkpoint
+	err = json.Unmarshal(checkpointBytes, &checkpoint)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse checkpoint %s: %v", c.path, err)
+	}
+
+	return &checkpoint, nil
+}
+
+// Save writes the checkpoint to a temporary file first and moves it over
+// the checkpoint file, so a crash never leaves a partial checkpoint.
+func (c *FileCheckpointer) Save(checkpoint *Checkpoint) error {
+	checkpointBytes, err := json.Marshal(checkpoint)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(c.path), "checkpoint-")
+	if err != nil {
+		return fmt.Errorf("failed to create checkpoint file: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.Write(checkpointBytes)
+	if err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write checkpoint: %v", err)
+	}
+	err = tmp.Close()
+	if err != nil {
+		return fmt.Errorf("failed to write checkpoint: %v", err)
+	}
+
+	return os.Rename(tmp.Name(), c.path)
+}
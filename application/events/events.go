@@ -0,0 +1,107 @@
+// Package events delivers the chaincode events of the labplatform chaincode
+// to Go handlers. A Listener records how far it got in a Checkpointer, so a
+// restarted listener neither misses nor replays events.
+package events
+
+import (
+	"encoding/json"
+)
+
+// Names of the events emitted by the labplatform chaincode. Class, lab,
+// instance, session and submission events carry the document as the
+// chaincode stores it, the others carry the payload type noted next to them.
+// InstanceUpdated and SessionStopped add a UsageQuota to the document.
+const (
+	ClassCreated     = "ClassCreated"
+	ClassUpdated     = "ClassUpdated"
+	ClassTransferred = "ClassTransferred"
+	ClassDeleted     = "ClassDeleted"    // Deletion
+	StudentEnrolled  = "StudentEnrolled" // Enrollment
+	StudentDropped   = "StudentDropped"  // Enrollment
+
+	LabCreated = "LabCreated"
+	LabUpdated = "LabUpdated"
+	LabDeleted = "LabDeleted" // Deletion
+
+	InstanceCreated = "InstanceCreated"
+	InstanceUpdated = "InstanceUpdated"
+	InstanceDeleted = "InstanceDeleted" // Deletion
+	SessionStarted  = "SessionStarted"
+	SessionStopped  = "SessionStopped"
+	QuotaExceeded   = "QuotaExceeded" // Quota
+
+	SubmissionCreated = "SubmissionCreated"
+	SubmissionGraded  = "SubmissionGraded"  // Grade
+	GradeReleased     = "GradeReleased"     // Grade
+	SubmissionDeleted = "SubmissionDeleted" // Deletion
+)
+
+// Event is a chaincode event of a committed transaction
+type Event struct {
+	Name        string
+	TxID        string
+	BlockNumber uint64
+	Payload     []byte
+}
+
+// Decode unmarshals the JSON payload of the event into v
+func (e *Event) Decode(v interface{}) error {
+	return json.Unmarshal(e.Payload, v)
+}
+
+// Handler handles an event. An error stops the listener before the event is
+// checkpointed, so the event is delivered again after a restart.
+type Handler func(event *Event) error
+
+// Deletion is the payload of the events of deleted documents. Policy is set
+// for classes and labs, their dependents don't get events of their own.
+type Deletion struct {
+	ID     string `json:"ID"`
+	Policy string `json:"policy,omitempty"`
+}
+
+// Enrollment is the payload of the StudentEnrolled and StudentDropped events
+type Enrollment struct {
+	ClassID string `json:"classID"`
+	Student string `json:"student"`
+}
+
+// Grade is the payload of the SubmissionGraded and GradeReleased events
+type Grade struct {
+	SubmissionID string `json:"submissionID"`
+	ClassID      string `json:"classID"`
+	LabID        string `json:"labID"`
+	Owner        string `json:"owner"`
+	State        string `json:"state"`
+}
+
+// Quota is the payload of the QuotaExceeded event, a CreateInstance or
+// StartSession the quota of a lab refused
+type Quota struct {
+	Transaction string `json:"transaction"`
+	TxID        string `json:"txID"`
+	DeniedAt    string `json:"deniedAt"`
+	LabID       string `json:"labID"`
+	Owner       string `json:"owner"`
+	InstanceID  string `json:"instanceID,omitempty"`
+	Quota       string `json:"quota"`
+	Limit       uint64 `json:"limit"`
+	Used        uint64 `json:"used"`
+}
+
+// UsageQuota is part of the payload of the InstanceUpdated and SessionStopped
+// events. QuotaExceeded is set when the change took the usage of the owner
+// over the usage quota of the lab, the change is recorded all the same.
+type UsageQuota struct {
+	QuotaExceeded *QuotaBreach `json:"quotaExceeded,omitempty"`
+}
+
+// QuotaBreach is a usage quota the owner of an instance went over
+type QuotaBreach struct {
+	LabID      string `json:"labID"`
+	Owner      string `json:"owner"`
+	InstanceID string `json:"instanceID,omitempty"`
+	Quota      string `json:"quota"`
+	Limit      uint64 `json:"limit"`
+	Used       uint64 `json:"used"`
+}
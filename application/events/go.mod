@@ -0,0 +1,5 @@
+module events
+
+go 1.14
+
+require github.com/hyperledger/fabric-sdk-go v1.0.0-rc1
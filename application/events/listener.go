@@ -0,0 +1,127 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+)
+
+// Source delivers the chaincode events of a channel. A gateway network
+// connected with gateway.WithBlockNum, along with a Close closing its
+// gateway, is a Source.
+type Source interface {
+	RegisterChaincodeEvent(chaincodeID, eventFilter string) (fab.Registration, <-chan *fab.CCEvent, error)
+	Unregister(registration fab.Registration)
+	Close()
+}
+
+// Connect opens a Source delivering the events from block blockNum on
+type Connect func(blockNum uint64) (Source, error)
+
+// Listener dispatches the chaincode events of a network to the handlers
+// registered for their name. The events of a transaction are handled at
+// least once: the checkpoint is saved after the handlers of an event
+// returned, so a crash in between delivers that event again.
+//
+// Run connects at the block of the saved checkpoint, so a restarted
+// listener resumes where it stopped. Events of that block that were
+// already handled are skipped.
+type Listener struct {
+	connect      Connect
+	chaincodeID  string
+	checkpointer Checkpointer
+	handlers     map[string][]Handler
+	all          []Handler
+}
+
+// NewListener returns a listener for the events of chaincodeID, connecting
+// with connect
+func NewListener(connect Connect, chaincodeID string, checkpointer Checkpointer) *Listener {
+	return &Listener{
+		connect:      connect,
+		chaincodeID:  chaincodeID,
+		checkpointer: checkpointer,
+		handlers:     make(map[string][]Handler),
+	}
+}
+
+// Handle registers handler for the events called name. Handlers of the same
+// event run in the order they were registered. Handle must not be called
+// while the listener runs.
+func (l *Listener) Handle(name string, handler Handler) {
+	l.handlers[name] = append(l.handlers[name], handler)
+}
+
+// HandleAll registers handler for every event. It runs after the handlers
+// registered for the name of the event.
+func (l *Listener) HandleAll(handler Handler) {
+	l.all = append(l.all, handler)
+}
+
+// Run dispatches events until ctx is done or a handler fails
+func (l *Listener) Run(ctx context.Context) error {
+	checkpoint, err := l.checkpointer.Load()
+	if err != nil {
+		return err
+	}
+
+	source, err := l.connect(checkpoint.BlockNumber)
+	if err != nil {
+		return fmt.Errorf("failed to connect for events from block %d: %v", checkpoint.BlockNumber, err)
+	}
+	defer source.Close()
+
+	registration, notifier, err := source.RegisterChaincodeEvent(l.chaincodeID, ".*")
+	if err != nil {
+		return fmt.Errorf("failed to register for events of %s: %v", l.chaincodeID, err)
+	}
+	defer source.Unregister(registration)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case ccEvent, ok := <-notifier:
+			if !ok {
+				return errors.New("event service closed the event stream")
+			}
+
+			err = l.dispatch(checkpoint, ccEvent)
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// dispatch runs the handlers of an event unless the checkpoint is past it,
+// then moves the checkpoint past the event.
+func (l *Listener) dispatch(checkpoint *Checkpoint, ccEvent *fab.CCEvent) error {
+	event := &Event{
+		Name:        ccEvent.EventName,
+		TxID:        ccEvent.TxID,
+		BlockNumber: ccEvent.BlockNumber,
+		Payload:     ccEvent.Payload,
+	}
+	if checkpoint.handled(event) {
+		return nil
+	}
+
+	handlers := append(append([]Handler{}, l.handlers[event.Name]...), l.all...)
+	for _, handler := range handlers {
+		err := handler(event)
+		if err != nil {
+			return fmt.Errorf("failed to handle %s event of transaction %s: %v", event.Name, event.TxID, err)
+		}
+	}
+
+	checkpoint.advance(event)
+	err := l.checkpointer.Save(checkpoint)
+	if err != nil {
+		return fmt.Errorf("failed to save checkpoint: %v", err)
+	}
+
+	return nil
+}
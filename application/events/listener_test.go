@@ -0,0 +1,114 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+)
+
+// replaySource delivers the events of a chain from the block it was
+// connected at, like the event service does, then closes the stream
+type replaySource struct {
+	events []*fab.CCEvent
+	closed bool
+}
+
+func (s *replaySource) RegisterChaincodeEvent(chaincodeID, eventFilter string) (fab.Registration, <-chan *fab.CCEvent, error) {
+	notifier := make(chan *fab.CCEvent, len(s.events))
+	for _, event := range s.events {
+		notifier <- event
+	}
+	close(notifier)
+
+	return "registration", notifier, nil
+}
+
+func (s *replaySource) Unregister(registration fab.Registration) {}
+
+func (s *replaySource) Close() {
+	s.closed = true
+}
+
+// memCheckpointer keeps the checkpoint in memory
+type memCheckpointer struct {
+	checkpoint Checkpoint
+}
+
+func (c *memCheckpointer) Load() (*Checkpoint, error) {
+	checkpoint := c.checkpoint
+	return &checkpoint, nil
+}
+
+func (c *memCheckpointer) Save(checkpoint *Checkpoint) error {
+	c.checkpoint = *checkpoint
+	return nil
+}
+
+func TestListenerResumesFromCheckpoint(t *testing.T) {
+	chain := []*fab.CCEvent{
+		{TxID: "tx1", EventName: ClassCreated, BlockNumber: 4},
+		{TxID: "tx2", EventName: LabCreated, BlockNumber: 5},
+		{TxID: "tx3", EventName: LabUpdated, BlockNumber: 5},
+		{TxID: "tx4", EventName: LabDeleted, BlockNumber: 6},
+	}
+
+	var connectedAt []uint64
+	var sources []*replaySource
+	connect := func(blockNum uint64) (Source, error) {
+		connectedAt = append(connectedAt, blockNum)
+		source := &replaySource{}
+		for _, event := range chain {
+			if event.BlockNumber >= blockNum {
+				source.events = append(source.events, event)
+			}
+		}
+		sources = append(sources, source)
+		return source, nil
+	}
+	checkpointer := &memCheckpointer{}
+
+	var handled []string
+	failing := "tx3"
+	listen := func() error {
+		listener := NewListener(connect, "labplatform", checkpointer)
+		listener.HandleAll(func(event *Event) error {
+			if event.TxID == failing {
+				return errors.New("handler failed")
+			}
+			handled = append(handled, event.TxID)
+			return nil
+		})
+		return listener.Run(context.Background())
+	}
+
+	err := listen()
+	if err == nil || !strings.Contains(err.Error(), "handler failed") {
+		t.Fatalf("first run returned %v", err)
+	}
+	want := Checkpoint{BlockNumber: 5, TxIDs: []string{"tx2"}}
+	if !reflect.DeepEqual(checkpointer.checkpoint, want) {
+		t.Fatalf("checkpoint %+v, want %+v", checkpointer.checkpoint, want)
+	}
+
+	failing = ""
+	err = listen()
+	if err == nil || !strings.Contains(err.Error(), "closed") {
+		t.Fatalf("second run returned %v", err)
+	}
+
+	if !reflect.DeepEqual(connectedAt, []uint64{0, 5}) {
+		t.Errorf("connected at blocks %v, want 0 then 5", connectedAt)
+	}
+	if !reflect.DeepEqual(handled, []string{"tx1", "tx2", "tx3", "tx4"}) {
+		t.Errorf("handled %v, want every transaction once", handled)
+	}
+	for i, source := range sources {
+		if !source.closed {
+			t.Errorf("source %d was not closed", i)
+		}
+	}
+}
@@ -2,4 +2,9 @@ module lab
 
 go 1.14
 
-require github.com/hyperledger/fabric-sdk-go v1.0.0-rc1
+require (
+	client v0.0.0-00010101000000-000000000000
+	github.com/hyperledger/fabric-sdk-go v1.0.0-rc1
+)
+
+replace client => ../client
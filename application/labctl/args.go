@@ -0,0 +1,76 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"strconv"
+)
+
+// errUsage makes labctl print the usage of the command
+var errUsage = errors.New("invalid arguments")
+
+// parse parses the flags of a command and checks that n positional arguments
+// are left
+func parse(flags *flag.FlagSet, args []string, n int) ([]string, error) {
+	args, err := parseAtLeast(flags, args, n)
+	if err != nil {
+		return nil, err
+	}
+	if len(args) != n {
+		return nil, errUsage
+	}
+
+	return args, nil
+}
+
+// parseAtLeast parses the flags of a command and checks that at least n
+// positional arguments are left
+func parseAtLeast(flags *flag.FlagSet, args []string, n int) ([]string, error) {
+	flags.SetOutput(ioutil.Discard)
+
+	err := flags.Parse(args)
+	if err != nil {
+		return nil, errUsage
+	}
+	if flags.NArg() < n {
+		return nil, errUsage
+	}
+
+	return flags.Args(), nil
+}
+
+// exactly checks that a command without flags got n arguments
+func exactly(args []string, n int) error {
+	if len(args) != n {
+		return errUsage
+	}
+
+	return nil
+}
+
+// parseUint32 parses a numeric argument
+func parseUint32(name, value string) (uint32, error) {
+	n, err := strconv.ParseUint(value, 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("%s %q is not a number", name, value)
+	}
+
+	return uint32(n), nil
+}
+
+// readArg returns value, or the content of the file it names when it starts
+// with @
+func readArg(value string) (string, error) {
+	if len(value) == 0 || value[0] != '@' {
+		return value, nil
+	}
+
+	content, err := ioutil.ReadFile(value[1:])
+	if err != nil {
+		return "", err
+	}
+
+	return string(content), nil
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"flag"
+
+	"client"
+)
+
+var classCommands = map[string]command{
+	"list": {
+		usage: "[-student <student>]",
+		run: func(ctx *context, args []string) error {
+			flags := flag.NewFlagSet("class list", flag.ContinueOnError)
+			student := flags.String("student", "", "only list the classes the student is enrolled in")
+			_, err := parse(flags, args, 0)
+			if err != nil {
+				return err
+			}
+
+			var classes []*client.Class
+			if *student != "" {
+				classes, err = ctx.client.Classes().ForStudent(*student).All()
+			} else {
+				classes, err = ctx.client.Classes().List().All()
+			}
+			if err != nil {
+				return err
+			}
+
+			return ctx.out.print(classes)
+		},
+	},
+	"get": {
+		usage: "<classID>",
+		run: func(ctx *context, args []string) error {
+			if err := exactly(args, 1); err != nil {
+				return err
+			}
+
+			class, err := ctx.client.Classes().Get(args[0])
+			if err != nil {
+				return err
+			}
+
+			return ctx.out.print(class)
+		},
+	},
+	"create": {
+		usage: "<classID> <name> <content|@file>",
+		run: func(ctx *context, args []string) error {
+			if err := exactly(args, 3); err != nil {
+				return err
+			}
+			content, err := readArg(args[2])
+			if err != nil {
+				return err
+			}
+
+			class, err := ctx.client.Classes().Create(args[0], args[1], content)
+			if err != nil {
+				return err
+			}
+
+			return ctx.out.print(class)
+		},
+	},
+	"update": {
+		usage: "<classID> <name> <content|@file>",
+		run: func(ctx *context, args []string) error {
+			if err := exactly(args, 3); err != nil {
+				return err
+			}
+			content, err := readArg(args[2])
+			if err != nil {
+				return err
+			}
+
+			class, err := ctx.client.Classes().Update(args[0], args[1], content)
+			if err != nil {
+				return err
+			}
+
+			return ctx.out.print(class)
+		},
+	},
+	"transfer": {
+		usage: "<classID> <owner>",
+		run: func(ctx *context, args []string) error {
+			if err := exactly(args, 2); err != nil {
+				return err
+			}
+
+			class, err := ctx.client.Classes().Transfer(args[0], args[1])
+			if err != nil {
+				return err
+			}
+
+			return ctx.out.print(class)
+		},
+	},
+	"delete": {
+		usage: "[-cascade] <classID>",
+		run: func(ctx *context, args []string) error {
+			flags := flag.NewFlagSet("class delete", flag.ContinueOnError)
+			cascade := flags.Bool("cascade", false, "delete the labs of the class along")
+			args, err := parse(flags, args, 1)
+			if err != nil {
+				return err
+			}
+
+			err = ctx.client.Classes().Delete(args[0], deletePolicy(*cascade))
+			if err != nil {
+				return err
+			}
+
+			ctx.out.done("class %s deleted", args[0])
+			return nil
+		},
+	},
+	"enroll": {
+		usage: "<classID> <student>",
+		run: func(ctx *context, args []string) error {
+			if err := exactly(args, 2); err != nil {
+				return err
+			}
+
+			err := ctx.client.Classes().Enroll(args[0], args[1])
+			if err != nil {
+				return err
+			}
+
+			ctx.out.done("%s enrolled in class %s", args[1], args[0])
+			return nil
+		},
+	},
+	"drop": {
+		usage: "<classID> <student>",
+		run: func(ctx *context, args []string) error {
+			if err := exactly(args, 2); err != nil {
+				return err
+			}
+
+			err := ctx.client.Classes().Drop(args[0], args[1])
+			if err != nil {
+				return err
+			}
+
+			ctx.out.done("%s dropped from class %s", args[1], args[0])
+			return nil
+		},
+	},
+	"roster": {
+		usage: "<classID>",
+		run: func(ctx *context, args []string) error {
+			if err := exactly(args, 1); err != nil {
+				return err
+			}
+
+			students, err := ctx.client.Classes().Roster(args[0]).All()
+			if err != nil {
+				return err
+			}
+
+			return ctx.out.print(students)
+		},
+	},
+	"history": {
+		usage: "<classID>",
+		run: func(ctx *context, args []string) error {
+			if err := exactly(args, 1); err != nil {
+				return err
+			}
+
+			history, err := ctx.client.Classes().History(args[0])
+			if err != nil {
+				return err
+			}
+
+			return ctx.out.print(history)
+		},
+	},
+}
+
+// deletePolicy returns the delete policy for the -cascade flag
+func deletePolicy(cascade bool) string {
+	if cascade {
+		return client.DeleteCascade
+	}
+
+	return client.DeleteRestrict
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	gocontext "context"
+	"flag"
+	"os"
+	"os/signal"
+
+	"events"
+)
+
+// eventRow is how watch prints an event
+type eventRow struct {
+	Block   uint64 `json:"block"`
+	TxID    string `json:"txID"`
+	Name    string `json:"name"`
+	Payload string `json:"payload"`
+}
+
+var eventCommands = map[string]command{
+	"watch": {
+		usage: "[-checkpoint <file>] [event...]",
+		run: func(ctx *context, args []string) error {
+			flags := flag.NewFlagSet("events watch", flag.ContinueOnError)
+			checkpoint := flags.String("checkpoint", "events.checkpoint", "file recording the events already printed, to resume from")
+			names, err := parseAtLeast(flags, args, 0)
+			if err != nil {
+				return err
+			}
+
+			connect := func(blockNum uint64) (events.Source, error) {
+				source, err := ctx.client.Events(blockNum)
+				if err != nil {
+					return nil, err
+				}
+				return source, nil
+			}
+			listener := events.NewListener(connect, ctx.chaincode, events.NewFileCheckpointer(*checkpoint))
+
+			printEvent := func(event *events.Event) error {
+				return ctx.out.print(&eventRow{
+					Block:   event.BlockNumber,
+					TxID:    event.TxID,
+					Name:    event.Name,
+					Payload: string(event.Payload),
+				})
+			}
+			if len(names) == 0 {
+				listener.HandleAll(printEvent)
+			}
+			for _, name := range names {
+				listener.Handle(name, printEvent)
+			}
+
+			watch, stop := gocontext.WithCancel(gocontext.Background())
+			defer stop()
+			interrupt := make(chan os.Signal, 1)
+			signal.Notify(interrupt, os.Interrupt)
+			defer signal.Stop(interrupt)
+			go func() {
+				<-interrupt
+				stop()
+			}()
+
+			err = listener.Run(watch)
+			if err == gocontext.Canceled {
+				return nil
+			}
+			return err
+		},
+	},
+}
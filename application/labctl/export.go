@@ -0,0 +1,110 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"client"
+)
+
+// ClassExport is a snapshot of a class and everything that belongs to it
+type ClassExport struct {
+	Class       *client.Class        `json:"class"`
+	Roster      []string             `json:"roster"`
+	Labs        []*client.Lab        `json:"labs"`
+	Instances   []*client.Instance   `json:"instances"`
+	Submissions []*client.Submission `json:"submissions"`
+}
+
+// gradeColumns are the columns of a grade export
+var gradeColumns = []string{"submission", "owner", "attempt", "submittedAt", "late", "state", "total", "feedback"}
+
+var exportCommands = map[string]command{
+	"class": {
+		usage: "<classID>",
+		run: func(ctx *context, args []string) error {
+			if err := exactly(args, 1); err != nil {
+				return err
+			}
+
+			var export ClassExport
+			var err error
+			export.Class, err = ctx.client.Classes().Get(args[0])
+			if err != nil {
+				return err
+			}
+			export.Roster, err = ctx.client.Classes().Roster(args[0]).All()
+			if err != nil {
+				return err
+			}
+			export.Labs, err = ctx.client.Labs().ListByClass(args[0]).All()
+			if err != nil {
+				return err
+			}
+			export.Instances, err = ctx.client.Instances().ListByClass(args[0]).All()
+			if err != nil {
+				return err
+			}
+			export.Submissions, err = ctx.client.Submissions().ListByClass(args[0]).All()
+			if err != nil {
+				return err
+			}
+
+			exportBytes, err := json.MarshalIndent(&export, "", "  ")
+			if err != nil {
+				return err
+			}
+			_, err = fmt.Fprintln(ctx.out.w, string(exportBytes))
+			return err
+		},
+	},
+	"grades": {
+		usage: "<labID>",
+		run: func(ctx *context, args []string) error {
+			if err := exactly(args, 1); err != nil {
+				return err
+			}
+
+			submissions, err := ctx.client.Submissions().ListByLab(args[0]).All()
+			if err != nil {
+				return err
+			}
+
+			w := csv.NewWriter(ctx.out.w)
+			err = w.Write(gradeColumns)
+			if err != nil {
+				return err
+			}
+
+			for _, submission := range submissions {
+				record := []string{
+					submission.ID,
+					submission.Owner,
+					strconv.FormatUint(uint64(submission.Attempt), 10),
+					submission.SubmittedAt,
+					strconv.FormatBool(submission.Late),
+					"", "", "",
+				}
+				if submission.Grade != nil {
+					details, err := ctx.client.Submissions().GetGrade(submission.ID)
+					if err != nil {
+						return err
+					}
+					record[5] = submission.Grade.State
+					record[6] = strconv.FormatUint(uint64(details.Total), 10)
+					record[7] = details.Feedback
+				}
+
+				err = w.Write(record)
+				if err != nil {
+					return err
+				}
+			}
+
+			w.Flush()
+			return w.Error()
+		},
+	},
+}
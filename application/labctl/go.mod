@@ -0,0 +1,16 @@
+module labctl
+
+go 1.14
+
+require (
+	artifact v0.0.0-00010101000000-000000000000
+	client v0.0.0-00010101000000-000000000000
+	events v0.0.0-00010101000000-000000000000
+	github.com/hyperledger/fabric-sdk-go v1.0.0-rc1
+)
+
+replace artifact => ../artifact
+
+replace client => ../client
+
+replace events => ../events
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"strings"
+
+	"client"
+)
+
+var gradeCommands = map[string]command{
+	"rubric": {
+		usage: "[-feedback <text|@file>] [-salt <salt>] <submissionID> <criterion=points>...",
+		run: func(ctx *context, args []string) error {
+			flags := flag.NewFlagSet("grade rubric", flag.ContinueOnError)
+			feedback := flags.String("feedback", "", "feedback to the student")
+			salt := flags.String("salt", "", "salt of the grade hash, random by default")
+			args, err := parseAtLeast(flags, args, 2)
+			if err != nil {
+				return err
+			}
+
+			details := &client.GradeDetails{Salt: *salt}
+			for _, arg := range args[1:] {
+				parts := strings.SplitN(arg, "=", 2)
+				if len(parts) != 2 {
+					return errUsage
+				}
+				points, err := parseUint32("points of "+parts[0], parts[1])
+				if err != nil {
+					return err
+				}
+				details.Scores = append(details.Scores, client.CriterionScore{Criterion: parts[0], Points: points})
+			}
+
+			return grade(ctx, args[0], details, *feedback, ctx.client.Submissions().Grade)
+		},
+	},
+	"score": {
+		usage: "[-feedback <text|@file>] [-salt <salt>] <submissionID> <total>",
+		run: func(ctx *context, args []string) error {
+			flags := flag.NewFlagSet("grade score", flag.ContinueOnError)
+			feedback := flags.String("feedback", "", "feedback to the student")
+			salt := flags.String("salt", "", "salt of the grade hash, random by default")
+			args, err := parse(flags, args, 2)
+			if err != nil {
+				return err
+			}
+
+			total, err := parseUint32("total", args[1])
+			if err != nil {
+				return err
+			}
+
+			details := &client.GradeDetails{Salt: *salt, Total: total}
+			return grade(ctx, args[0], details, *feedback, ctx.client.Submissions().UpdateScore)
+		},
+	},
+	"release": {
+		usage: "<submissionID>",
+		run: func(ctx *context, args []string) error {
+			if err := exactly(args, 1); err != nil {
+				return err
+			}
+
+			err := ctx.client.Submissions().ReleaseGrade(args[0])
+			if err != nil {
+				return err
+			}
+
+			ctx.out.done("grade of submission %s released", args[0])
+			return nil
+		},
+	},
+	"get": {
+		usage: "<submissionID>",
+		run: func(ctx *context, args []string) error {
+			if err := exactly(args, 1); err != nil {
+				return err
+			}
+
+			details, err := ctx.client.Submissions().GetGrade(args[0])
+			if err != nil {
+				return err
+			}
+
+			return ctx.out.print(details)
+		},
+	},
+	"verify": {
+		usage: "<submissionID> <grade|@file>",
+		run: func(ctx *context, args []string) error {
+			if err := exactly(args, 2); err != nil {
+				return err
+			}
+			gradeJSON, err := readArg(args[1])
+			if err != nil {
+				return err
+			}
+
+			var details client.GradeDetails
+			err = json.Unmarshal([]byte(gradeJSON), &details)
+			if err != nil {
+				return fmt.Errorf("grade is not a JSON object: %v", err)
+			}
+
+			ok, err := ctx.client.Submissions().VerifyGrade(args[0], &details)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				return fmt.Errorf("grade does not match the grade recorded for submission %s", args[0])
+			}
+
+			ctx.out.done("grade matches the grade recorded for submission %s", args[0])
+			return nil
+		},
+	},
+}
+
+// grade records details as the grade of a submission with record and prints
+// the grade, including its salt
+func grade(ctx *context, submissionID string, details *client.GradeDetails, feedback string, record func(string, *client.GradeDetails) error) error {
+	feedback, err := readArg(feedback)
+	if err != nil {
+		return err
+	}
+	details.Feedback = feedback
+
+	err = record(submissionID, details)
+	if err != nil {
+		return err
+	}
+
+	return ctx.out.print(details)
+}
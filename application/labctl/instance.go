@@ -0,0 +1,200 @@
+package main
+
+import (
+	"flag"
+	"strconv"
+
+	"client"
+)
+
+var instanceCommands = map[string]command{
+	"list": {
+		usage: "-class <classID> | -lab <labID> | -owner <owner> | -from <instanceID> -to <instanceID>",
+		run: func(ctx *context, args []string) error {
+			flags := flag.NewFlagSet("instance list", flag.ContinueOnError)
+			classID := flags.String("class", "", "list the instances of the class")
+			labID := flags.String("lab", "", "list the instances of the lab")
+			owner := flags.String("owner", "", "list the instances of the student")
+			from := flags.String("from", "", "list the instances from this ID")
+			to := flags.String("to", "", "list the instances up to this ID")
+			_, err := parse(flags, args, 0)
+			if err != nil {
+				return err
+			}
+
+			instances := ctx.client.Instances()
+			var list []*client.Instance
+			switch {
+			case *classID != "":
+				list, err = instances.ListByClass(*classID).All()
+			case *labID != "":
+				list, err = instances.ListByLab(*labID).All()
+			case *owner != "":
+				list, err = instances.ListByOwner(*owner).All()
+			default:
+				list, err = instances.ListByRange(*from, *to).All()
+			}
+			if err != nil {
+				return err
+			}
+
+			return ctx.out.print(list)
+		},
+	},
+	"get": {
+		usage: "<instanceID>",
+		run: func(ctx *context, args []string) error {
+			if err := exactly(args, 1); err != nil {
+				return err
+			}
+
+			instance, err := ctx.client.Instances().Get(args[0])
+			if err != nil {
+				return err
+			}
+
+			return ctx.out.print(instance)
+		},
+	},
+	"create": {
+		usage: "[-owner <owner>] <instanceID> <labID> <classID> <config|@file>",
+		run: func(ctx *context, args []string) error {
+			flags := flag.NewFlagSet("instance create", flag.ContinueOnError)
+			owner := flags.String("owner", "", "student to create the instance for, yourself by default")
+			args, err := parse(flags, args, 4)
+			if err != nil {
+				return err
+			}
+			config, err := readArg(args[3])
+			if err != nil {
+				return err
+			}
+
+			instance, err := ctx.client.Instances().Create(args[0], args[1], args[2], config, *owner)
+			if err != nil {
+				return err
+			}
+
+			return ctx.out.print(instance)
+		},
+	},
+	"delete": {
+		usage: "<instanceID>",
+		run: func(ctx *context, args []string) error {
+			if err := exactly(args, 1); err != nil {
+				return err
+			}
+
+			err := ctx.client.Instances().Delete(args[0])
+			if err != nil {
+				return err
+			}
+
+			ctx.out.done("instance %s deleted", args[0])
+			return nil
+		},
+	},
+	"set-usedtime": {
+		usage: "<instanceID> <seconds>",
+		run: func(ctx *context, args []string) error {
+			if err := exactly(args, 2); err != nil {
+				return err
+			}
+			usedTime, err := strconv.ParseUint(args[1], 10, 64)
+			if err != nil {
+				return errUsage
+			}
+
+			instance, err := ctx.client.Instances().UpdateUsedTime(args[0], usedTime)
+			if err != nil {
+				return err
+			}
+
+			return ctx.out.print(instance)
+		},
+	},
+	"start": {
+		usage: "<instanceID>",
+		run: func(ctx *context, args []string) error {
+			if err := exactly(args, 1); err != nil {
+				return err
+			}
+
+			err := ctx.client.Instances().StartSession(args[0])
+			if err != nil {
+				return err
+			}
+
+			ctx.out.done("session of instance %s started", args[0])
+			return nil
+		},
+	},
+	"stop": {
+		usage: "<instanceID>",
+		run: func(ctx *context, args []string) error {
+			if err := exactly(args, 1); err != nil {
+				return err
+			}
+
+			err := ctx.client.Instances().StopSession(args[0])
+			if err != nil {
+				return err
+			}
+
+			ctx.out.done("session of instance %s stopped", args[0])
+			return nil
+		},
+	},
+	"sessions": {
+		usage: "<instanceID>",
+		run: func(ctx *context, args []string) error {
+			if err := exactly(args, 1); err != nil {
+				return err
+			}
+
+			sessions, err := ctx.client.Instances().Sessions(args[0])
+			if err != nil {
+				return err
+			}
+
+			return ctx.out.print(sessions)
+		},
+	},
+	"usage": {
+		usage: "[-lab <labID>] [-owner <owner>] <classID>",
+		run: func(ctx *context, args []string) error {
+			flags := flag.NewFlagSet("instance usage", flag.ContinueOnError)
+			labID := flags.String("lab", "", "only report the usage of the lab")
+			owner := flags.String("owner", "", "only report the usage of the student")
+			args, err := parse(flags, args, 1)
+			if err != nil {
+				return err
+			}
+
+			report, err := ctx.client.Instances().UsageReport(args[0], *labID, *owner)
+			if err != nil {
+				return err
+			}
+
+			if ctx.out.format == outputTable {
+				return ctx.out.print(report.Entries)
+			}
+			return ctx.out.print(report)
+		},
+	},
+	"history": {
+		usage: "<instanceID>",
+		run: func(ctx *context, args []string) error {
+			if err := exactly(args, 1); err != nil {
+				return err
+			}
+
+			history, err := ctx.client.Instances().History(args[0])
+			if err != nil {
+				return err
+			}
+
+			return ctx.out.print(history)
+		},
+	},
+}
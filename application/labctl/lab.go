@@ -0,0 +1,229 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+
+	"client"
+)
+
+var labCommands = map[string]command{
+	"list": {
+		usage: "[-class <classID>]",
+		run: func(ctx *context, args []string) error {
+			flags := flag.NewFlagSet("lab list", flag.ContinueOnError)
+			classID := flags.String("class", "", "only list the labs of the class")
+			_, err := parse(flags, args, 0)
+			if err != nil {
+				return err
+			}
+
+			var labs []*client.Lab
+			if *classID != "" {
+				labs, err = ctx.client.Labs().ListByClass(*classID).All()
+			} else {
+				labs, err = ctx.client.Labs().List().All()
+			}
+			if err != nil {
+				return err
+			}
+
+			return ctx.out.print(labs)
+		},
+	},
+	"get": {
+		usage: "<labID>",
+		run: func(ctx *context, args []string) error {
+			if err := exactly(args, 1); err != nil {
+				return err
+			}
+
+			lab, err := ctx.client.Labs().Get(args[0])
+			if err != nil {
+				return err
+			}
+
+			return ctx.out.print(lab)
+		},
+	},
+	"create": {
+		usage: "<labID> <classID> <name> <content|@file> <config|@file> <startTime> <endTime>",
+		run: func(ctx *context, args []string) error {
+			if err := exactly(args, 7); err != nil {
+				return err
+			}
+			content, err := readArg(args[3])
+			if err != nil {
+				return err
+			}
+			config, err := readArg(args[4])
+			if err != nil {
+				return err
+			}
+
+			lab, err := ctx.client.Labs().Create(args[0], args[1], args[2], content, config, args[5], args[6])
+			if err != nil {
+				return err
+			}
+
+			return ctx.out.print(lab)
+		},
+	},
+	"update": {
+		usage: "<labID> <name> <content|@file> <config|@file> <startTime> <endTime>",
+		run: func(ctx *context, args []string) error {
+			if err := exactly(args, 6); err != nil {
+				return err
+			}
+			content, err := readArg(args[2])
+			if err != nil {
+				return err
+			}
+			config, err := readArg(args[3])
+			if err != nil {
+				return err
+			}
+
+			lab, err := ctx.client.Labs().Update(args[0], config, args[1], content, args[4], args[5])
+			if err != nil {
+				return err
+			}
+
+			return ctx.out.print(lab)
+		},
+	},
+	"set-endtime": {
+		usage: "<labID> <endTime>",
+		run: func(ctx *context, args []string) error {
+			if err := exactly(args, 2); err != nil {
+				return err
+			}
+
+			lab, err := ctx.client.Labs().UpdateEndtime(args[0], args[1])
+			if err != nil {
+				return err
+			}
+
+			return ctx.out.print(lab)
+		},
+	},
+	"set-late-policy": {
+		usage: "<labID> <policy>",
+		run: func(ctx *context, args []string) error {
+			if err := exactly(args, 2); err != nil {
+				return err
+			}
+
+			lab, err := ctx.client.Labs().UpdateLatePolicy(args[0], args[1])
+			if err != nil {
+				return err
+			}
+
+			return ctx.out.print(lab)
+		},
+	},
+	"set-attempts": {
+		usage: "<labID> <maxAttempts> <gradingPolicy>",
+		run: func(ctx *context, args []string) error {
+			if err := exactly(args, 3); err != nil {
+				return err
+			}
+			maxAttempts, err := parseUint32("maxAttempts", args[1])
+			if err != nil {
+				return err
+			}
+
+			lab, err := ctx.client.Labs().UpdateAttemptPolicy(args[0], maxAttempts, args[2])
+			if err != nil {
+				return err
+			}
+
+			return ctx.out.print(lab)
+		},
+	},
+	"set-rubric": {
+		usage: "<labID> <rubric|@file>",
+		run: func(ctx *context, args []string) error {
+			if err := exactly(args, 2); err != nil {
+				return err
+			}
+			rubricJSON, err := readArg(args[1])
+			if err != nil {
+				return err
+			}
+
+			var rubric []client.Criterion
+			err = json.Unmarshal([]byte(rubricJSON), &rubric)
+			if err != nil {
+				return fmt.Errorf("rubric is not a JSON array of criteria: %v", err)
+			}
+
+			lab, err := ctx.client.Labs().SetRubric(args[0], rubric)
+			if err != nil {
+				return err
+			}
+
+			return ctx.out.print(lab)
+		},
+	},
+	"set-quota": {
+		usage: "<labID> <quota|@file>",
+		run: func(ctx *context, args []string) error {
+			if err := exactly(args, 2); err != nil {
+				return err
+			}
+			quotaJSON, err := readArg(args[1])
+			if err != nil {
+				return err
+			}
+
+			var quota client.Quota
+			err = json.Unmarshal([]byte(quotaJSON), &quota)
+			if err != nil {
+				return fmt.Errorf("quota is not a JSON object: %v", err)
+			}
+
+			lab, err := ctx.client.Labs().UpdateQuota(args[0], quota)
+			if err != nil {
+				return err
+			}
+
+			return ctx.out.print(lab)
+		},
+	},
+	"delete": {
+		usage: "[-cascade] <labID>",
+		run: func(ctx *context, args []string) error {
+			flags := flag.NewFlagSet("lab delete", flag.ContinueOnError)
+			cascade := flags.Bool("cascade", false, "delete the instances and submissions of the lab along")
+			args, err := parse(flags, args, 1)
+			if err != nil {
+				return err
+			}
+
+			err = ctx.client.Labs().Delete(args[0], deletePolicy(*cascade))
+			if err != nil {
+				return err
+			}
+
+			ctx.out.done("lab %s deleted", args[0])
+			return nil
+		},
+	},
+	"history": {
+		usage: "<labID>",
+		run: func(ctx *context, args []string) error {
+			if err := exactly(args, 1); err != nil {
+				return err
+			}
+
+			history, err := ctx.client.Labs().History(args[0])
+			if err != nil {
+				return err
+			}
+
+			return ctx.out.print(history)
+		},
+	},
+}
@@ -0,0 +1,174 @@
+/*
+Copyright 2020 IBM All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// labctl manages classes, labs, instances and submissions of the labplatform
+// chaincode from the command line:
+//
+//	labctl [global flags] <resource> <action> [flags] [args]
+//
+// Run labctl help for the list of commands.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"client"
+)
+
+// command is an action on a resource
+type command struct {
+	usage string
+	run   func(ctx *context, args []string) error
+}
+
+// context is what commands run with
+type context struct {
+	client    *client.Client
+	chaincode string
+	out       *printer
+}
+
+// commands lists the actions of each resource
+var commands = map[string]map[string]command{
+	"class":      classCommands,
+	"lab":        labCommands,
+	"instance":   instanceCommands,
+	"submission": submissionCommands,
+	"grade":      gradeCommands,
+	"export":     exportCommands,
+	"events":     eventCommands,
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr, client.Connect))
+}
+
+// run runs labctl with args, opening the client with connect, and returns
+// the exit code
+func run(args []string, stdout, stderr io.Writer, connect func(client.Config) (*client.Client, error)) int {
+	cfg := client.DefaultConfig()
+
+	flags := flag.NewFlagSet("labctl", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.StringVar(&cfg.Identity, "identity", cfg.Identity, "wallet label of the identity to act as")
+	flags.StringVar(&cfg.MSPID, "msp-id", cfg.MSPID, "MSP ID of the identity, when adding it to the wallet")
+	flags.StringVar(&cfg.CredentialPath, "credentials", cfg.CredentialPath, "msp directory to add the identity to the wallet from when missing")
+	flags.StringVar(&cfg.WalletPath, "wallet", cfg.WalletPath, "directory of the wallet")
+	flags.StringVar(&cfg.Channel, "channel", cfg.Channel, "channel the chaincode is deployed on")
+	flags.StringVar(&cfg.Chaincode, "chaincode", cfg.Chaincode, "name of the chaincode")
+	flags.StringVar(&cfg.ConnectionProfile, "profile", cfg.ConnectionProfile, "path of the connection profile")
+	output := flags.String("output", outputTable, "output format, "+outputTable+" or "+outputJSON)
+	flags.Usage = func() {
+		usage(flags)
+	}
+
+	err := flags.Parse(args)
+	if err != nil {
+		return 2
+	}
+
+	out, err := newPrinter(stdout, *output)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+
+	args = flags.Args()
+	if len(args) == 0 || args[0] == "help" {
+		usage(flags)
+		return 0
+	}
+
+	actions, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(stderr, "unknown resource %s, run labctl help\n", args[0])
+		return 2
+	}
+	if len(args) < 2 {
+		fmt.Fprintf(stderr, "missing action, one of %s\n", strings.Join(actionNames(actions), ", "))
+		return 2
+	}
+	cmd, ok := actions[args[1]]
+	if !ok {
+		fmt.Fprintf(stderr, "unknown action %s %s, one of %s\n", args[0], args[1], strings.Join(actionNames(actions), ", "))
+		return 2
+	}
+
+	// identities other than the default are expected in the wallet already
+	if cfg.Identity != client.DefaultConfig().Identity && !flagSet(flags, "credentials") {
+		cfg.CredentialPath = ""
+	}
+
+	c, err := connect(cfg)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	defer c.Close()
+
+	err = cmd.run(&context{client: c, chaincode: cfg.Chaincode, out: out}, args[2:])
+	if err == errUsage {
+		fmt.Fprintf(stderr, "usage: labctl %s %s %s\n", args[0], args[1], cmd.usage)
+		return 2
+	}
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+
+	return 0
+}
+
+// usage prints the global flags and the commands
+func usage(flags *flag.FlagSet) {
+	w := flags.Output()
+	fmt.Fprintln(w, "usage: labctl [global flags] <resource> <action> [flags] [args]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "global flags:")
+	flags.PrintDefaults()
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "commands:")
+
+	var resources []string
+	for resource := range commands {
+		resources = append(resources, resource)
+	}
+	sort.Strings(resources)
+
+	for _, resource := range resources {
+		for _, action := range actionNames(commands[resource]) {
+			fmt.Fprintf(w, "  %s %s %s\n", resource, action, commands[resource][action].usage)
+		}
+	}
+}
+
+// actionNames returns the sorted actions of a resource
+func actionNames(actions map[string]command) []string {
+	var names []string
+	for name := range actions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// flagSet returns true when the flag name was given
+func flagSet(flags *flag.FlagSet, name string) bool {
+	set := false
+	flags.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+
+	return set
+}
@@ -0,0 +1,266 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"client"
+)
+
+// call is a transaction received by a fakeContract
+type call struct {
+	contract  string
+	name      string
+	args      []string
+	transient map[string][]byte
+}
+
+// fakeContract stands in for the contracts of the chaincode. It records the
+// calls and answers them from results, keyed by transaction name.
+type fakeContract struct {
+	name   string
+	ledger *fakeLedger
+}
+
+// fakeLedger keeps the calls and results of every fakeContract of a client
+type fakeLedger struct {
+	calls   []call
+	results map[string]interface{}
+}
+
+func (c *fakeContract) respond(name string, transient map[string][]byte, args []string) ([]byte, error) {
+	c.ledger.calls = append(c.ledger.calls, call{contract: c.name, name: name, args: args, transient: transient})
+
+	result, ok := c.ledger.results[name]
+	if !ok {
+		return nil, nil
+	}
+
+	return json.Marshal(result)
+}
+
+func (c *fakeContract) EvaluateTransaction(name string, args ...string) ([]byte, error) {
+	return c.respond(name, nil, args)
+}
+
+func (c *fakeContract) SubmitTransaction(name string, args ...string) ([]byte, error) {
+	return c.respond(name, nil, args)
+}
+
+func (c *fakeContract) EvaluateTransient(name string, transient map[string][]byte, args ...string) ([]byte, error) {
+	return c.respond(name, transient, args)
+}
+
+func (c *fakeContract) SubmitTransient(name string, transient map[string][]byte, args ...string) ([]byte, error) {
+	return c.respond(name, transient, args)
+}
+
+// result is the outcome of one labctl run
+type result struct {
+	code           int
+	stdout, stderr string
+	config         *client.Config
+}
+
+// labctl runs labctl with args against ledger
+func labctl(ledger *fakeLedger, args ...string) *result {
+	var stdout, stderr bytes.Buffer
+	r := &result{}
+	connect := func(cfg client.Config) (*client.Client, error) {
+		r.config = &cfg
+		return client.NewWithContracts(func(name string) client.Contract {
+			return &fakeContract{name: name, ledger: ledger}
+		}), nil
+	}
+
+	r.code = run(args, &stdout, &stderr, connect)
+	r.stdout, r.stderr = stdout.String(), stderr.String()
+
+	return r
+}
+
+var testLab = &client.Lab{
+	ID:        "lab1",
+	ClassID:   "class1",
+	Name:      "Routing",
+	Content:   "Static routes",
+	Config:    `{"version":1}`,
+	StartTime: "2022-09-01T00:00:00Z",
+	EndTime:   "2022-12-01T00:00:00Z",
+	Owner:     "instructor",
+}
+
+func TestLabCreate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "labctl-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	contentFile := filepath.Join(dir, "content.md")
+	err = ioutil.WriteFile(contentFile, []byte("Static routes"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ledger := &fakeLedger{results: map[string]interface{}{"ReadLab": testLab}}
+	r := labctl(ledger, "lab", "create", "lab1", "class1", "Routing", "@"+contentFile, `{"version":1}`, testLab.StartTime, testLab.EndTime)
+	if r.code != 0 {
+		t.Fatalf("exit code %d: %s", r.code, r.stderr)
+	}
+
+	want := []call{
+		{contract: "lab", name: "CreateLab", args: []string{"lab1", "class1", "Routing", "Static routes", `{"version":1}`, testLab.StartTime, testLab.EndTime}},
+		{contract: "lab", name: "ReadLab", args: []string{"lab1"}},
+	}
+	if !reflect.DeepEqual(ledger.calls, want) {
+		t.Errorf("calls %+v, want %+v", ledger.calls, want)
+	}
+
+	lines := strings.Split(strings.TrimSpace(r.stdout), "\n")
+	if len(lines) != 2 || !strings.HasPrefix(lines[0], "ID  ") || !strings.Contains(lines[0], "CLASSID") || !strings.HasPrefix(lines[1], "lab1  ") {
+		t.Errorf("table output\n%s", r.stdout)
+	}
+}
+
+func TestLabList(t *testing.T) {
+	page := &client.LabPage{Records: []*client.Lab{testLab}, FetchedRecordsCount: 1}
+
+	tests := []struct {
+		name string
+		args []string
+		want call
+	}{
+		{"all", []string{"lab", "list"}, call{contract: "lab", name: "ReadLabs", args: []string{"0", ""}}},
+		{"class", []string{"lab", "list", "-class", "class1"}, call{contract: "lab", name: "GetLabsByClass", args: []string{"class1", "0", ""}}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ledger := &fakeLedger{results: map[string]interface{}{test.want.name: page}}
+			r := labctl(ledger, append([]string{"-output", "json"}, test.args...)...)
+			if r.code != 0 {
+				t.Fatalf("exit code %d: %s", r.code, r.stderr)
+			}
+			if !reflect.DeepEqual(ledger.calls, []call{test.want}) {
+				t.Errorf("calls %+v, want %+v", ledger.calls, []call{test.want})
+			}
+
+			var labs []*client.Lab
+			err := json.Unmarshal([]byte(r.stdout), &labs)
+			if err != nil {
+				t.Fatalf("JSON output %q: %v", r.stdout, err)
+			}
+			if !reflect.DeepEqual(labs, []*client.Lab{testLab}) {
+				t.Errorf("listed %+v", labs)
+			}
+		})
+	}
+}
+
+func TestSubmissionSubmit(t *testing.T) {
+	dir, err := ioutil.TempDir("", "labctl-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	content := []byte("interface eth0\n ip address 10.0.0.1/24\n")
+	file := filepath.Join(dir, "router.cfg")
+	err = ioutil.WriteFile(file, content, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	submission := &client.Submission{ID: "tx1", ClassID: "class1", LabID: "lab1", Owner: "student", Attempt: 1}
+	ledger := &fakeLedger{results: map[string]interface{}{"SubmitAttempt": submission}}
+	store := filepath.Join(dir, "store")
+	r := labctl(ledger, "-identity", "student", "submission", "submit", "-store", store, "-type", "text/plain", "lab1", "class1", file)
+	if r.code != 0 {
+		t.Fatalf("exit code %d: %s", r.code, r.stderr)
+	}
+
+	if len(ledger.calls) != 1 {
+		t.Fatalf("calls %+v, want SubmitAttempt only", ledger.calls)
+	}
+	c := ledger.calls[0]
+	if c.contract != "submission" || c.name != "SubmitAttempt" || !reflect.DeepEqual(c.args, []string{"lab1", "class1"}) {
+		t.Errorf("call %+v", c)
+	}
+
+	var artifact client.Artifact
+	err = json.Unmarshal(c.transient["artifact"], &artifact)
+	if err != nil {
+		t.Fatalf("transient artifact %q: %v", c.transient["artifact"], err)
+	}
+	digest := sha256.Sum256(content)
+	if artifact.SHA256 != hex.EncodeToString(digest[:]) || artifact.Size != uint64(len(content)) || artifact.MediaType != "text/plain" {
+		t.Errorf("transient artifact %+v", artifact)
+	}
+	if _, err := os.Stat(filepath.Join(store, "sha256", artifact.SHA256)); err != nil {
+		t.Errorf("file was not uploaded to the store: %v", err)
+	}
+
+	if !strings.Contains(r.stdout, "tx1") {
+		t.Errorf("output %q does not show the submission", r.stdout)
+	}
+	if r.config.Identity != "student" {
+		t.Errorf("connected as %q", r.config.Identity)
+	}
+}
+
+func TestUsageErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr string
+	}{
+		{"unknown resource", []string{"course", "list"}, "unknown resource course"},
+		{"missing action", []string{"lab"}, "missing action, one of create, delete"},
+		{"unknown action", []string{"lab", "rename"}, "unknown action lab rename"},
+		{"output format", []string{"-output", "yaml", "lab", "list"}, `unknown output format "yaml"`},
+		{"missing arguments", []string{"lab", "create", "lab1", "class1"}, "usage: labctl lab create <labID> <classID>"},
+		{"unknown flag", []string{"lab", "list", "-owner", "me"}, "usage: labctl lab list [-class <classID>]"},
+		{"extra arguments", []string{"submission", "submit", "lab1", "class1"}, "usage: labctl submission submit"},
+	}
+
+	for _, test := range tests {
+		ledger := &fakeLedger{}
+		r := labctl(ledger, test.args...)
+		if r.code != 2 || !strings.Contains(r.stderr, test.wantErr) {
+			t.Errorf("%s: exit code %d, stderr %q, want 2 and %q", test.name, r.code, r.stderr, test.wantErr)
+		}
+		if len(ledger.calls) != 0 {
+			t.Errorf("%s: called %+v", test.name, ledger.calls)
+		}
+	}
+}
+
+func TestGlobalFlags(t *testing.T) {
+	ledger := &fakeLedger{}
+	r := labctl(ledger, "-identity", "teacher", "-chaincode", "labs", "-channel", "course", "lab", "delete", "-cascade", "lab1")
+	if r.code != 0 {
+		t.Fatalf("exit code %d: %s", r.code, r.stderr)
+	}
+
+	if r.config.Identity != "teacher" || r.config.Chaincode != "labs" || r.config.Channel != "course" {
+		t.Errorf("connected with %+v", r.config)
+	}
+	if r.config.CredentialPath != "" {
+		t.Errorf("credentials %q used for an identity of the wallet", r.config.CredentialPath)
+	}
+
+	want := []call{{contract: "lab", name: "DeleteLab", args: []string{"lab1", "cascade"}}}
+	if !reflect.DeepEqual(ledger.calls, want) {
+		t.Errorf("calls %+v, want %+v", ledger.calls, want)
+	}
+	if r.stdout != "lab lab1 deleted\n" {
+		t.Errorf("output %q", r.stdout)
+	}
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"text/tabwriter"
+)
+
+// Output formats
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+)
+
+// printer writes results in the chosen output format
+type printer struct {
+	w      io.Writer
+	format string
+}
+
+func newPrinter(w io.Writer, format string) (*printer, error) {
+	if format != outputTable && format != outputJSON {
+		return nil, fmt.Errorf("unknown output format %q, expected %s or %s", format, outputTable, outputJSON)
+	}
+
+	return &printer{w: w, format: format}, nil
+}
+
+// print writes v. Tables have a row per element of a slice, or a single row
+// for a struct, with a column per field. Nested values are shown as JSON.
+func (p *printer) print(v interface{}) error {
+	if p.format == outputJSON {
+		vBytes, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(p.w, string(vBytes))
+		return err
+	}
+
+	value := reflect.Indirect(reflect.ValueOf(v))
+	rows := []reflect.Value{value}
+	if value.Kind() == reflect.Slice {
+		rows = nil
+		for i := 0; i < value.Len(); i++ {
+			rows = append(rows, reflect.Indirect(value.Index(i)))
+		}
+	}
+
+	tw := tabwriter.NewWriter(p.w, 0, 4, 2, ' ', 0)
+	if value.Kind() == reflect.Slice && value.Type().Elem().Kind() != reflect.Struct && value.Type().Elem().Kind() != reflect.Ptr {
+		for _, row := range rows {
+			fmt.Fprintln(tw, cell(row))
+		}
+		return tw.Flush()
+	}
+
+	structType := value.Type()
+	if value.Kind() == reflect.Slice {
+		structType = value.Type().Elem()
+	}
+	if structType.Kind() == reflect.Ptr {
+		structType = structType.Elem()
+	}
+	if structType.Kind() != reflect.Struct {
+		fmt.Fprintln(tw, cell(value))
+		return tw.Flush()
+	}
+
+	var header []string
+	for i := 0; i < structType.NumField(); i++ {
+		header = append(header, strings.ToUpper(columnName(structType.Field(i))))
+	}
+	fmt.Fprintln(tw, strings.Join(header, "\t"))
+
+	for _, row := range rows {
+		if !row.IsValid() {
+			continue
+		}
+		var cells []string
+		for i := 0; i < row.NumField(); i++ {
+			cells = append(cells, cell(row.Field(i)))
+		}
+		fmt.Fprintln(tw, strings.Join(cells, "\t"))
+	}
+
+	return tw.Flush()
+}
+
+// done reports a command without result. JSON output stays empty so that
+// it can be piped.
+func (p *printer) done(format string, args ...interface{}) {
+	if p.format == outputTable {
+		fmt.Fprintf(p.w, format+"\n", args...)
+	}
+}
+
+// columnName returns the JSON name of a field
+func columnName(field reflect.StructField) string {
+	name := strings.Split(field.Tag.Get("json"), ",")[0]
+	if name == "" {
+		return field.Name
+	}
+
+	return name
+}
+
+// cell formats a value for a table
+func cell(value reflect.Value) string {
+	switch value.Kind() {
+	case reflect.Invalid:
+		return ""
+	case reflect.Ptr, reflect.Slice, reflect.Map, reflect.Struct:
+		if (value.Kind() != reflect.Struct) && value.IsNil() {
+			return ""
+		}
+		vBytes, err := json.Marshal(value.Interface())
+		if err != nil {
+			return err.Error()
+		}
+		return string(vBytes)
+	default:
+		return fmt.Sprint(value.Interface())
+	}
+}
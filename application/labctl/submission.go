@@ -0,0 +1,252 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"mime"
+	"os"
+	"path/filepath"
+
+	"artifact"
+	"client"
+)
+
+// uploadFlags are the flags of the commands handing in a file
+type uploadFlags struct {
+	store     *string
+	mediaType *string
+}
+
+func newUploadFlags(flags *flag.FlagSet) *uploadFlags {
+	return &uploadFlags{
+		store:     flags.String("store", "artifacts", "directory of the artifact store to upload the file to"),
+		mediaType: flags.String("type", "", "media type of the file, guessed from its extension by default"),
+	}
+}
+
+// upload puts a file into the artifact store and returns its reference
+func (u *uploadFlags) upload(path string) (*client.Artifact, error) {
+	store, err := artifact.NewFileStore(*u.store)
+	if err != nil {
+		return nil, err
+	}
+
+	mediaType := *u.mediaType
+	if mediaType == "" {
+		mediaType = mime.TypeByExtension(filepath.Ext(path))
+	}
+	if mediaType == "" {
+		mediaType = "application/octet-stream"
+	}
+
+	file, err := os.Open(filepath.Clean(path))
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	ref, err := store.Put(file, mediaType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to upload %s: %v", path, err)
+	}
+
+	return &client.Artifact{SHA256: ref.SHA256, Size: ref.Size, MediaType: ref.MediaType, URI: ref.URI}, nil
+}
+
+var submissionCommands = map[string]command{
+	"list": {
+		usage: "-class <classID> | -lab <labID> | -owner <owner> | -from <submissionID> -to <submissionID>",
+		run: func(ctx *context, args []string) error {
+			flags := flag.NewFlagSet("submission list", flag.ContinueOnError)
+			classID := flags.String("class", "", "list the submissions of the class")
+			labID := flags.String("lab", "", "list the submissions of the lab")
+			owner := flags.String("owner", "", "list the submissions of the student")
+			from := flags.String("from", "", "list the submissions from this ID")
+			to := flags.String("to", "", "list the submissions up to this ID")
+			_, err := parse(flags, args, 0)
+			if err != nil {
+				return err
+			}
+
+			submissions := ctx.client.Submissions()
+			var list []*client.Submission
+			switch {
+			case *classID != "":
+				list, err = submissions.ListByClass(*classID).All()
+			case *labID != "":
+				list, err = submissions.ListByLab(*labID).All()
+			case *owner != "":
+				list, err = submissions.ListByOwner(*owner).All()
+			default:
+				list, err = submissions.ListByRange(*from, *to).All()
+			}
+			if err != nil {
+				return err
+			}
+
+			return ctx.out.print(list)
+		},
+	},
+	"get": {
+		usage: "<submissionID>",
+		run: func(ctx *context, args []string) error {
+			if err := exactly(args, 1); err != nil {
+				return err
+			}
+
+			submission, err := ctx.client.Submissions().Get(args[0])
+			if err != nil {
+				return err
+			}
+
+			return ctx.out.print(submission)
+		},
+	},
+	"content": {
+		usage: "<submissionID>",
+		run: func(ctx *context, args []string) error {
+			if err := exactly(args, 1); err != nil {
+				return err
+			}
+
+			content, err := ctx.client.Submissions().Content(args[0])
+			if err != nil {
+				return err
+			}
+
+			return ctx.out.print(content.Artifact)
+		},
+	},
+	"submit": {
+		usage: "[-store <dir>] [-type <mediaType>] <labID> <classID> <file>",
+		run: func(ctx *context, args []string) error {
+			flags := flag.NewFlagSet("submission submit", flag.ContinueOnError)
+			upload := newUploadFlags(flags)
+			args, err := parse(flags, args, 3)
+			if err != nil {
+				return err
+			}
+
+			ref, err := upload.upload(args[2])
+			if err != nil {
+				return err
+			}
+
+			submission, err := ctx.client.Submissions().Submit(args[0], args[1], ref)
+			if err != nil {
+				return err
+			}
+
+			return ctx.out.print(submission)
+		},
+	},
+	"create": {
+		usage: "[-store <dir>] [-type <mediaType>] [-owner <owner>] <submissionID> <labID> <classID> <file>",
+		run: func(ctx *context, args []string) error {
+			flags := flag.NewFlagSet("submission create", flag.ContinueOnError)
+			upload := newUploadFlags(flags)
+			owner := flags.String("owner", "", "student to hand in the work for, yourself by default")
+			args, err := parse(flags, args, 4)
+			if err != nil {
+				return err
+			}
+
+			ref, err := upload.upload(args[3])
+			if err != nil {
+				return err
+			}
+
+			submission, err := ctx.client.Submissions().Create(args[0], args[1], args[2], *owner, ref)
+			if err != nil {
+				return err
+			}
+
+			return ctx.out.print(submission)
+		},
+	},
+	"delete": {
+		usage: "<submissionID>",
+		run: func(ctx *context, args []string) error {
+			if err := exactly(args, 1); err != nil {
+				return err
+			}
+
+			err := ctx.client.Submissions().Delete(args[0])
+			if err != nil {
+				return err
+			}
+
+			ctx.out.done("submission %s deleted", args[0])
+			return nil
+		},
+	},
+	"attempts": {
+		usage: "[-owner <owner>] <labID>",
+		run: func(ctx *context, args []string) error {
+			flags := flag.NewFlagSet("submission attempts", flag.ContinueOnError)
+			owner := flags.String("owner", "", "student to list the attempts of, yourself by default")
+			args, err := parse(flags, args, 1)
+			if err != nil {
+				return err
+			}
+
+			attempts, err := ctx.client.Submissions().Attempts(args[0], *owner)
+			if err != nil {
+				return err
+			}
+
+			return ctx.out.print(attempts)
+		},
+	},
+	"latest": {
+		usage: "[-owner <owner>] <labID>",
+		run: func(ctx *context, args []string) error {
+			flags := flag.NewFlagSet("submission latest", flag.ContinueOnError)
+			owner := flags.String("owner", "", "student to read the attempt of, yourself by default")
+			args, err := parse(flags, args, 1)
+			if err != nil {
+				return err
+			}
+
+			submission, err := ctx.client.Submissions().LatestAttempt(args[0], *owner)
+			if err != nil {
+				return err
+			}
+
+			return ctx.out.print(submission)
+		},
+	},
+	"graded": {
+		usage: "[-owner <owner>] <labID>",
+		run: func(ctx *context, args []string) error {
+			flags := flag.NewFlagSet("submission graded", flag.ContinueOnError)
+			owner := flags.String("owner", "", "student to read the attempt of, yourself by default")
+			args, err := parse(flags, args, 1)
+			if err != nil {
+				return err
+			}
+
+			submission, err := ctx.client.Submissions().GradedAttempt(args[0], *owner)
+			if err != nil {
+				return err
+			}
+
+			return ctx.out.print(submission)
+		},
+	},
+	"history": {
+		usage: "<submissionID>",
+		run: func(ctx *context, args []string) error {
+			if err := exactly(args, 1); err != nil {
+				return err
+			}
+
+			history, err := ctx.client.Submissions().History(args[0])
+			if err != nil {
+				return err
+			}
+
+			return ctx.out.print(history)
+		},
+	},
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// chaincodeStatus marks errors returned by the chaincode, as opposed to
+// errors reaching the peers
+const chaincodeStatus = "Chaincode status"
+
+// statusRules maps chaincode error messages to HTTP status codes. The first
+// rule whose text is part of the message applies.
+var statusRules = []struct {
+	text   string
+	status int
+}{
+	{"access denied", http.StatusForbidden},
+	{"not authorized", http.StatusForbidden},
+	{"quota exceeded", http.StatusForbidden},
+	{"not enrolled", http.StatusForbidden},
+	{"does not exist", http.StatusNotFound},
+	{"already exists", http.StatusConflict},
+	{"already enrolled", http.StatusConflict},
+	{"still has", http.StatusConflict},
+	{"open session", http.StatusConflict},
+}
+
+// errorStatus returns the HTTP status code for an error of the client.
+// Chaincode errors nothing else matches are bad requests, any other error
+// means the peers couldn't be reached.
+func errorStatus(err error) int {
+	var bad *badRequest
+	if errors.As(err, &bad) {
+		return http.StatusBadRequest
+	}
+
+	msg := err.Error()
+	for _, rule := range statusRules {
+		if strings.Contains(msg, rule.text) {
+			return rule.status
+		}
+	}
+	if strings.Contains(msg, chaincodeStatus) {
+		return http.StatusBadRequest
+	}
+
+	return http.StatusBadGateway
+}
+
+// errorBody is the body of every error response
+type errorBody struct {
+	Error string `json:"error"`
+}
+
+// writeError answers with the status code of err
+func writeError(w http.ResponseWriter, err error) {
+	writeJSON(w, errorStatus(err), &errorBody{Error: err.Error()})
+}
@@ -0,0 +1,293 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"client"
+)
+
+// timeFormat is the format of the times in responses
+const timeFormat = time.RFC3339
+
+// Session is the response to a login
+type Session struct {
+	Identity string `json:"identity"`
+	Expires  string `json:"expires"`
+}
+
+// NewClass is the body creating a class
+type NewClass struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Content string `json:"content"`
+}
+
+// ClassUpdate is the body updating a class
+type ClassUpdate struct {
+	Name    string `json:"name"`
+	Content string `json:"content"`
+}
+
+// NewLab is the body creating a lab of a class
+type NewLab struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Content   string `json:"content"`
+	Config    string `json:"config"`
+	StartTime string `json:"startTime"`
+	EndTime   string `json:"endTime"`
+}
+
+// NewInstance is the body creating an instance of a lab for the identity of
+// the session
+type NewInstance struct {
+	ID     string `json:"id"`
+	Config string `json:"config"`
+}
+
+// NewSubmission is the body handing in an attempt for a lab
+type NewSubmission struct {
+	Artifact *client.Artifact `json:"artifact"`
+}
+
+// serveClasses lists and creates classes
+func serveClasses(c *client.Client, w http.ResponseWriter, r *http.Request, ids []string) error {
+	switch r.Method {
+	case http.MethodGet:
+		pageSize, bookmark, err := pageParams(r)
+		if err != nil {
+			return err
+		}
+
+		page, err := c.Classes().ListPage(pageSize, bookmark)
+		if err != nil {
+			return err
+		}
+		if page.Records == nil {
+			page.Records = []*client.Class{}
+		}
+		writeJSON(w, http.StatusOK, page)
+	case http.MethodPost:
+		var body NewClass
+		err := readJSON(r, &body)
+		if err != nil {
+			return err
+		}
+
+		class, err := c.Classes().Create(body.ID, body.Name, body.Content)
+		if err != nil {
+			return err
+		}
+		writeJSON(w, http.StatusCreated, class)
+	default:
+		return errMethodNotAllowed
+	}
+
+	return nil
+}
+
+// serveClass reads, updates and deletes a class
+func serveClass(c *client.Client, w http.ResponseWriter, r *http.Request, ids []string) error {
+	switch r.Method {
+	case http.MethodGet:
+		class, err := c.Classes().Get(ids[0])
+		if err != nil {
+			return err
+		}
+		writeJSON(w, http.StatusOK, class)
+	case http.MethodPut:
+		var body ClassUpdate
+		err := readJSON(r, &body)
+		if err != nil {
+			return err
+		}
+
+		class, err := c.Classes().Update(ids[0], body.Name, body.Content)
+		if err != nil {
+			return err
+		}
+		writeJSON(w, http.StatusOK, class)
+	case http.MethodDelete:
+		err := c.Classes().Delete(ids[0], deletePolicy(r))
+		if err != nil {
+			return err
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		return errMethodNotAllowed
+	}
+
+	return nil
+}
+
+// serveClassLabs lists and creates the labs of a class
+func serveClassLabs(c *client.Client, w http.ResponseWriter, r *http.Request, ids []string) error {
+	switch r.Method {
+	case http.MethodGet:
+		pageSize, bookmark, err := pageParams(r)
+		if err != nil {
+			return err
+		}
+
+		page, err := c.Labs().ListByClassPage(ids[0], pageSize, bookmark)
+		if err != nil {
+			return err
+		}
+		if page.Records == nil {
+			page.Records = []*client.Lab{}
+		}
+		writeJSON(w, http.StatusOK, page)
+	case http.MethodPost:
+		var body NewLab
+		err := readJSON(r, &body)
+		if err != nil {
+			return err
+		}
+
+		lab, err := c.Labs().Create(body.ID, ids[0], body.Name, body.Content, body.Config, body.StartTime, body.EndTime)
+		if err != nil {
+			return err
+		}
+		writeJSON(w, http.StatusCreated, lab)
+	default:
+		return errMethodNotAllowed
+	}
+
+	return nil
+}
+
+// serveLab reads and deletes a lab
+func serveLab(c *client.Client, w http.ResponseWriter, r *http.Request, ids []string) error {
+	switch r.Method {
+	case http.MethodGet:
+		lab, err := c.Labs().Get(ids[0])
+		if err != nil {
+			return err
+		}
+		writeJSON(w, http.StatusOK, lab)
+	case http.MethodDelete:
+		err := c.Labs().Delete(ids[0], deletePolicy(r))
+		if err != nil {
+			return err
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		return errMethodNotAllowed
+	}
+
+	return nil
+}
+
+// serveLabInstances lists and creates the instances of a lab
+func serveLabInstances(c *client.Client, w http.ResponseWriter, r *http.Request, ids []string) error {
+	switch r.Method {
+	case http.MethodGet:
+		pageSize, bookmark, err := pageParams(r)
+		if err != nil {
+			return err
+		}
+
+		page, err := c.Instances().ListByLabPage(ids[0], pageSize, bookmark)
+		if err != nil {
+			return err
+		}
+		if page.Records == nil {
+			page.Records = []*client.Instance{}
+		}
+		writeJSON(w, http.StatusOK, page)
+	case http.MethodPost:
+		var body NewInstance
+		err := readJSON(r, &body)
+		if err != nil {
+			return err
+		}
+
+		lab, err := c.Labs().Get(ids[0])
+		if err != nil {
+			return err
+		}
+
+		instance, err := c.Instances().Create(body.ID, lab.ID, lab.ClassID, body.Config, "")
+		if err != nil {
+			return err
+		}
+		writeJSON(w, http.StatusCreated, instance)
+	default:
+		return errMethodNotAllowed
+	}
+
+	return nil
+}
+
+// serveLabSubmissions lists the submissions of a lab and hands in attempts
+// for it
+func serveLabSubmissions(c *client.Client, w http.ResponseWriter, r *http.Request, ids []string) error {
+	switch r.Method {
+	case http.MethodGet:
+		pageSize, bookmark, err := pageParams(r)
+		if err != nil {
+			return err
+		}
+
+		page, err := c.Submissions().ListByLabPage(ids[0], pageSize, bookmark)
+		if err != nil {
+			return err
+		}
+		if page.Records == nil {
+			page.Records = []*client.Submission{}
+		}
+		writeJSON(w, http.StatusOK, page)
+	case http.MethodPost:
+		var body NewSubmission
+		err := readJSON(r, &body)
+		if err != nil {
+			return err
+		}
+
+		lab, err := c.Labs().Get(ids[0])
+		if err != nil {
+			return err
+		}
+
+		submission, err := c.Submissions().Submit(lab.ID, lab.ClassID, body.Artifact)
+		if err != nil {
+			return err
+		}
+		writeJSON(w, http.StatusCreated, submission)
+	default:
+		return errMethodNotAllowed
+	}
+
+	return nil
+}
+
+// deletePolicy returns the policy query parameter of a delete, restrict by
+// default
+func deletePolicy(r *http.Request) string {
+	policy := r.URL.Query().Get("policy")
+	if policy == "" {
+		return client.DeleteRestrict
+	}
+
+	return policy
+}
+
+// pageParams returns the pageSize and bookmark query parameters of a list.
+// Without pageSize the chaincode picks the page size.
+func pageParams(r *http.Request) (int, string, error) {
+	query := r.URL.Query()
+
+	pageSize := 0
+	if value := query.Get("pageSize"); value != "" {
+		var err error
+		pageSize, err = strconv.Atoi(value)
+		if err != nil {
+			return 0, "", &badRequest{fmt.Errorf("invalid pageSize %q", value)}
+		}
+	}
+
+	return pageSize, query.Get("bookmark"), nil
+}
@@ -0,0 +1,298 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+
+	"client"
+)
+
+// memLedger stands in for the labplatform chaincode in tests. It keeps the
+// documents in memory and implements the transactions the server calls,
+// failing them with messages shaped like chaincode errors.
+type memLedger struct {
+	mu          sync.Mutex
+	txs         int
+	classes     map[string]*client.Class
+	labs        map[string]*client.Lab
+	instances   map[string]*client.Instance
+	submissions map[string]*client.Submission
+}
+
+func newMemLedger() *memLedger {
+	return &memLedger{
+		classes:     make(map[string]*client.Class),
+		labs:        make(map[string]*client.Lab),
+		instances:   make(map[string]*client.Instance),
+		submissions: make(map[string]*client.Submission),
+	}
+}
+
+// Get implements Clients, the client calls the ledger as identity
+func (l *memLedger) Get(identity string) (*client.Client, error) {
+	return client.NewWithContracts(func(name string) client.Contract {
+		return &memContract{ledger: l, identity: identity, name: name}
+	}), nil
+}
+
+// chaincodeError returns an error like the gateway returns for a failed
+// transaction
+func chaincodeError(format string, args ...interface{}) error {
+	return fmt.Errorf("%s: (500) UNKNOWN. Description: %s", chaincodeStatus, fmt.Sprintf(format, args...))
+}
+
+// memContract is a contract of a memLedger called by identity
+type memContract struct {
+	ledger   *memLedger
+	identity string
+	name     string
+}
+
+func (c *memContract) EvaluateTransaction(name string, args ...string) ([]byte, error) {
+	return c.invoke(name, nil, args)
+}
+
+func (c *memContract) SubmitTransaction(name string, args ...string) ([]byte, error) {
+	return c.invoke(name, nil, args)
+}
+
+func (c *memContract) EvaluateTransient(name string, transient map[string][]byte, args ...string) ([]byte, error) {
+	return c.invoke(name, transient, args)
+}
+
+func (c *memContract) SubmitTransient(name string, transient map[string][]byte, args ...string) ([]byte, error) {
+	return c.invoke(name, transient, args)
+}
+
+func (c *memContract) invoke(name string, transient map[string][]byte, args []string) ([]byte, error) {
+	c.ledger.mu.Lock()
+	defer c.ledger.mu.Unlock()
+
+	result, err := c.call(c.name+"."+name, transient, args)
+	if err != nil {
+		return nil, err
+	}
+	if result == nil {
+		return nil, nil
+	}
+
+	return json.Marshal(result)
+}
+
+// call runs a transaction, named contract.transaction
+func (c *memContract) call(name string, transient map[string][]byte, args []string) (interface{}, error) {
+	l := c.ledger
+
+	switch name {
+	case "class.GetAllClassses":
+		ids, bookmark, err := pageOf(sortedKeys(l.classes), args[0], args[1])
+		if err != nil {
+			return nil, err
+		}
+		page := &client.ClassPage{Records: []*client.Class{}, Bookmark: bookmark}
+		for _, id := range ids {
+			page.Records = append(page.Records, l.classes[id])
+		}
+		page.FetchedRecordsCount = int32(len(page.Records))
+		return page, nil
+	case "class.ReadClass":
+		class, ok := l.classes[args[0]]
+		if !ok {
+			return nil, chaincodeError("the class %s does not exist", args[0])
+		}
+		return class, nil
+	case "class.CreateClass":
+		if _, ok := l.classes[args[0]]; ok {
+			return nil, chaincodeError("the class %s already exists", args[0])
+		}
+		l.classes[args[0]] = &client.Class{ID: args[0], Name: args[1], Content: args[2], Owner: c.identity, OrgMSP: "Org1MSP"}
+		return nil, nil
+	case "class.UpdateClass":
+		class, ok := l.classes[args[0]]
+		if !ok {
+			return nil, chaincodeError("the class %s does not exist", args[0])
+		}
+		if class.Owner != c.identity {
+			return nil, chaincodeError("submitting client not authorized to update class, does not own class")
+		}
+		class.Name, class.Content = args[1], args[2]
+		return nil, nil
+	case "class.DeleteClass":
+		if _, ok := l.classes[args[0]]; !ok {
+			return nil, chaincodeError("the class %s does not exist", args[0])
+		}
+		for _, lab := range l.labs {
+			if lab.ClassID == args[0] && args[1] != client.DeleteCascade {
+				return nil, chaincodeError("class %s still has labs, delete them first or use the %s policy", args[0], client.DeleteCascade)
+			}
+		}
+		delete(l.classes, args[0])
+		return nil, nil
+	case "lab.GetLabsByClass":
+		var labIDs []string
+		for _, id := range sortedKeys(l.labs) {
+			if l.labs[id].ClassID == args[0] {
+				labIDs = append(labIDs, id)
+			}
+		}
+		ids, bookmark, err := pageOf(labIDs, args[1], args[2])
+		if err != nil {
+			return nil, err
+		}
+		page := &client.LabPage{Records: []*client.Lab{}, Bookmark: bookmark}
+		for _, id := range ids {
+			page.Records = append(page.Records, l.labs[id])
+		}
+		page.FetchedRecordsCount = int32(len(page.Records))
+		return page, nil
+	case "lab.ReadLab":
+		lab, ok := l.labs[args[0]]
+		if !ok {
+			return nil, chaincodeError("the asset %s does not exist", args[0])
+		}
+		return lab, nil
+	case "lab.CreateLab":
+		if _, ok := l.labs[args[0]]; ok {
+			return nil, chaincodeError("lab already exists: %s", args[0])
+		}
+		if _, ok := l.classes[args[1]]; !ok {
+			return nil, chaincodeError("the class %s does not exist", args[1])
+		}
+		l.labs[args[0]] = &client.Lab{
+			ID: args[0], ClassID: args[1], Name: args[2], Content: args[3], Config: args[4],
+			StartTime: args[5], EndTime: args[6], Owner: c.identity,
+		}
+		return nil, nil
+	case "lab.DeleteLab":
+		lab, ok := l.labs[args[0]]
+		if !ok {
+			return nil, chaincodeError("the asset %s does not exist", args[0])
+		}
+		if lab.Owner != c.identity {
+			return nil, chaincodeError("submitting client not authorized to delete lab, does not own lab")
+		}
+		delete(l.labs, args[0])
+		return nil, nil
+	case "instance.GetInstancesByLab":
+		var instanceIDs []string
+		for _, id := range sortedKeys(l.instances) {
+			if l.instances[id].LabID == args[0] {
+				instanceIDs = append(instanceIDs, id)
+			}
+		}
+		ids, bookmark, err := pageOf(instanceIDs, args[1], args[2])
+		if err != nil {
+			return nil, err
+		}
+		page := &client.InstancePage{Records: []*client.Instance{}, Bookmark: bookmark}
+		for _, id := range ids {
+			page.Records = append(page.Records, l.instances[id])
+		}
+		page.FetchedRecordsCount = int32(len(page.Records))
+		return page, nil
+	case "instance.CreateInstance":
+		if _, ok := l.instances[args[0]]; ok {
+			return nil, chaincodeError("instance already exists: %s", args[0])
+		}
+		owner := args[4]
+		if owner == "" {
+			owner = c.identity
+		}
+		l.instances[args[0]] = &client.Instance{ID: args[0], LabID: args[1], ClassID: args[2], Config: args[3], Owner: owner}
+		return nil, nil
+	case "instance.ReadInstance":
+		instance, ok := l.instances[args[0]]
+		if !ok {
+			return nil, chaincodeError("instance %s does not exist", args[0])
+		}
+		return instance, nil
+	case "submission.GetSubmissionsByLab":
+		var submissionIDs []string
+		for _, id := range sortedKeys(l.submissions) {
+			if l.submissions[id].LabID == args[0] {
+				submissionIDs = append(submissionIDs, id)
+			}
+		}
+		ids, bookmark, err := pageOf(submissionIDs, args[1], args[2])
+		if err != nil {
+			return nil, err
+		}
+		page := &client.SubmissionPage{Records: []*client.Submission{}, Bookmark: bookmark}
+		for _, id := range ids {
+			page.Records = append(page.Records, l.submissions[id])
+		}
+		page.FetchedRecordsCount = int32(len(page.Records))
+		return page, nil
+	case "submission.SubmitAttempt":
+		var artifact client.Artifact
+		err := json.Unmarshal(transient["artifact"], &artifact)
+		if err != nil || artifact.SHA256 == "" {
+			return nil, chaincodeError("artifact must be passed in the transient map")
+		}
+
+		l.txs++
+		submission := &client.Submission{ID: fmt.Sprintf("tx%d", l.txs), LabID: args[0], ClassID: args[1], Owner: c.identity, Attempt: 1}
+		for _, other := range l.submissions {
+			if other.LabID == submission.LabID && other.Owner == submission.Owner {
+				submission.Attempt++
+			}
+		}
+		l.submissions[submission.ID] = submission
+		return submission, nil
+	}
+
+	return nil, chaincodeError("function %s not found in contract %s", name, c.name)
+}
+
+// sortedKeys returns the keys of a document map in order
+func sortedKeys(m interface{}) []string {
+	var keys []string
+	switch docs := m.(type) {
+	case map[string]*client.Lab:
+		for key := range docs {
+			keys = append(keys, key)
+		}
+	case map[string]*client.Class:
+		for key := range docs {
+			keys = append(keys, key)
+		}
+	case map[string]*client.Instance:
+		for key := range docs {
+			keys = append(keys, key)
+		}
+	case map[string]*client.Submission:
+		for key := range docs {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// pageOf returns the page of the sorted IDs starting at bookmark, and the
+// bookmark of the next page, like the list transactions of the chaincode.
+// The bookmark is the ID the next page starts at.
+func pageOf(ids []string, pageSize, bookmark string) ([]string, string, error) {
+	size, err := strconv.Atoi(pageSize)
+	if err != nil {
+		return nil, "", chaincodeError("invalid page size %s", pageSize)
+	}
+	if size == 0 {
+		size = 100
+	}
+	if size < 0 || size > 1000 {
+		return nil, "", chaincodeError("page size %d is not between 1 and 1000", size)
+	}
+
+	start := sort.SearchStrings(ids, bookmark)
+	ids = ids[start:]
+	if len(ids) <= size {
+		return ids, "", nil
+	}
+
+	return ids[:size], ids[size], nil
+}
@@ -0,0 +1,46 @@
+/*
+Copyright 2020 IBM All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"client"
+)
+
+func main() {
+	addr := flag.String("addr", ":8080", "address to listen on")
+	keysPath := flag.String("keys", "apikeys.json", "JSON file of the API keys and their wallet identities")
+	openAPI := flag.String("openapi", "openapi.yaml", "OpenAPI document of the API")
+	ttl := flag.Duration("session-ttl", 8*time.Hour, "lifetime of a session")
+	flag.Parse()
+
+	keys, err := LoadAPIKeys(*keysPath)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	pool, err := client.NewPool(client.DefaultConfig())
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	defer pool.Close()
+
+	server := NewServer(pool, NewSessions(keys, *ttl), *openAPI)
+
+	fmt.Printf("listening on %s\n", *addr)
+	err = http.ListenAndServe(*addr, server)
+	if err != nil {
+		fmt.Println(err)
+	}
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"client"
+)
+
+// Clients returns the client of a wallet identity, client.Pool implements it
+type Clients interface {
+	Get(identity string) (*client.Client, error)
+}
+
+// Server serves the labplatform contracts as a REST API, calling them as the
+// identity of the session of each request
+type Server struct {
+	clients  Clients
+	sessions *Sessions
+	openAPI  string
+}
+
+// NewServer returns a server. openAPI is the path of the OpenAPI document
+// served at /openapi.yaml.
+func NewServer(clients Clients, sessions *Sessions, openAPI string) *Server {
+	return &Server{
+		clients:  clients,
+		sessions: sessions,
+		openAPI:  openAPI,
+	}
+}
+
+// errMethodNotAllowed is returned for methods a resource doesn't support
+var errMethodNotAllowed = errors.New("method not allowed")
+
+// errNotFound is returned for unknown resources
+var errNotFound = errors.New("resource does not exist")
+
+// handler serves a resource as the client of the session
+type handler func(c *client.Client, w http.ResponseWriter, r *http.Request, ids []string) error
+
+// ServeHTTP routes a request to the handler of its resource
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/openapi.yaml":
+		http.ServeFile(w, r, s.openAPI)
+		return
+	case "/sessions":
+		s.serveSessions(w, r)
+		return
+	}
+
+	h, ids := route(r.URL.Path)
+	if h == nil {
+		writeJSON(w, http.StatusNotFound, &errorBody{Error: errNotFound.Error()})
+		return
+	}
+
+	identity, err := s.sessions.identity(r)
+	if err != nil {
+		writeJSON(w, http.StatusUnauthorized, &errorBody{Error: err.Error()})
+		return
+	}
+
+	c, err := s.clients.Get(identity)
+	if err != nil {
+		writeJSON(w, http.StatusBadGateway, &errorBody{Error: err.Error()})
+		return
+	}
+
+	err = h(c, w, r, ids)
+	if err == errMethodNotAllowed {
+		writeJSON(w, http.StatusMethodNotAllowed, &errorBody{Error: err.Error()})
+		return
+	}
+	if err != nil {
+		writeError(w, err)
+	}
+}
+
+// route returns the handler of a path and the IDs in it
+func route(path string) (handler, []string) {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	for _, part := range parts {
+		if part == "" {
+			return nil, nil
+		}
+	}
+
+	switch {
+	case len(parts) == 1 && parts[0] == "classes":
+		return serveClasses, nil
+	case len(parts) == 2 && parts[0] == "classes":
+		return serveClass, parts[1:]
+	case len(parts) == 3 && parts[0] == "classes" && parts[2] == "labs":
+		return serveClassLabs, parts[1:2]
+	case len(parts) == 2 && parts[0] == "labs":
+		return serveLab, parts[1:]
+	case len(parts) == 3 && parts[0] == "labs" && parts[2] == "instances":
+		return serveLabInstances, parts[1:2]
+	case len(parts) == 3 && parts[0] == "labs" && parts[2] == "submissions":
+		return serveLabSubmissions, parts[1:2]
+	}
+
+	return nil, nil
+}
+
+// serveSessions logs in with an API key and out again
+func (s *Server) serveSessions(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		id, sess, err := s.sessions.login(r)
+		if err == errUnauthenticated {
+			writeJSON(w, http.StatusUnauthorized, &errorBody{Error: "invalid API key"})
+			return
+		}
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, &errorBody{Error: err.Error()})
+			return
+		}
+
+		http.SetCookie(w, &http.Cookie{
+			Name:     sessionCookie,
+			Value:    id,
+			Path:     "/",
+			Expires:  sess.expires,
+			HttpOnly: true,
+			Secure:   r.TLS != nil,
+			SameSite: http.SameSiteStrictMode,
+		})
+		writeJSON(w, http.StatusCreated, &Session{Identity: sess.identity, Expires: sess.expires.UTC().Format(timeFormat)})
+	case http.MethodDelete:
+		s.sessions.logout(r)
+		http.SetCookie(w, &http.Cookie{Name: sessionCookie, Path: "/", MaxAge: -1})
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeJSON(w, http.StatusMethodNotAllowed, &errorBody{Error: errMethodNotAllowed.Error()})
+	}
+}
+
+// writeJSON answers with v as JSON
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	err := json.NewEncoder(w).Encode(v)
+	if err != nil {
+		fmt.Printf("failed to write response: %v\n", err)
+	}
+}
+
+// badRequest is an error in the request itself, answered with 400
+type badRequest struct {
+	err error
+}
+
+func (e *badRequest) Error() string {
+	return e.err.Error()
+}
+
+// readJSON decodes the body of a request into v
+func readJSON(r *http.Request, v interface{}) error {
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+
+	err := decoder.Decode(v)
+	if err != nil {
+		return &badRequest{fmt.Errorf("invalid request body: %v", err)}
+	}
+
+	return nil
+}
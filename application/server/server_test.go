@@ -0,0 +1,312 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"client"
+)
+
+// apiKey returns the APIKey entry of a key
+func apiKey(key, identity string) APIKey {
+	digest := sha256.Sum256([]byte(key))
+	return APIKey{SHA256: hex.EncodeToString(digest[:]), Identity: identity}
+}
+
+// testServer is a server on an in-memory ledger, with the API keys
+// "teacher-key" and "student-key"
+type testServer struct {
+	t        *testing.T
+	ledger   *memLedger
+	sessions *Sessions
+	handler  http.Handler
+}
+
+func newTestServer(t *testing.T) *testServer {
+	ledger := newMemLedger()
+	sessions := NewSessions([]APIKey{
+		apiKey("teacher-key", "teacher"),
+		apiKey("student-key", "student"),
+	}, time.Hour)
+
+	return &testServer{
+		t:        t,
+		ledger:   ledger,
+		sessions: sessions,
+		handler:  NewServer(ledger, sessions, "openapi.yaml"),
+	}
+}
+
+// login opens a session with key and returns its cookie
+func (s *testServer) login(key string) *http.Cookie {
+	req := httptest.NewRequest(http.MethodPost, "/sessions", nil)
+	req.Header.Set("Authorization", "Bearer "+key)
+	rec := httptest.NewRecorder()
+	s.handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusCreated {
+		s.t.Fatalf("login with %s: status %d, body %s", key, rec.Code, rec.Body)
+	}
+	for _, cookie := range rec.Result().Cookies() {
+		if cookie.Name == sessionCookie {
+			return cookie
+		}
+	}
+	s.t.Fatalf("login with %s set no session cookie", key)
+
+	return nil
+}
+
+// do sends a request in the session of cookie, with body as JSON when not
+// nil, and decodes the response into v when not nil
+func (s *testServer) do(cookie *http.Cookie, method, path string, body, v interface{}) int {
+	var reqBody bytes.Buffer
+	if body != nil {
+		err := json.NewEncoder(&reqBody).Encode(body)
+		if err != nil {
+			s.t.Fatal(err)
+		}
+	}
+
+	req := httptest.NewRequest(method, path, &reqBody)
+	if cookie != nil {
+		req.AddCookie(cookie)
+	}
+	rec := httptest.NewRecorder()
+	s.handler.ServeHTTP(rec, req)
+
+	if v != nil {
+		err := json.Unmarshal(rec.Body.Bytes(), v)
+		if err != nil {
+			s.t.Fatalf("%s %s: failed to decode %s: %v", method, path, rec.Body, err)
+		}
+	}
+
+	return rec.Code
+}
+
+// expect fails the test when a request doesn't answer with status
+func (s *testServer) expect(cookie *http.Cookie, method, path string, body interface{}, status int) {
+	code := s.do(cookie, method, path, body, nil)
+	if code != status {
+		s.t.Errorf("%s %s: status %d, want %d", method, path, code, status)
+	}
+}
+
+func TestSessions(t *testing.T) {
+	s := newTestServer(t)
+
+	s.expect(nil, http.MethodGet, "/classes", nil, http.StatusUnauthorized)
+
+	req := httptest.NewRequest(http.MethodPost, "/sessions", nil)
+	req.Header.Set("Authorization", "Bearer wrong-key")
+	rec := httptest.NewRecorder()
+	s.handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("login with a wrong key: status %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+
+	cookie := s.login("teacher-key")
+	s.expect(cookie, http.MethodGet, "/classes", nil, http.StatusOK)
+
+	s.expect(cookie, http.MethodDelete, "/sessions", nil, http.StatusNoContent)
+	s.expect(cookie, http.MethodGet, "/classes", nil, http.StatusUnauthorized)
+}
+
+func TestSessionExpiry(t *testing.T) {
+	s := newTestServer(t)
+	now := time.Now()
+	s.sessions.now = func() time.Time { return now }
+
+	cookie := s.login("teacher-key")
+	s.expect(cookie, http.MethodGet, "/classes", nil, http.StatusOK)
+
+	now = now.Add(2 * time.Hour)
+	s.expect(cookie, http.MethodGet, "/classes", nil, http.StatusUnauthorized)
+}
+
+func TestLoginPrunesExpiredSessions(t *testing.T) {
+	s := newTestServer(t)
+	now := time.Now()
+	s.sessions.now = func() time.Time { return now }
+
+	s.login("teacher-key")
+	s.login("student-key")
+	now = now.Add(2 * time.Hour)
+	cookie := s.login("teacher-key")
+
+	if len(s.sessions.sessions) != 1 {
+		t.Errorf("%d sessions kept, want only the new one", len(s.sessions.sessions))
+	}
+	s.expect(cookie, http.MethodGet, "/classes", nil, http.StatusOK)
+}
+
+func TestClasses(t *testing.T) {
+	s := newTestServer(t)
+	teacher := s.login("teacher-key")
+	student := s.login("student-key")
+
+	var classes client.ClassPage
+	s.do(teacher, http.MethodGet, "/classes", nil, &classes)
+	if classes.Records == nil || len(classes.Records) != 0 || classes.Bookmark != "" {
+		t.Errorf("GET /classes returned %+v, want an empty page", classes)
+	}
+
+	var class client.Class
+	code := s.do(teacher, http.MethodPost, "/classes", &NewClass{ID: "class1", Name: "Networks", Content: "intro"}, &class)
+	if code != http.StatusCreated {
+		t.Fatalf("POST /classes: status %d, want %d", code, http.StatusCreated)
+	}
+	if class.ID != "class1" || class.Owner != "teacher" {
+		t.Errorf("POST /classes created %+v", class)
+	}
+
+	s.expect(teacher, http.MethodPost, "/classes", &NewClass{ID: "class1", Name: "Networks"}, http.StatusConflict)
+	s.expect(teacher, http.MethodGet, "/classes/class2", nil, http.StatusNotFound)
+	s.expect(student, http.MethodPut, "/classes/class1", &ClassUpdate{Name: "Mine"}, http.StatusForbidden)
+
+	code = s.do(teacher, http.MethodPut, "/classes/class1", &ClassUpdate{Name: "Networking", Content: "intro"}, &class)
+	if code != http.StatusOK || class.Name != "Networking" {
+		t.Errorf("PUT /classes/class1: status %d, class %+v", code, class)
+	}
+
+	s.expect(teacher, http.MethodPatch, "/classes/class1", nil, http.StatusMethodNotAllowed)
+	s.expect(teacher, http.MethodDelete, "/classes/class1", nil, http.StatusNoContent)
+	s.expect(teacher, http.MethodGet, "/classes/class1", nil, http.StatusNotFound)
+}
+
+func TestListPages(t *testing.T) {
+	s := newTestServer(t)
+	teacher := s.login("teacher-key")
+
+	for _, id := range []string{"class1", "class2", "class3"} {
+		s.expect(teacher, http.MethodPost, "/classes", &NewClass{ID: id, Name: "Networks"}, http.StatusCreated)
+	}
+
+	var ids []string
+	path := "/classes?pageSize=2"
+	for pages := 0; pages < 3; pages++ {
+		var page client.ClassPage
+		code := s.do(teacher, http.MethodGet, path, nil, &page)
+		if code != http.StatusOK {
+			t.Fatalf("GET %s: status %d", path, code)
+		}
+		for _, class := range page.Records {
+			ids = append(ids, class.ID)
+		}
+		if page.Bookmark == "" {
+			break
+		}
+		path = "/classes?pageSize=2&bookmark=" + page.Bookmark
+	}
+	if !reflect.DeepEqual(ids, []string{"class1", "class2", "class3"}) {
+		t.Errorf("pages listed classes %v", ids)
+	}
+
+	s.expect(teacher, http.MethodGet, "/classes?pageSize=many", nil, http.StatusBadRequest)
+	s.expect(teacher, http.MethodGet, "/classes?pageSize=5000", nil, http.StatusBadRequest)
+}
+
+func TestClassLabs(t *testing.T) {
+	s := newTestServer(t)
+	teacher := s.login("teacher-key")
+
+	s.expect(teacher, http.MethodPost, "/classes", &NewClass{ID: "class1", Name: "Networks"}, http.StatusCreated)
+
+	lab := &NewLab{ID: "lab1", Name: "Routing", Config: "{}", StartTime: "2022-09-01T00:00:00Z", EndTime: "2022-12-31T23:59:59Z"}
+	s.expect(teacher, http.MethodPost, "/classes/class1/labs", lab, http.StatusCreated)
+	s.expect(teacher, http.MethodPost, "/classes/class2/labs", &NewLab{ID: "lab2"}, http.StatusNotFound)
+
+	var labs client.LabPage
+	s.do(teacher, http.MethodGet, "/classes/class1/labs", nil, &labs)
+	if len(labs.Records) != 1 || labs.Records[0].ID != "lab1" || labs.Records[0].ClassID != "class1" {
+		t.Errorf("GET /classes/class1/labs returned %+v", labs)
+	}
+
+	s.expect(teacher, http.MethodDelete, "/classes/class1", nil, http.StatusConflict)
+	s.expect(teacher, http.MethodDelete, "/classes/class1?policy=cascade", nil, http.StatusNoContent)
+}
+
+func TestLabInstancesAndSubmissions(t *testing.T) {
+	s := newTestServer(t)
+	teacher := s.login("teacher-key")
+	student := s.login("student-key")
+
+	s.expect(teacher, http.MethodPost, "/classes", &NewClass{ID: "class1", Name: "Networks"}, http.StatusCreated)
+	s.expect(teacher, http.MethodPost, "/classes/class1/labs", &NewLab{ID: "lab1", Name: "Routing", Config: "{}"}, http.StatusCreated)
+
+	var instance client.Instance
+	code := s.do(student, http.MethodPost, "/labs/lab1/instances", &NewInstance{ID: "instance1", Config: "{}"}, &instance)
+	if code != http.StatusCreated || instance.ClassID != "class1" || instance.LabID != "lab1" || instance.Owner != "student" {
+		t.Errorf("POST /labs/lab1/instances: status %d, instance %+v", code, instance)
+	}
+
+	var instances client.InstancePage
+	s.do(teacher, http.MethodGet, "/labs/lab1/instances", nil, &instances)
+	if len(instances.Records) != 1 || instances.Records[0].ID != "instance1" {
+		t.Errorf("GET /labs/lab1/instances returned %+v", instances)
+	}
+
+	artifact := &client.Artifact{SHA256: "b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9", Size: 11, MediaType: "text/plain", URI: "file:///tmp/work"}
+	for attempt := uint32(1); attempt <= 2; attempt++ {
+		var submission client.Submission
+		code = s.do(student, http.MethodPost, "/labs/lab1/submissions", &NewSubmission{Artifact: artifact}, &submission)
+		if code != http.StatusCreated || submission.Owner != "student" || submission.Attempt != attempt {
+			t.Errorf("POST /labs/lab1/submissions: status %d, submission %+v", code, submission)
+		}
+	}
+	s.expect(student, http.MethodPost, "/labs/lab1/submissions", &NewSubmission{}, http.StatusBadRequest)
+	s.expect(student, http.MethodPost, "/labs/lab1/submissions", map[string]string{"content": "x"}, http.StatusBadRequest)
+
+	var submissions client.SubmissionPage
+	s.do(teacher, http.MethodGet, "/labs/lab1/submissions", nil, &submissions)
+	if len(submissions.Records) != 2 || submissions.FetchedRecordsCount != 2 {
+		t.Errorf("GET /labs/lab1/submissions returned %+v", submissions)
+	}
+
+	s.expect(student, http.MethodDelete, "/labs/lab1", nil, http.StatusForbidden)
+	s.expect(teacher, http.MethodDelete, "/labs/lab1", nil, http.StatusNoContent)
+	s.expect(teacher, http.MethodGet, "/labs/lab1", nil, http.StatusNotFound)
+}
+
+func TestUnknownPath(t *testing.T) {
+	s := newTestServer(t)
+	teacher := s.login("teacher-key")
+
+	for _, path := range []string{"/", "/classes/class1/students", "/labs", "/classes//labs"} {
+		s.expect(teacher, http.MethodGet, path, nil, http.StatusNotFound)
+	}
+}
+
+func TestErrorStatus(t *testing.T) {
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{chaincodeError("the class class1 does not exist"), http.StatusNotFound},
+		{chaincodeError("submitting client not authorized to update lab, does not own lab"), http.StatusForbidden},
+		{chaincodeError("access denied: transaction DeleteLab requires one of the roles staff"), http.StatusForbidden},
+		{chaincodeError("quota exceeded for lab lab1: instances is 3, limit is 3"), http.StatusForbidden},
+		{chaincodeError("student2 is not enrolled in class class1"), http.StatusForbidden},
+		{chaincodeError("lab already exists: lab1"), http.StatusConflict},
+		{chaincodeError("instance instance1 has an open session, stop it first"), http.StatusConflict},
+		{chaincodeError("start time 2022 is not an RFC 3339 timestamp"), http.StatusBadRequest},
+		{&badRequest{errors.New("invalid request body: EOF")}, http.StatusBadRequest},
+		{errors.New("failed to evaluate transaction: connection refused"), http.StatusBadGateway},
+	}
+
+	for _, test := range tests {
+		got := errorStatus(test.err)
+		if got != test.want {
+			t.Errorf("errorStatus(%q) = %d, want %d", test.err, got, test.want)
+		}
+	}
+}
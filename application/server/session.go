@@ -0,0 +1,160 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// sessionCookie is the name of the cookie holding the session ID
+const sessionCookie = "labplatform_session"
+
+// errUnauthenticated is returned for requests without a valid session
+var errUnauthenticated = errors.New("no valid session, log in with POST /sessions")
+
+// APIKey lets its holder log in as an identity of the wallet. Only the
+// SHA-256 digest of the key is kept, keys are random and long enough for a
+// fast hash.
+type APIKey struct {
+	SHA256   string `json:"sha256"`
+	Identity string `json:"identity"`
+}
+
+// LoadAPIKeys reads a JSON array of APIKey from a file
+func LoadAPIKeys(path string) ([]APIKey, error) {
+	keysBytes, err := ioutil.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read API keys: %v", err)
+	}
+
+	var keys []APIKey
+	err = json.Unmarshal(keysBytes, &keys)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode API keys: %v", err)
+	}
+
+	return keys, nil
+}
+
+// session is a logged in wallet identity
+type session struct {
+	identity string
+	expires  time.Time
+}
+
+// Sessions authenticates API keys and keeps the sessions they open
+type Sessions struct {
+	keys []APIKey
+	ttl  time.Duration
+	now  func() time.Time
+
+	mu       sync.Mutex
+	sessions map[string]*session
+}
+
+// NewSessions returns a session store for keys. Sessions expire ttl after
+// login.
+func NewSessions(keys []APIKey, ttl time.Duration) *Sessions {
+	return &Sessions{
+		keys:     keys,
+		ttl:      ttl,
+		now:      time.Now,
+		sessions: make(map[string]*session),
+	}
+}
+
+// identityOf returns the identity of an API key
+func (s *Sessions) identityOf(key string) (string, bool) {
+	digest := sha256.Sum256([]byte(key))
+	keyHash := hex.EncodeToString(digest[:])
+
+	for _, apiKey := range s.keys {
+		if subtle.ConstantTimeCompare([]byte(strings.ToLower(apiKey.SHA256)), []byte(keyHash)) == 1 {
+			return apiKey.Identity, true
+		}
+	}
+
+	return "", false
+}
+
+// login opens a session for the API key in the Authorization header
+func (s *Sessions) login(r *http.Request) (string, *session, error) {
+	key := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	identity, ok := s.identityOf(key)
+	if key == "" || !ok {
+		return "", nil, errUnauthenticated
+	}
+
+	idBytes := make([]byte, 32)
+	_, err := rand.Read(idBytes)
+	if err != nil {
+		return "", nil, err
+	}
+	id := hex.EncodeToString(idBytes)
+
+	sess := &session{identity: identity, expires: s.now().Add(s.ttl)}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// expired sessions nobody uses again would pile up otherwise
+	s.prune()
+	s.sessions[id] = sess
+
+	return id, sess, nil
+}
+
+// prune removes the expired sessions, s.mu has to be held
+func (s *Sessions) prune() {
+	now := s.now()
+	for id, sess := range s.sessions {
+		if !now.Before(sess.expires) {
+			delete(s.sessions, id)
+		}
+	}
+}
+
+// logout ends the session of a request
+func (s *Sessions) logout(r *http.Request) {
+	cookie, err := r.Cookie(sessionCookie)
+	if err != nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.sessions, cookie.Value)
+}
+
+// identity returns the identity of the session of a request
+func (s *Sessions) identity(r *http.Request) (string, error) {
+	cookie, err := r.Cookie(sessionCookie)
+	if err != nil {
+		return "", errUnauthenticated
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sess, ok := s.sessions[cookie.Value]
+	if !ok {
+		return "", errUnauthenticated
+	}
+	if !s.now().Before(sess.expires) {
+		delete(s.sessions, cookie.Value)
+		return "", errUnauthenticated
+	}
+
+	return sess.identity, nil
+}
@@ -0,0 +1,67 @@
+/*
+Copyright 2020 IBM All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"client"
+)
+
+// show prints v as JSON, or err when the call failed
+func show(v interface{}, err error) {
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	vBytes, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println(string(vBytes))
+}
+
+func main() {
+	c, err := client.Connect(client.DefaultConfig())
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer c.Close()
+
+	submissions := c.Submissions()
+	show(submissions.ListByLab("lab1").All())
+	show(submissions.ListByClass("class1").All())
+	show(submissions.ListByOwner("student1").All())
+	show(submissions.ListByRange("", "").All())
+
+	work := []byte("hello world\n")
+	digest := sha256.Sum256(work)
+	artifact := &client.Artifact{
+		SHA256:    hex.EncodeToString(digest[:]),
+		Size:      uint64(len(work)),
+		MediaType: "text/plain",
+		URI:       "file:///tmp/submission7.txt",
+	}
+	show(submissions.Create("submission7", "lab1", "class1", "", artifact))
+	show(submissions.Content("submission7"))
+
+	fmt.Println(submissions.UpdateScore("submission7", &client.GradeDetails{Total: 90, Feedback: "good"}))
+	grade, err := submissions.GetGrade("submission7")
+	show(grade, err)
+	if err == nil {
+		show(submissions.VerifyGrade("submission7", grade))
+	}
+	show(submissions.Get("submission7"))
+	show(submissions.History("submission7"))
+	fmt.Println(submissions.Delete("submission7"))
+}
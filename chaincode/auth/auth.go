@@ -0,0 +1,170 @@
+// Package auth maps the client identity of a transaction to platform roles and
+// checks them against the roles each transaction declares.
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// Role is what a client identity is allowed to do on the platform
+type Role string
+
+const (
+	Admin      Role = "admin"
+	Instructor Role = "instructor"
+	TA         Role = "ta"
+	Student    Role = "student"
+)
+
+// Staff are the roles that run a class, Everyone are all roles
+var (
+	Staff    = []Role{Admin, Instructor, TA}
+	Everyone = []Role{Admin, Instructor, TA, Student}
+)
+
+// RoleAttribute is the certificate attribute carrying the roles of an
+// identity, comma separated. Register it with the Fabric CA, for example
+// --id.attrs 'labplatform.role=instructor:ecert'.
+const RoleAttribute = "labplatform.role"
+
+// RolesOf returns the roles of the submitting client. Roles are taken from
+// RoleAttribute, from the hf.Type attribute the Fabric CA puts in every
+// enrollment certificate (type admin grants Admin) and from the class.creator
+// attribute CreateClass used to check (grants Instructor). Identities without
+// any of these are students.
+func RolesOf(ctx contractapi.TransactionContextInterface) ([]Role, error) {
+	identity := ctx.GetClientIdentity()
+
+	var roles []Role
+	value, found, err := identity.GetAttributeValue(RoleAttribute)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s attribute: %v", RoleAttribute, err)
+	}
+	if found {
+		for _, name := range strings.Split(value, ",") {
+			role := Role(strings.TrimSpace(name))
+			if !contains(Everyone, role) {
+				return nil, fmt.Errorf("unknown role %q in %s attribute", role, RoleAttribute)
+			}
+			roles = append(roles, role)
+		}
+	}
+
+	value, found, err = identity.GetAttributeValue("hf.Type")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read hf.Type attribute: %v", err)
+	}
+	if found && value == "admin" {
+		roles = append(roles, Admin)
+	}
+
+	value, found, err = identity.GetAttributeValue("class.creator")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read class.creator attribute: %v", err)
+	}
+	if found && value == "true" {
+		roles = append(roles, Instructor)
+	}
+
+	if len(roles) == 0 {
+		roles = append(roles, Student)
+	}
+
+	return roles, nil
+}
+
+// HasRole returns true when the submitting client has any of the roles
+func HasRole(ctx contractapi.TransactionContextInterface, roles ...Role) (bool, error) {
+	callerRoles, err := RolesOf(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	for _, role := range callerRoles {
+		if contains(roles, role) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// AccessDeniedError is returned when the submitting client has none of the
+// roles a transaction allows.
+type AccessDeniedError struct {
+	Transaction string
+	Allowed     []Role
+	Roles       []Role
+}
+
+func (e *AccessDeniedError) Error() string {
+	if len(e.Allowed) == 0 {
+		return fmt.Sprintf("access denied: transaction %s declares no roles", e.Transaction)
+	}
+	return fmt.Sprintf("access denied: transaction %s requires one of the roles %s, submitting client has %s",
+		e.Transaction, joinRoles(e.Allowed), joinRoles(e.Roles))
+}
+
+// IsAccessDenied returns true when err is or wraps an AccessDeniedError
+func IsAccessDenied(err error) bool {
+	var denied *AccessDeniedError
+	return errors.As(err, &denied)
+}
+
+// Permissions lists the roles allowed to call each transaction of a contract.
+// Transactions missing from the list can't be called by anyone.
+type Permissions map[string][]Role
+
+// Check returns an AccessDeniedError unless the submitting client has one of
+// the roles allowed to call transaction.
+func (p Permissions) Check(ctx contractapi.TransactionContextInterface, transaction string) error {
+	allowed := p[transaction]
+
+	roles, err := RolesOf(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, role := range roles {
+		if contains(allowed, role) {
+			return nil
+		}
+	}
+
+	return &AccessDeniedError{Transaction: transaction, Allowed: allowed, Roles: roles}
+}
+
+// BeforeTransaction returns a function to set as the BeforeTransaction of a
+// contract, so that every transaction of the contract is checked against p.
+func (p Permissions) BeforeTransaction() func(contractapi.TransactionContextInterface) error {
+	return func(ctx contractapi.TransactionContextInterface) error {
+		// Functions of named contracts are invoked as <contract>:<function>
+		function, _ := ctx.GetStub().GetFunctionAndParameters()
+		if i := strings.LastIndex(function, ":"); i >= 0 {
+			function = function[i+1:]
+		}
+
+		return p.Check(ctx, function)
+	}
+}
+
+func contains(roles []Role, role Role) bool {
+	for _, r := range roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+func joinRoles(roles []Role) string {
+	names := make([]string, len(roles))
+	for i, role := range roles {
+		names[i] = string(role)
+	}
+	return strings.Join(names, ", ")
+}
@@ -1,4 +1,4 @@
-module class
+module auth
 
 go 1.17
 
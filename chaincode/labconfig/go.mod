@@ -0,0 +1,3 @@
+module labconfig
+
+go 1.17
@@ -0,0 +1,182 @@
+// Package labconfig defines the configuration of lab environments shared by
+// the lab and instance chaincodes. Configs are stored on the ledger as the
+// canonical JSON encoding returned by Canonical. schema.json is documentation
+// for clients only: nothing validates configs against it, Validate is what the
+// chaincodes enforce, and the tests keep the schema in line with it.
+package labconfig
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+)
+
+// Version is the version of the config schema implemented by this package
+const Version = 1
+
+// Protocols a port can be exposed with
+const (
+	TCP = "tcp"
+	UDP = "udp"
+)
+
+// Config describes the environment of a lab or of one instance of it
+type Config struct {
+	Version          uint32            `json:"version"`
+	Image            string            `json:"image"`
+	Resources        Resources         `json:"resources"`
+	Ports            []Port            `json:"ports,omitempty"`
+	Env              map[string]string `json:"env,omitempty"`
+	TimeLimitMinutes uint32            `json:"timeLimitMinutes"`
+}
+
+// Resources are the CPU and memory limits of an environment. CPU is given in
+// millicores, memory in MiB.
+type Resources struct {
+	CPUMillis uint32 `json:"cpuMillis"`
+	MemoryMiB uint32 `json:"memoryMiB"`
+}
+
+// Port is a port exposed by an environment
+type Port struct {
+	Port     uint16 `json:"port"`
+	Protocol string `json:"protocol"`
+}
+
+var (
+	imagePattern  = regexp.MustCompile(`^[a-z0-9]+([._/:@-][a-zA-Z0-9_]+)*$`)
+	envKeyPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+)
+
+// Parse decodes and validates a config. Unknown fields are rejected.
+func Parse(s string) (*Config, error) {
+	decoder := json.NewDecoder(bytes.NewReader([]byte(s)))
+	decoder.DisallowUnknownFields()
+
+	var config Config
+	err := decoder.Decode(&config)
+	if err != nil {
+		return nil, fmt.Errorf("invalid config: %v", err)
+	}
+	if decoder.More() {
+		return nil, fmt.Errorf("invalid config: trailing data after config")
+	}
+
+	err = config.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	return &config, nil
+}
+
+// Validate checks a config against the schema
+func (c *Config) Validate() error {
+	if c.Version != Version {
+		return fmt.Errorf("invalid config: unsupported version %d, expected %d", c.Version, Version)
+	}
+	if !imagePattern.MatchString(c.Image) {
+		return fmt.Errorf("invalid config: image %q is not a container image reference", c.Image)
+	}
+	if c.Resources.CPUMillis == 0 {
+		return fmt.Errorf("invalid config: cpu limit must be positive")
+	}
+	if c.Resources.MemoryMiB == 0 {
+		return fmt.Errorf("invalid config: memory limit must be positive")
+	}
+
+	ports := make(map[Port]bool)
+	for _, port := range c.Ports {
+		if port.Port == 0 {
+			return fmt.Errorf("invalid config: port must be positive")
+		}
+		if port.Protocol != TCP && port.Protocol != UDP {
+			return fmt.Errorf("invalid config: unknown protocol %q for port %d", port.Protocol, port.Port)
+		}
+		if ports[port] {
+			return fmt.Errorf("invalid config: port %d/%s is listed twice", port.Port, port.Protocol)
+		}
+		ports[port] = true
+	}
+
+	for key := range c.Env {
+		if !envKeyPattern.MatchString(key) {
+			return fmt.Errorf("invalid config: %q is not an environment variable name", key)
+		}
+	}
+
+	if c.TimeLimitMinutes == 0 {
+		return fmt.Errorf("invalid config: time limit must be positive")
+	}
+
+	return nil
+}
+
+// Canonical returns the JSON encoding configs are stored as. Ports are
+// sorted, map keys are sorted by encoding/json.
+func (c *Config) Canonical() (string, error) {
+	sorted := *c
+	sorted.Ports = append([]Port(nil), c.Ports...)
+	sort.Slice(sorted.Ports, func(i, j int) bool {
+		if sorted.Ports[i].Port != sorted.Ports[j].Port {
+			return sorted.Ports[i].Port < sorted.Ports[j].Port
+		}
+		return sorted.Ports[i].Protocol < sorted.Ports[j].Protocol
+	})
+
+	configBytes, err := json.Marshal(&sorted)
+	if err != nil {
+		return "", err
+	}
+
+	return string(configBytes), nil
+}
+
+// Normalize parses a config and returns its canonical encoding
+func Normalize(s string) (string, error) {
+	config, err := Parse(s)
+	if err != nil {
+		return "", err
+	}
+
+	return config.Canonical()
+}
+
+// DerivedFrom checks that an instance config stays within the config of its
+// lab: same image, no higher limits, only ports the lab exposes, and the
+// environment of the lab unchanged. Instances may add environment variables.
+func (c *Config) DerivedFrom(lab *Config) error {
+	if c.Image != lab.Image {
+		return fmt.Errorf("instance config uses image %s, lab uses %s", c.Image, lab.Image)
+	}
+	if c.Resources.CPUMillis > lab.Resources.CPUMillis {
+		return fmt.Errorf("instance config asks for %d millicores, lab allows %d", c.Resources.CPUMillis, lab.Resources.CPUMillis)
+	}
+	if c.Resources.MemoryMiB > lab.Resources.MemoryMiB {
+		return fmt.Errorf("instance config asks for %d MiB, lab allows %d", c.Resources.MemoryMiB, lab.Resources.MemoryMiB)
+	}
+
+	ports := make(map[Port]bool)
+	for _, port := range lab.Ports {
+		ports[port] = true
+	}
+	for _, port := range c.Ports {
+		if !ports[port] {
+			return fmt.Errorf("instance config exposes port %d/%s, lab does not", port.Port, port.Protocol)
+		}
+	}
+
+	for key, value := range lab.Env {
+		if instanceValue, ok := c.Env[key]; !ok || instanceValue != value {
+			return fmt.Errorf("instance config changes environment variable %s of the lab", key)
+		}
+	}
+
+	if c.TimeLimitMinutes > lab.TimeLimitMinutes {
+		return fmt.Errorf("instance config asks for %d minutes, lab allows %d", c.TimeLimitMinutes, lab.TimeLimitMinutes)
+	}
+
+	return nil
+}
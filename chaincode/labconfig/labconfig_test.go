@@ -0,0 +1,204 @@
+package labconfig
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+// validConfig returns a config passing Validate, tests change one field
+func validConfig() *Config {
+	return &Config{
+		Version:          Version,
+		Image:            "registry.example.com/labs/router:1.2",
+		Resources:        Resources{CPUMillis: 1000, MemoryMiB: 512},
+		Ports:            []Port{{Port: 22, Protocol: TCP}, {Port: 161, Protocol: UDP}},
+		Env:              map[string]string{"LAB_MODE": "routing"},
+		TimeLimitMinutes: 120,
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := map[string]struct {
+		change  func(c *Config)
+		wantErr string
+	}{
+		"valid":           {func(c *Config) {}, ""},
+		"no ports or env": {func(c *Config) { c.Ports, c.Env = nil, nil }, ""},
+		"version":         {func(c *Config) { c.Version = 2 }, "unsupported version 2"},
+		"empty image":     {func(c *Config) { c.Image = "" }, "is not a container image reference"},
+		"image":           {func(c *Config) { c.Image = "Router; rm -rf /" }, "is not a container image reference"},
+		"cpu":             {func(c *Config) { c.Resources.CPUMillis = 0 }, "cpu limit must be positive"},
+		"memory":          {func(c *Config) { c.Resources.MemoryMiB = 0 }, "memory limit must be positive"},
+		"port":            {func(c *Config) { c.Ports = []Port{{Port: 0, Protocol: TCP}} }, "port must be positive"},
+		"protocol":        {func(c *Config) { c.Ports = []Port{{Port: 22, Protocol: "sctp"}} }, `unknown protocol "sctp"`},
+		"duplicate port":  {func(c *Config) { c.Ports = append(c.Ports, Port{Port: 22, Protocol: TCP}) }, "port 22/tcp is listed twice"},
+		"same port, other protocol": {
+			func(c *Config) { c.Ports = append(c.Ports, Port{Port: 22, Protocol: UDP}) }, "",
+		},
+		"env key":    {func(c *Config) { c.Env = map[string]string{"1PATH": "x"} }, `"1PATH" is not an environment variable name`},
+		"time limit": {func(c *Config) { c.TimeLimitMinutes = 0 }, "time limit must be positive"},
+	}
+
+	for name, test := range tests {
+		config := validConfig()
+		test.change(config)
+
+		err := config.Validate()
+		if test.wantErr == "" {
+			if err != nil {
+				t.Errorf("%s: got %v", name, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+			t.Errorf("%s: got %v, want %q", name, err, test.wantErr)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := map[string]struct {
+		config  string
+		wantErr string
+	}{
+		"valid":          {`{"version":1,"image":"alpine","resources":{"cpuMillis":100,"memoryMiB":64},"timeLimitMinutes":30}`, ""},
+		"unknown field":  {`{"version":1,"image":"alpine","resources":{"cpuMillis":100,"memoryMiB":64},"timeLimitMinutes":30,"privileged":true}`, `unknown field "privileged"`},
+		"trailing data":  {`{"version":1,"image":"alpine","resources":{"cpuMillis":100,"memoryMiB":64},"timeLimitMinutes":30}{}`, "trailing data"},
+		"out of range":   {`{"version":1,"image":"alpine","resources":{"cpuMillis":100,"memoryMiB":64},"ports":[{"port":70000,"protocol":"tcp"}],"timeLimitMinutes":30}`, "invalid config"},
+		"not an object":  {`[]`, "invalid config"},
+		"fails Validate": {`{"version":1,"image":"alpine","resources":{"cpuMillis":0,"memoryMiB":64},"timeLimitMinutes":30}`, "cpu limit must be positive"},
+	}
+
+	for name, test := range tests {
+		_, err := Parse(test.config)
+		if test.wantErr == "" {
+			if err != nil {
+				t.Errorf("%s: got %v", name, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+			t.Errorf("%s: got %v, want %q", name, err, test.wantErr)
+		}
+	}
+}
+
+func TestCanonicalRoundTrip(t *testing.T) {
+	config := validConfig()
+	config.Ports = []Port{{Port: 161, Protocol: UDP}, {Port: 22, Protocol: UDP}, {Port: 22, Protocol: TCP}}
+	config.Env = map[string]string{"B": "2", "A": "1"}
+
+	canonical, err := config.Canonical()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"version":1,"image":"registry.example.com/labs/router:1.2","resources":{"cpuMillis":1000,"memoryMiB":512},` +
+		`"ports":[{"port":22,"protocol":"tcp"},{"port":22,"protocol":"udp"},{"port":161,"protocol":"udp"}],` +
+		`"env":{"A":"1","B":"2"},"timeLimitMinutes":120}`
+	if canonical != want {
+		t.Errorf("got canonical encoding\n%s\nwant\n%s", canonical, want)
+	}
+	if config.Ports[0].Port != 161 {
+		t.Error("Canonical reordered the ports of the config")
+	}
+
+	// Whitespace and field order don't change the canonical encoding, and
+	// normalizing it again keeps it as it is
+	normalized, err := Normalize(`{
+		"timeLimitMinutes": 120,
+		"env": {"B": "2", "A": "1"},
+		"ports": [{"protocol": "udp", "port": 161}, {"port": 22, "protocol": "udp"}, {"port": 22, "protocol": "tcp"}],
+		"resources": {"memoryMiB": 512, "cpuMillis": 1000},
+		"image": "registry.example.com/labs/router:1.2",
+		"version": 1
+	}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if normalized != want {
+		t.Errorf("got normalized encoding %s", normalized)
+	}
+
+	again, err := Normalize(normalized)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again != normalized {
+		t.Errorf("normalizing twice changed %s into %s", normalized, again)
+	}
+}
+
+func TestDerivedFrom(t *testing.T) {
+	tests := map[string]struct {
+		change  func(c *Config)
+		wantErr string
+	}{
+		"same":           {func(c *Config) {}, ""},
+		"lower limits":   {func(c *Config) { c.Resources = Resources{CPUMillis: 500, MemoryMiB: 256}; c.TimeLimitMinutes = 60 }, ""},
+		"fewer ports":    {func(c *Config) { c.Ports = c.Ports[:1] }, ""},
+		"added env":      {func(c *Config) { c.Env["STUDENT"] = "42" }, ""},
+		"image":          {func(c *Config) { c.Image = "alpine" }, "uses image alpine"},
+		"cpu":            {func(c *Config) { c.Resources.CPUMillis = 2000 }, "asks for 2000 millicores, lab allows 1000"},
+		"memory":         {func(c *Config) { c.Resources.MemoryMiB = 1024 }, "asks for 1024 MiB, lab allows 512"},
+		"port":           {func(c *Config) { c.Ports = append(c.Ports, Port{Port: 80, Protocol: TCP}) }, "exposes port 80/tcp"},
+		"protocol":       {func(c *Config) { c.Ports = []Port{{Port: 22, Protocol: UDP}} }, "exposes port 22/udp"},
+		"changed env":    {func(c *Config) { c.Env["LAB_MODE"] = "switching" }, "changes environment variable LAB_MODE"},
+		"removed env":    {func(c *Config) { c.Env = nil }, "changes environment variable LAB_MODE"},
+		"time limit":     {func(c *Config) { c.TimeLimitMinutes = 240 }, "asks for 240 minutes, lab allows 120"},
+		"everything off": {func(c *Config) { c.Image = "alpine"; c.TimeLimitMinutes = 240 }, "uses image alpine"},
+	}
+
+	lab := validConfig()
+	for name, test := range tests {
+		config := validConfig()
+		test.change(config)
+
+		err := config.DerivedFrom(lab)
+		if test.wantErr == "" {
+			if err != nil {
+				t.Errorf("%s: got %v", name, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+			t.Errorf("%s: got %v, want %q", name, err, test.wantErr)
+		}
+	}
+}
+
+// TestSchemaDocumentsValidate keeps the patterns and required fields of
+// schema.json in line with Validate, which is what the chaincode enforces
+func TestSchemaDocumentsValidate(t *testing.T) {
+	schemaBytes, err := ioutil.ReadFile("schema.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type property struct {
+		Pattern       string              `json:"pattern"`
+		Required      []string            `json:"required"`
+		Properties    map[string]property `json:"properties"`
+		PropertyNames *property           `json:"propertyNames"`
+		Const         *uint32             `json:"const"`
+	}
+	var schema property
+	err = json.Unmarshal(schemaBytes, &schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := schema.Properties["image"].Pattern; got != imagePattern.String() {
+		t.Errorf("schema image pattern %s, Validate uses %s", got, imagePattern)
+	}
+	if env := schema.Properties["env"].PropertyNames; env == nil || env.Pattern != envKeyPattern.String() {
+		t.Errorf("schema env names %+v, Validate uses %s", env, envKeyPattern)
+	}
+	if version := schema.Properties["version"].Const; version == nil || *version != Version {
+		t.Errorf("schema version %v, Validate uses %d", version, Version)
+	}
+	if got := strings.Join(schema.Required, ","); got != "version,image,resources,timeLimitMinutes" {
+		t.Errorf("schema requires %s", got)
+	}
+}
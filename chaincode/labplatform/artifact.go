@@ -0,0 +1,37 @@
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Artifact references the content of a submission, stored off-chain. The
+// SHA-256 digest lets anyone check a downloaded artifact against the ledger.
+type Artifact struct {
+	SHA256    string `json:"sha256"`
+	Size      uint64 `json:"size"`
+	MediaType string `json:"mediaType"`
+	URI       string `json:"uri"`
+}
+
+// validate checks that the artifact reference is complete and well formed
+func (a *Artifact) validate() error {
+	digest, err := hex.DecodeString(a.SHA256)
+	if err != nil || len(digest) != 32 || strings.ToLower(a.SHA256) != a.SHA256 {
+		return fmt.Errorf("artifact digest %q is not a lowercase hex SHA-256 digest", a.SHA256)
+	}
+	if a.Size == 0 {
+		return fmt.Errorf("artifact %s is empty", a.SHA256)
+	}
+	if !strings.Contains(a.MediaType, "/") {
+		return fmt.Errorf("artifact media type %q is not of the form type/subtype", a.MediaType)
+	}
+	uri, err := url.Parse(a.URI)
+	if err != nil || uri.Scheme == "" {
+		return fmt.Errorf("artifact URI %q is not an absolute URI", a.URI)
+	}
+
+	return nil
+}
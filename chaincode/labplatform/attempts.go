@@ -0,0 +1,238 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// Grading policies decide which attempt of a student counts for the lab
+const (
+	GradeLatest = "latest"
+	GradeBest   = "best"
+	GradeFirst  = "first"
+)
+
+// UpdateLabAttemptPolicy sets how many attempts a student may submit for a
+// lab, 0 for no limit, and which of them counts: latest, best or first.
+func (t *LabContract) UpdateLabAttemptPolicy(ctx contractapi.TransactionContextInterface, labID string, maxAttempts uint32, gradingPolicy string) error {
+	if gradingPolicy != GradeLatest && gradingPolicy != GradeBest && gradingPolicy != GradeFirst {
+		return fmt.Errorf("unknown grading policy %q, expected %s, %s or %s", gradingPolicy, GradeLatest, GradeBest, GradeFirst)
+	}
+
+	lab, err := readLab(ctx, labID)
+	if err != nil {
+		return err
+	}
+
+	clientID, err := t.GetSubmittingClientIdentity(ctx)
+	if err != nil {
+		return err
+	}
+
+	if clientID != lab.Owner {
+		return fmt.Errorf("submitting client not authorized to update lab, does not own lab")
+	}
+
+	lab.MaxAttempts = maxAttempts
+	lab.GradingPolicy = gradingPolicy
+	labBytes, err := json.Marshal(lab)
+	if err != nil {
+		return err
+	}
+
+	err = putState(ctx, docLab, labID, labBytes)
+	if err != nil {
+		return err
+	}
+
+	return emit(ctx, LabUpdatedEvent, lab)
+}
+
+// attemptIndex ties the submissions of one owner for one lab together. The
+// attempt number is zero padded so that entries are listed in order.
+const attemptIndex = "labID~owner~attempt"
+
+// attemptCountIndex counts the attempts one owner submitted for one lab. The
+// count is kept when attempts are deleted, so deleting an attempt neither
+// frees it for MaxAttempts nor reuses its number.
+const attemptCountIndex = "attemptCount~labID~owner"
+
+// SubmitAttempt hands in the next attempt of the submitting client for a lab.
+// The transaction ID becomes the ID of the submission. The Artifact is
+// passed as "artifact" in the transient map.
+func (t *SubmissionContract) SubmitAttempt(ctx contractapi.TransactionContextInterface, labID, classID string) (*Submission, error) {
+	owner, err := t.GetSubmittingClientIdentity(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var artifact Artifact
+	err = readTransient(ctx, "artifact", &artifact)
+	if err != nil {
+		return nil, err
+	}
+
+	submission, err := t.createSubmission(ctx, ctx.GetStub().GetTxID(), labID, classID, &artifact, owner)
+	if err != nil {
+		return nil, err
+	}
+
+	err = emit(ctx, SubmissionCreatedEvent, submission)
+	if err != nil {
+		return nil, err
+	}
+
+	return submission, nil
+}
+
+// ListAttempts returns the attempts of owner for a lab, first attempt first.
+// An empty owner lists the attempts of the submitting client.
+func (t *SubmissionContract) ListAttempts(ctx contractapi.TransactionContextInterface, labID, owner string) ([]*Submission, error) {
+	if owner == "" {
+		clientID, err := t.GetSubmittingClientIdentity(ctx)
+		if err != nil {
+			return nil, err
+		}
+		owner = clientID
+	}
+
+	submissions, err := t.listAttempts(ctx, labID, owner)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, submission := range submissions {
+		err = t.hideUnreleasedGrade(ctx, submission)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return submissions, nil
+}
+
+// GetLatestAttempt returns the last attempt of owner for a lab. An empty
+// owner reads the attempts of the submitting client.
+func (t *SubmissionContract) GetLatestAttempt(ctx contractapi.TransactionContextInterface, labID, owner string) (*Submission, error) {
+	submissions, err := t.ListAttempts(ctx, labID, owner)
+	if err != nil {
+		return nil, err
+	}
+	if len(submissions) == 0 {
+		return nil, fmt.Errorf("no attempts for lab %s", labID)
+	}
+
+	return submissions[len(submissions)-1], nil
+}
+
+// GetGradedAttempt returns the attempt of owner that counts for a lab
+// according to its grading policy: the latest, the first, or the one with
+// the best grade. An empty owner reads the attempts of the submitting client.
+func (t *SubmissionContract) GetGradedAttempt(ctx contractapi.TransactionContextInterface, labID, owner string) (*Submission, error) {
+	lab, err := readLab(ctx, labID)
+	if err != nil {
+		return nil, err
+	}
+
+	submissions, err := t.ListAttempts(ctx, labID, owner)
+	if err != nil {
+		return nil, err
+	}
+	if len(submissions) == 0 {
+		return nil, fmt.Errorf("no attempts for lab %s", labID)
+	}
+
+	switch lab.GradingPolicy {
+	case GradeFirst:
+		return submissions[0], nil
+	case GradeBest:
+		var best *Submission
+		var bestTotal uint32
+		for _, submission := range submissions {
+			if submission.Grade == nil {
+				continue
+			}
+			details, err := readGradeDetails(ctx, submission)
+			if err != nil {
+				return nil, err
+			}
+			if best == nil || details.Total > bestTotal {
+				best = submission
+				bestTotal = details.Total
+			}
+		}
+		if best == nil {
+			return nil, fmt.Errorf("no graded attempts for lab %s", labID)
+		}
+		return best, nil
+	default:
+		return submissions[len(submissions)-1], nil
+	}
+}
+
+// listAttempts reads the attempts of owner for a lab without checking the caller
+func (t *SubmissionContract) listAttempts(ctx contractapi.TransactionContextInterface, labID, owner string) ([]*Submission, error) {
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(attemptIndex, []string{labID, owner})
+	if err != nil {
+		return nil, err
+	}
+	defer resultsIterator.Close()
+
+	var submissions []*Submission
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		submission, err := readSubmission(ctx, string(queryResponse.Value))
+		if err != nil {
+			return nil, err
+		}
+		submissions = append(submissions, submission)
+	}
+
+	return submissions, nil
+}
+
+// attemptCount returns how many attempts owner submitted for a lab, deleted
+// attempts included
+func attemptCount(ctx contractapi.TransactionContextInterface, labID, owner string) (uint32, error) {
+	countKey, err := ctx.GetStub().CreateCompositeKey(attemptCountIndex, []string{labID, owner})
+	if err != nil {
+		return 0, err
+	}
+
+	countBytes, err := ctx.GetStub().GetState(countKey)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read attempt count of lab %s: %v", labID, err)
+	}
+	if countBytes == nil {
+		return 0, nil
+	}
+
+	count, err := strconv.ParseUint(string(countBytes), 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid attempt count of lab %s: %v", labID, err)
+	}
+
+	return uint32(count), nil
+}
+
+// putAttemptCount stores how many attempts owner submitted for a lab
+func putAttemptCount(ctx contractapi.TransactionContextInterface, labID, owner string, count uint32) error {
+	countKey, err := ctx.GetStub().CreateCompositeKey(attemptCountIndex, []string{labID, owner})
+	if err != nil {
+		return err
+	}
+
+	return ctx.GetStub().PutState(countKey, []byte(strconv.FormatUint(uint64(count), 10)))
+}
+
+// attemptKey returns the attempt index key of one attempt
+func attemptKey(ctx contractapi.TransactionContextInterface, labID, owner string, attempt uint32) (string, error) {
+	return ctx.GetStub().CreateCompositeKey(attemptIndex, []string{labID, owner, fmt.Sprintf("%06d", attempt)})
+}
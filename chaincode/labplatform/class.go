@@ -4,36 +4,38 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
-	"log"
 
 	"github.com/hyperledger/fabric-contract-api-go/contractapi"
 )
 
-// ClassContract provides functions for managing an Asset
+// ClassContract manages classes and their rosters
 type ClassContract struct {
 	contractapi.Contract
 }
 
 // Asset describes basic details of what makes up a simple asset
 type Class struct {
+	DocType string `json:"docType"` //docType is used to distinguish the various types of objects in state database
 	ID      string `json:"ID"`
 	Name    string `json:"name"`
 	Content string `json:"content"`
 	Owner   string `json:"owner"`
+	OrgMSP  string `json:"orgMSP"`
+}
+
+// PaginatedClassResult is a page of classes and the bookmark of the next
+// page, empty after the last page
+type PaginatedClassResult struct {
+	Classes             []*Class `json:"records"`
+	FetchedRecordsCount int32    `json:"fetchedRecordsCount"`
+	Bookmark            string   `json:"bookmark"`
 }
 
 // CreateAsset issues a new asset to the world state with given details.
 func (s *ClassContract) CreateClass(ctx contractapi.TransactionContextInterface, id string, name string, content string, owner string) error {
 
-	// Demonstrate the use of Attribute-Based Access Control (ABAC) by checking
-	// to see if the caller has the "abac.creator" attribute with a value of true;
-	// if not, return an error.
-	//
-	err := ctx.GetClientIdentity().AssertAttributeValue("class.creator", "true")
-	if err != nil {
-		return fmt.Errorf("submitting client not authorized to create class, does not have class.creator role")
-	}
-
+	// The caller's role is checked by permissions before the transaction runs,
+	// identities with the class.creator attribute count as instructors.
 	exists, err := s.ClassExists(ctx, id)
 	if err != nil {
 		return err
@@ -48,18 +50,32 @@ func (s *ClassContract) CreateClass(ctx contractapi.TransactionContextInterface,
 		return err
 	}
 
+	// Private data of the class, like grades, is kept in the collection of
+	// the org of its creator
+	mspID, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return fmt.Errorf("failed to read client MSP ID: %v", err)
+	}
+
 	class := Class{
+		DocType: docClass,
 		ID:      id,
 		Name:    name,
 		Content: content,
 		Owner:   clientID,
+		OrgMSP:  mspID,
 	}
 	classJSON, err := json.Marshal(class)
 	if err != nil {
 		return err
 	}
 
-	return ctx.GetStub().PutState(id, classJSON)
+	err = putState(ctx, docClass, id, classJSON)
+	if err != nil {
+		return err
+	}
+
+	return emit(ctx, ClassCreatedEvent, class)
 }
 
 // UpdateAsset updates an existing asset in the world state with provided parameters.
@@ -87,11 +103,17 @@ func (s *ClassContract) UpdateClass(ctx contractapi.TransactionContextInterface,
 		return err
 	}
 
-	return ctx.GetStub().PutState(id, classJSON)
+	err = putState(ctx, docClass, id, classJSON)
+	if err != nil {
+		return err
+	}
+
+	return emit(ctx, ClassUpdatedEvent, class)
 }
 
-// DeleteAsset deletes a given asset from the world state.
-func (s *ClassContract) DeleteClass(ctx contractapi.TransactionContextInterface, id string) error {
+// DeleteClass deletes a given class from the world state. policy decides
+// what happens to the labs of the class, see DeleteRestrict and DeleteCascade.
+func (s *ClassContract) DeleteClass(ctx contractapi.TransactionContextInterface, id string, policy string) error {
 
 	asset, err := s.ReadClass(ctx, id)
 	if err != nil {
@@ -107,7 +129,29 @@ func (s *ClassContract) DeleteClass(ctx contractapi.TransactionContextInterface,
 		return fmt.Errorf("submitting client not authorized to update class, does not own class")
 	}
 
-	return ctx.GetStub().DelState(id)
+	err = deleteClassLabs(ctx, id, policy)
+	if err != nil {
+		return err
+	}
+
+	// Drop every enrolled student so no roster entries outlive the class
+	students, err := listIndex(ctx, rosterIndex, id)
+	if err != nil {
+		return err
+	}
+	for _, student := range students {
+		err = delEnrollment(ctx, id, student)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = delState(ctx, docClass, id)
+	if err != nil {
+		return err
+	}
+
+	return emit(ctx, ClassDeletedEvent, &DeleteEvent{ID: id, Policy: policy})
 }
 
 // TransferAsset updates the owner field of asset with given id in world state.
@@ -133,13 +177,23 @@ func (s *ClassContract) TransferClass(ctx contractapi.TransactionContextInterfac
 		return err
 	}
 
-	return ctx.GetStub().PutState(id, classJSON)
+	err = putState(ctx, docClass, id, classJSON)
+	if err != nil {
+		return err
+	}
+
+	return emit(ctx, ClassTransferredEvent, class)
 }
 
 // ReadAsset returns the asset stored in the world state with given id.
 func (s *ClassContract) ReadClass(ctx contractapi.TransactionContextInterface, id string) (*Class, error) {
+	return readClass(ctx, id)
+}
 
-	classJSON, err := ctx.GetStub().GetState(id)
+// readClass reads a class, other contracts use it as well
+func readClass(ctx contractapi.TransactionContextInterface, id string) (*Class, error) {
+
+	classJSON, err := getState(ctx, docClass, id)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read from world state: %v", err)
 	}
@@ -156,38 +210,42 @@ func (s *ClassContract) ReadClass(ctx contractapi.TransactionContextInterface, i
 	return &class, nil
 }
 
-// GetAllAssets returns all assets found in world state
-func (s *ClassContract) GetAllClassses(ctx contractapi.TransactionContextInterface) ([]*Class, error) {
-	// range query with empty string for startKey and endKey does an
-	// open-ended query of all assets in the chaincode namespace.
-	resultsIterator, err := ctx.GetStub().GetStateByRange("", "")
+// GetAllAssets returns a page of all assets found in world state
+func (s *ClassContract) GetAllClassses(ctx contractapi.TransactionContextInterface, pageSize int, bookmark string) (*PaginatedClassResult, error) {
+	// list every document of the class docType, index entries and
+	// documents of other types are stored under other key prefixes.
+	values, nextBookmark, err := listStatePage(ctx, docClass, "", "", pageSize, bookmark)
 	if err != nil {
 		return nil, err
 	}
-	defer resultsIterator.Close()
-
-	var classes []*Class
-	for resultsIterator.HasNext() {
-		queryResponse, err := resultsIterator.Next()
-		if err != nil {
-			return nil, err
-		}
 
+	classes := []*Class{}
+	for _, value := range values {
 		var class Class
-		err = json.Unmarshal(queryResponse.Value, &class)
+		err = json.Unmarshal(value, &class)
 		if err != nil {
 			return nil, err
 		}
 		classes = append(classes, &class)
 	}
 
-	return classes, nil
+	return &PaginatedClassResult{
+		Classes:             classes,
+		FetchedRecordsCount: int32(len(classes)),
+		Bookmark:            nextBookmark,
+	}, nil
 }
 
 // AssetExists returns true when asset with given ID exists in world state
 func (s *ClassContract) ClassExists(ctx contractapi.TransactionContextInterface, id string) (bool, error) {
+	return classExists(ctx, id)
+}
+
+// classExists returns true when the class exists, other contracts use it as
+// well
+func classExists(ctx contractapi.TransactionContextInterface, id string) (bool, error) {
 
-	classJSON, err := ctx.GetStub().GetState(id)
+	classJSON, err := getState(ctx, docClass, id)
 	if err != nil {
 		return false, fmt.Errorf("failed to read from world state: %v", err)
 	}
@@ -229,16 +287,15 @@ func (t *ClassContract) InitLedger(ctx contractapi.TransactionContextInterface)
 		}
 	}
 
-	return nil
-}
-
-func main() {
-	chaincode, err := contractapi.NewChaincode(&ClassContract{})
-	if err != nil {
-		log.Panicf("Error creating class chaincode: %v", err)
+	// Enroll the owners of the sample instances and submissions. The classes
+	// above are not readable until this transaction commits, so the roster
+	// entries are written directly instead of through EnrollStudent.
+	for _, student := range []string{"Tom", "Sam"} {
+		err := putEnrollment(ctx, "class1", student)
+		if err != nil {
+			return err
+		}
 	}
 
-	if err := chaincode.Start(); err != nil {
-		log.Panicf("Error starting class chaincode: %v", err)
-	}
+	return nil
 }
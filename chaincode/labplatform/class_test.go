@@ -0,0 +1,170 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+
+	"auth"
+)
+
+func TestCreateClass(t *testing.T) {
+	p := newPlatform(t)
+
+	err := p.call(instructor, p.class, "CreateClass", func(ctx contractapi.TransactionContextInterface) error {
+		return p.class.CreateClass(ctx, "class1", "Networks", "Routing and switching", "")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var class Class
+	if !p.document(docClass, "class1", &class) {
+		t.Fatal("class was not stored")
+	}
+	if class.Owner != instructor.String() || class.OrgMSP != "Org1MSP" {
+		t.Errorf("got owner %q of %q, want the creator", class.Owner, class.OrgMSP)
+	}
+	if p.lastEvent() != ClassCreatedEvent {
+		t.Errorf("got event %s", p.lastEvent())
+	}
+
+	err = p.call(instructor, p.class, "CreateClass", func(ctx contractapi.TransactionContextInterface) error {
+		return p.class.CreateClass(ctx, "class1", "Networks", "", "")
+	})
+	if err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Errorf("got %v creating the class twice", err)
+	}
+}
+
+func TestStudentCannotCreateClass(t *testing.T) {
+	p := newPlatform(t)
+
+	err := p.call(student, p.class, "CreateClass", func(ctx contractapi.TransactionContextInterface) error {
+		return p.class.CreateClass(ctx, "class1", "Networks", "", "")
+	})
+	if !auth.IsAccessDenied(err) {
+		t.Errorf("got %v, want access denied", err)
+	}
+	if p.document(docClass, "class1", nil) {
+		t.Error("class was stored")
+	}
+}
+
+func TestClassOwnership(t *testing.T) {
+	p := newClassroom(t)
+
+	tests := map[string]func(ctx contractapi.TransactionContextInterface) error{
+		"UpdateClass": func(ctx contractapi.TransactionContextInterface) error {
+			return p.class.UpdateClass(ctx, "class1", "Stolen", "")
+		},
+		"TransferClass": func(ctx contractapi.TransactionContextInterface) error {
+			return p.class.TransferClass(ctx, "class1", otherInstructor.String())
+		},
+		"DeleteClass": func(ctx contractapi.TransactionContextInterface) error {
+			return p.class.DeleteClass(ctx, "class1", DeleteCascade)
+		},
+		"EnrollStudent": func(ctx contractapi.TransactionContextInterface) error {
+			return p.class.EnrollStudent(ctx, "class1", otherStudent.String())
+		},
+		"DropStudent": func(ctx contractapi.TransactionContextInterface) error {
+			return p.class.DropStudent(ctx, "class1", student.String())
+		},
+	}
+
+	for function, fn := range tests {
+		err := p.call(otherInstructor, p.class, function, fn)
+		if err == nil || !strings.Contains(err.Error(), "not authorized") {
+			t.Errorf("%s by another instructor: got %v", function, err)
+		}
+	}
+
+	var class Class
+	p.document(docClass, "class1", &class)
+	if class.Name != "Networks" || class.Owner != instructor.String() {
+		t.Errorf("class changed: %+v", class)
+	}
+}
+
+func TestDeleteClassRestrict(t *testing.T) {
+	p := newClassroom(t)
+
+	err := p.call(instructor, p.class, "DeleteClass", func(ctx contractapi.TransactionContextInterface) error {
+		return p.class.DeleteClass(ctx, "class1", DeleteRestrict)
+	})
+	if err == nil || !strings.Contains(err.Error(), "still has 1 labs") {
+		t.Errorf("got %v deleting a class with labs", err)
+	}
+	if !p.document(docClass, "class1", nil) {
+		t.Error("class was deleted")
+	}
+}
+
+func TestDeleteClassCascade(t *testing.T) {
+	p := newClassroom(t)
+	p.must(p.call(student, p.instance, "CreateInstance", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := p.instance.CreateInstance(ctx, "instance1", "lab1", "class1", sampleConfig, student.String())
+		return err
+	}))
+
+	err := p.call(instructor, p.class, "DeleteClass", func(ctx contractapi.TransactionContextInterface) error {
+		return p.class.DeleteClass(ctx, "class1", DeleteCascade)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for docType, id := range map[string]string{docClass: "class1", docLab: "lab1", docInstance: "instance1"} {
+		if p.document(docType, id, nil) {
+			t.Errorf("%s %s was not deleted", docType, id)
+		}
+	}
+	for _, index := range []string{labClassIndex, instanceLabIndex, instanceClassIndex, instanceOwnerIndex, rosterIndex, studentIndex} {
+		if keys := p.ledger.CompositeKeys(index); len(keys) != 0 {
+			t.Errorf("%s entries left: %v", index, keys)
+		}
+	}
+	if p.lastEvent() != ClassDeletedEvent {
+		t.Errorf("got event %s", p.lastEvent())
+	}
+}
+
+func TestEnrollment(t *testing.T) {
+	p := newClassroom(t)
+
+	var roster *PaginatedStudentResult
+	err := p.call(instructor, p.class, "ListRoster", func(ctx contractapi.TransactionContextInterface) (err error) {
+		roster, err = p.class.ListRoster(ctx, "class1", 0, "")
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(roster.Students, []string{student.String()}) || roster.Bookmark != "" {
+		t.Errorf("got roster %+v", roster)
+	}
+
+	err = p.call(instructor, p.class, "EnrollStudent", func(ctx contractapi.TransactionContextInterface) error {
+		return p.class.EnrollStudent(ctx, "class1", student.String())
+	})
+	if err == nil || !strings.Contains(err.Error(), "already enrolled") {
+		t.Errorf("got %v enrolling twice", err)
+	}
+
+	err = p.call(student, p.class, "ListRoster", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := p.class.ListRoster(ctx, "class1", 0, "")
+		return err
+	})
+	if !auth.IsAccessDenied(err) {
+		t.Errorf("got %v listing the roster as a student", err)
+	}
+
+	p.must(p.call(instructor, p.class, "DropStudent", func(ctx contractapi.TransactionContextInterface) error {
+		return p.class.DropStudent(ctx, "class1", student.String())
+	}))
+	if keys := p.ledger.CompositeKeys(studentIndex); len(keys) != 0 {
+		t.Errorf("student index left after drop: %v", keys)
+	}
+}
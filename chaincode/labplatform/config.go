@@ -0,0 +1,28 @@
+package main
+
+import (
+	"fmt"
+
+	"labconfig"
+)
+
+// deriveConfig validates the config of a new instance against the config of
+// its lab and returns it in canonical encoding.
+func deriveConfig(lab *Lab, config string) (string, error) {
+	parent, err := labconfig.Parse(lab.Config)
+	if err != nil {
+		return "", fmt.Errorf("lab %s has no valid config: %v", lab.ID, err)
+	}
+
+	instanceConfig, err := labconfig.Parse(config)
+	if err != nil {
+		return "", err
+	}
+
+	err = instanceConfig.DerivedFrom(parent)
+	if err != nil {
+		return "", err
+	}
+
+	return instanceConfig.Canonical()
+}
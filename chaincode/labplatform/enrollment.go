@@ -0,0 +1,241 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+
+	"auth"
+)
+
+// rosterIndex lists the students of a class, studentIndex lists the classes
+// of a student. Both point at the same enrollment and are written together.
+const rosterIndex = "classID~student"
+const studentIndex = "student~classID"
+
+// EnrollStudent adds a student to the roster of a class. The student is the
+// client identity string returned by GetSubmittingClientIdentity.
+func (s *ClassContract) EnrollStudent(ctx contractapi.TransactionContextInterface, classID string, student string) error {
+
+	class, err := s.ReadClass(ctx, classID)
+	if err != nil {
+		return err
+	}
+
+	clientID, err := s.GetSubmittingClientIdentity(ctx)
+	if err != nil {
+		return err
+	}
+
+	if clientID != class.Owner {
+		return fmt.Errorf("submitting client not authorized to enroll students, does not own class")
+	}
+
+	enrolled, err := s.IsEnrolled(ctx, classID, student)
+	if err != nil {
+		return err
+	}
+	if enrolled {
+		return fmt.Errorf("student %s is already enrolled in class %s", student, classID)
+	}
+
+	err = putEnrollment(ctx, classID, student)
+	if err != nil {
+		return err
+	}
+
+	return emit(ctx, StudentEnrolledEvent, &EnrollmentEvent{ClassID: classID, Student: student})
+}
+
+// DropStudent removes a student from the roster of a class.
+func (s *ClassContract) DropStudent(ctx contractapi.TransactionContextInterface, classID string, student string) error {
+
+	class, err := s.ReadClass(ctx, classID)
+	if err != nil {
+		return err
+	}
+
+	clientID, err := s.GetSubmittingClientIdentity(ctx)
+	if err != nil {
+		return err
+	}
+
+	if clientID != class.Owner {
+		return fmt.Errorf("submitting client not authorized to drop students, does not own class")
+	}
+
+	enrolled, err := s.IsEnrolled(ctx, classID, student)
+	if err != nil {
+		return err
+	}
+	if !enrolled {
+		return fmt.Errorf("student %s is not enrolled in class %s", student, classID)
+	}
+
+	err = delEnrollment(ctx, classID, student)
+	if err != nil {
+		return err
+	}
+
+	return emit(ctx, StudentDroppedEvent, &EnrollmentEvent{ClassID: classID, Student: student})
+}
+
+// IsEnrolled returns true when the student is on the roster of the class.
+func (s *ClassContract) IsEnrolled(ctx contractapi.TransactionContextInterface, classID string, student string) (bool, error) {
+	return isEnrolled(ctx, classID, student)
+}
+
+// isEnrolled returns true when the student is on the roster of the class.
+func isEnrolled(ctx contractapi.TransactionContextInterface, classID string, student string) (bool, error) {
+
+	rosterKey, err := ctx.GetStub().CreateCompositeKey(rosterIndex, []string{classID, student})
+	if err != nil {
+		return false, err
+	}
+
+	value, err := ctx.GetStub().GetState(rosterKey)
+	if err != nil {
+		return false, fmt.Errorf("failed to read from world state: %v", err)
+	}
+
+	return value != nil, nil
+}
+
+// PaginatedStudentResult is a page of the students of a roster and the
+// bookmark of the next page, empty after the last page
+type PaginatedStudentResult struct {
+	Students            []string `json:"records"`
+	FetchedRecordsCount int32    `json:"fetchedRecordsCount"`
+	Bookmark            string   `json:"bookmark"`
+}
+
+// ListRoster returns a page of the students enrolled in a class.
+func (s *ClassContract) ListRoster(ctx contractapi.TransactionContextInterface, classID string, pageSize int, bookmark string) (*PaginatedStudentResult, error) {
+
+	exists, err := s.ClassExists(ctx, classID)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, fmt.Errorf("the class %s does not exist", classID)
+	}
+
+	students, nextBookmark, err := listIndexPage(ctx, rosterIndex, classID, pageSize, bookmark)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PaginatedStudentResult{
+		Students:            students,
+		FetchedRecordsCount: int32(len(students)),
+		Bookmark:            nextBookmark,
+	}, nil
+}
+
+// ListClassesForStudent returns a page of the classes a student is enrolled
+// in.
+func (s *ClassContract) ListClassesForStudent(ctx contractapi.TransactionContextInterface, student string, pageSize int, bookmark string) (*PaginatedClassResult, error) {
+
+	classIDs, nextBookmark, err := listIndexPage(ctx, studentIndex, student, pageSize, bookmark)
+	if err != nil {
+		return nil, err
+	}
+
+	classes := []*Class{}
+	for _, classID := range classIDs {
+		class, err := s.ReadClass(ctx, classID)
+		if err != nil {
+			return nil, err
+		}
+		classes = append(classes, class)
+	}
+
+	return &PaginatedClassResult{
+		Classes:             classes,
+		FetchedRecordsCount: int32(len(classes)),
+		Bookmark:            nextBookmark,
+	}, nil
+}
+
+// assertEnrolled returns an error when student is not on the roster of
+// classID. The other contracts use it to refuse students of other classes.
+func assertEnrolled(ctx contractapi.TransactionContextInterface, classID string, student string) error {
+	enrolled, err := isEnrolled(ctx, classID, student)
+	if err != nil {
+		return fmt.Errorf("failed to check enrollment in class %s: %v", classID, err)
+	}
+
+	if !enrolled {
+		return fmt.Errorf("%s is not enrolled in class %s", student, classID)
+	}
+
+	return nil
+}
+
+// ownerFor returns the owner of a document clientID creates in a class for
+// owner. An empty owner is clientID itself, only staff owning the class may
+// create documents for someone else.
+func ownerFor(ctx contractapi.TransactionContextInterface, clientID, classID, owner string) (string, error) {
+	if owner == "" || owner == clientID {
+		return clientID, nil
+	}
+
+	staff, err := auth.HasRole(ctx, auth.Staff...)
+	if err != nil {
+		return "", err
+	}
+	if !staff {
+		return "", fmt.Errorf("submitting client not authorized to act for %s, only staff of the class can", owner)
+	}
+
+	class, err := readClass(ctx, classID)
+	if err != nil {
+		return "", err
+	}
+	if clientID != class.Owner {
+		return "", fmt.Errorf("submitting client not authorized to act for %s, does not own class %s", owner, classID)
+	}
+
+	return owner, nil
+}
+
+// putEnrollment writes both index entries of an enrollment.
+func putEnrollment(ctx contractapi.TransactionContextInterface, classID string, student string) error {
+	rosterKey, err := ctx.GetStub().CreateCompositeKey(rosterIndex, []string{classID, student})
+	if err != nil {
+		return err
+	}
+	studentKey, err := ctx.GetStub().CreateCompositeKey(studentIndex, []string{student, classID})
+	if err != nil {
+		return err
+	}
+
+	//  Only the key name is needed, passing a 'nil' value would delete the key,
+	//  therefore we pass null character as value
+	value := []byte{0x00}
+	err = ctx.GetStub().PutState(rosterKey, value)
+	if err != nil {
+		return err
+	}
+
+	return ctx.GetStub().PutState(studentKey, value)
+}
+
+// delEnrollment removes both index entries of an enrollment.
+func delEnrollment(ctx contractapi.TransactionContextInterface, classID string, student string) error {
+	rosterKey, err := ctx.GetStub().CreateCompositeKey(rosterIndex, []string{classID, student})
+	if err != nil {
+		return err
+	}
+	studentKey, err := ctx.GetStub().CreateCompositeKey(studentIndex, []string{student, classID})
+	if err != nil {
+		return err
+	}
+
+	err = ctx.GetStub().DelState(rosterKey)
+	if err != nil {
+		return err
+	}
+
+	return ctx.GetStub().DelState(studentKey)
+}
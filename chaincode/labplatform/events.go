@@ -0,0 +1,99 @@
+package main
+
+import (
+	"encoding/json"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// Events emitted by the mutating transactions. Fabric keeps a single event
+// per transaction, so every transaction emits its event last and helpers
+// shared by several transactions never emit one. The payload of each event
+// is noted next to it.
+const (
+	ClassCreatedEvent     = "ClassCreated"     // Class
+	ClassUpdatedEvent     = "ClassUpdated"     // Class
+	ClassTransferredEvent = "ClassTransferred" // Class
+	ClassDeletedEvent     = "ClassDeleted"     // DeleteEvent
+	StudentEnrolledEvent  = "StudentEnrolled"  // EnrollmentEvent
+	StudentDroppedEvent   = "StudentDropped"   // EnrollmentEvent
+
+	LabCreatedEvent = "LabCreated" // Lab
+	LabUpdatedEvent = "LabUpdated" // Lab
+	LabDeletedEvent = "LabDeleted" // DeleteEvent
+
+	InstanceCreatedEvent = "InstanceCreated" // Instance
+	InstanceUpdatedEvent = "InstanceUpdated" // InstanceUsageEvent
+	InstanceDeletedEvent = "InstanceDeleted" // DeleteEvent
+	SessionStartedEvent  = "SessionStarted"  // Session
+	SessionStoppedEvent  = "SessionStopped"  // SessionUsageEvent
+	QuotaExceededEvent   = "QuotaExceeded"   // QuotaDenial
+
+	SubmissionCreatedEvent = "SubmissionCreated" // Submission
+	SubmissionGradedEvent  = "SubmissionGraded"  // GradeEvent
+	GradeReleasedEvent     = "GradeReleased"     // GradeEvent
+	SubmissionDeletedEvent = "SubmissionDeleted" // DeleteEvent
+)
+
+// DeleteEvent is the payload of the events of deleted documents. Policy is
+// the delete policy of deleted classes and labs, their dependents don't get
+// events of their own.
+type DeleteEvent struct {
+	ID     string `json:"ID"`
+	Policy string `json:"policy,omitempty"`
+}
+
+// InstanceUsageEvent is the payload of the InstanceUpdated event, the updated
+// instance and the QuotaError when the usage of the owner ended up over the
+// usage quota of the lab.
+type InstanceUsageEvent struct {
+	*Instance
+	QuotaExceeded *QuotaError `json:"quotaExceeded,omitempty"`
+}
+
+// SessionUsageEvent is the payload of the SessionStopped event, the stopped
+// session and the QuotaError when it took the owner over the usage quota of
+// the lab.
+type SessionUsageEvent struct {
+	*Session
+	QuotaExceeded *QuotaError `json:"quotaExceeded,omitempty"`
+}
+
+// EnrollmentEvent is the payload of the StudentEnrolled and StudentDropped
+// events.
+type EnrollmentEvent struct {
+	ClassID string `json:"classID"`
+	Student string `json:"student"`
+}
+
+// GradeEvent is the payload of the SubmissionGraded and GradeReleased
+// events. It leaves out the scores, students read them with GetGrade once
+// the grade is released.
+type GradeEvent struct {
+	SubmissionID string `json:"submissionID"`
+	ClassID      string `json:"classID"`
+	LabID        string `json:"labID"`
+	Owner        string `json:"owner"`
+	State        string `json:"state"`
+}
+
+// newGradeEvent returns the GradeEvent of a graded submission
+func newGradeEvent(submission *Submission) *GradeEvent {
+	return &GradeEvent{
+		SubmissionID: submission.ID,
+		ClassID:      submission.ClassID,
+		LabID:        submission.LabID,
+		Owner:        submission.Owner,
+		State:        submission.Grade.State,
+	}
+}
+
+// emit sets the event of the transaction with payload encoded as JSON
+func emit(ctx contractapi.TransactionContextInterface, name string, payload interface{}) error {
+	payloadBytes, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	return ctx.GetStub().SetEvent(name, payloadBytes)
+}
@@ -1,10 +1,14 @@
-module submission
+module labplatform
 
 go 1.17
 
 require (
+	auth v0.0.0-00010101000000-000000000000
 	github.com/hyperledger/fabric-chaincode-go v0.0.0-20210718160520-38d29fabecb9
 	github.com/hyperledger/fabric-contract-api-go v1.1.1
+	github.com/hyperledger/fabric-protos-go v0.0.0-20200424173316-dd554ba3746e
+	labconfig v0.0.0-00010101000000-000000000000
+	ledgertest v0.0.0-00010101000000-000000000000
 )
 
 require (
@@ -18,7 +22,6 @@ require (
 	github.com/gobuffalo/packd v0.3.0 // indirect
 	github.com/gobuffalo/packr v1.30.1 // indirect
 	github.com/golang/protobuf v1.3.2 // indirect
-	github.com/hyperledger/fabric-protos-go v0.0.0-20200424173316-dd554ba3746e // indirect
 	github.com/joho/godotenv v1.3.0 // indirect
 	github.com/mailru/easyjson v0.0.0-20190626092158-b2ccc519800e // indirect
 	github.com/rogpeppe/go-internal v1.3.0 // indirect
@@ -32,3 +35,9 @@ require (
 	google.golang.org/grpc v1.23.0 // indirect
 	gopkg.in/yaml.v2 v2.2.8 // indirect
 )
+
+replace auth => ../auth
+
+replace labconfig => ../labconfig
+
+replace ledgertest => ../ledgertest
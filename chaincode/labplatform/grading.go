@@ -0,0 +1,305 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+
+	"auth"
+)
+
+// Grade states. A draft grade is only visible to staff until it is released.
+// Grading a released submission again puts it back into draft, until the new
+// grade is released as regraded.
+const (
+	GradeDraft    = "draft"
+	GradeReleased = "released"
+	GradeRegraded = "regraded"
+)
+
+// CriterionScore is the points a submission earned for one rubric criterion
+type CriterionScore struct {
+	Criterion string `json:"criterion"`
+	Points    uint32 `json:"points"`
+}
+
+// Grade records who graded a submission and when. The points and feedback
+// are private data of the class org, see GradeDetails. Regrade marks a draft
+// replacing a grade that was released before.
+type Grade struct {
+	Grader   string `json:"grader"`
+	GradedAt string `json:"gradedAt"`
+	State    string `json:"state"`
+	Regrade  bool   `json:"regrade,omitempty" metadata:",optional"`
+}
+
+// GradeDetails is the private part of a grade. Graders pass it as "grade"
+// in the transient map, with a random Salt of at least minSaltLength
+// characters. The salt keeps the hash on the public ledger from giving the
+// grade away; students get it back with GetGrade and disclose it along with
+// the grade for VerifyGrade.
+type GradeDetails struct {
+	Salt     string           `json:"salt"`
+	Scores   []CriterionScore `json:"scores,omitempty" metadata:",optional"`
+	Total    uint32           `json:"total"`
+	Feedback string           `json:"feedback"`
+}
+
+// released returns true when the owner of the submission may see the grade
+func (g *Grade) released() bool {
+	return g != nil && (g.State == GradeReleased || g.State == GradeRegraded)
+}
+
+// GradeSubmission scores a submission against the rubric of its lab. Every
+// criterion of the rubric has to be scored exactly once, the total is
+// computed from the scores.
+func (t *SubmissionContract) GradeSubmission(ctx contractapi.TransactionContextInterface, submissionID string) error {
+	var details GradeDetails
+	err := readTransient(ctx, "grade", &details)
+	if err != nil {
+		return err
+	}
+
+	submission, err := readSubmission(ctx, submissionID)
+	if err != nil {
+		return err
+	}
+
+	lab, err := readLab(ctx, submission.LabID)
+	if err != nil {
+		return err
+	}
+	if len(lab.Rubric) == 0 {
+		return fmt.Errorf("lab %s has no rubric to grade by", lab.ID)
+	}
+
+	details.Total, err = scoreRubric(lab.Rubric, details.Scores)
+	if err != nil {
+		return err
+	}
+
+	err = t.putGrade(ctx, submission, &details)
+	if err != nil {
+		return err
+	}
+
+	return emit(ctx, SubmissionGradedEvent, newGradeEvent(submission))
+}
+
+// ReleaseGrade makes the draft grade of a submission visible to its owner
+func (t *SubmissionContract) ReleaseGrade(ctx contractapi.TransactionContextInterface, submissionID string) error {
+	submission, err := readSubmission(ctx, submissionID)
+	if err != nil {
+		return err
+	}
+
+	_, err = t.assertGrader(ctx, submission, "release grade")
+	if err != nil {
+		return err
+	}
+
+	if submission.Grade == nil {
+		return fmt.Errorf("submission %s has not been graded", submissionID)
+	}
+	if submission.Grade.State != GradeDraft {
+		return fmt.Errorf("grade of submission %s is already %s", submissionID, submission.Grade.State)
+	}
+
+	submission.Grade.State = GradeReleased
+	if submission.Grade.Regrade {
+		submission.Grade.State = GradeRegraded
+	}
+	submissionBytes, err := json.Marshal(submission)
+	if err != nil {
+		return err
+	}
+
+	err = putState(ctx, docSubmission, submissionID, submissionBytes)
+	if err != nil {
+		return err
+	}
+
+	return emit(ctx, GradeReleasedEvent, newGradeEvent(submission))
+}
+
+// GetGrade returns the private grade of a submission. Students only see the
+// grade of their own submissions, and only once it has been released. It is
+// only readable on peers of the org of the class.
+func (t *SubmissionContract) GetGrade(ctx contractapi.TransactionContextInterface, submissionID string) (*GradeDetails, error) {
+	submission, err := t.ReadSubmission(ctx, submissionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if submission.Grade == nil {
+		return nil, fmt.Errorf("submission %s has no grade", submissionID)
+	}
+
+	return readGradeDetails(ctx, submission)
+}
+
+// VerifyGrade checks a disclosed grade, passed as "grade" in the transient
+// map, against the hash of the private grade of a submission. Any org can
+// verify a grade, the hash is on every peer.
+func (t *SubmissionContract) VerifyGrade(ctx contractapi.TransactionContextInterface, submissionID string) (bool, error) {
+	var details GradeDetails
+	err := readTransient(ctx, "grade", &details)
+	if err != nil {
+		return false, err
+	}
+
+	submission, err := readSubmission(ctx, submissionID)
+	if err != nil {
+		return false, err
+	}
+	if submission.Grade == nil {
+		return false, fmt.Errorf("submission %s has no grade", submissionID)
+	}
+
+	detailsBytes, err := json.Marshal(&details)
+	if err != nil {
+		return false, err
+	}
+
+	return matchesPrivate(ctx, submission.Collection, docGrade, submissionID, detailsBytes)
+}
+
+// putGrade stores the private details of a grade and records the grader,
+// time and state on the submission.
+func (t *SubmissionContract) putGrade(ctx contractapi.TransactionContextInterface, submission *Submission, details *GradeDetails) error {
+	if len(details.Salt) < minSaltLength {
+		return fmt.Errorf("grade salt must be at least %d characters", minSaltLength)
+	}
+
+	grader, err := t.assertGrader(ctx, submission, "grade submission")
+	if err != nil {
+		return err
+	}
+
+	gradedAt, err := txTime(ctx)
+	if err != nil {
+		return err
+	}
+
+	grade := &Grade{
+		Grader:   grader,
+		GradedAt: gradedAt.Format(time.RFC3339),
+		State:    GradeDraft,
+		Regrade:  submission.Grade.released() || submission.Grade != nil && submission.Grade.Regrade,
+	}
+
+	detailsBytes, err := json.Marshal(details)
+	if err != nil {
+		return err
+	}
+	err = putPrivate(ctx, submission.Collection, docGrade, submission.ID, detailsBytes)
+	if err != nil {
+		return err
+	}
+
+	submission.Grade = grade
+	submissionBytes, err := json.Marshal(submission)
+	if err != nil {
+		return err
+	}
+
+	return putState(ctx, docSubmission, submission.ID, submissionBytes)
+}
+
+// assertGrader checks that the submitting client owns the class of a
+// submission, so that only the instructor of a class grades it, and returns
+// the client identity. action names what the client tried.
+func (t *SubmissionContract) assertGrader(ctx contractapi.TransactionContextInterface, submission *Submission, action string) (string, error) {
+	clientID, err := t.GetSubmittingClientIdentity(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	err = assertClassOwner(ctx, clientID, submission.ClassID, action)
+	if err != nil {
+		return "", err
+	}
+
+	return clientID, nil
+}
+
+// readGradeDetails reads the private grade of a submission without checking
+// the caller
+func readGradeDetails(ctx contractapi.TransactionContextInterface, submission *Submission) (*GradeDetails, error) {
+	detailsBytes, err := getPrivate(ctx, submission.Collection, docGrade, submission.ID)
+	if err != nil {
+		return nil, err
+	}
+	if detailsBytes == nil {
+		return nil, fmt.Errorf("submission %s has no private grade in collection %s", submission.ID, submission.Collection)
+	}
+
+	var details GradeDetails
+	err = json.Unmarshal(detailsBytes, &details)
+	if err != nil {
+		return nil, err
+	}
+
+	return &details, nil
+}
+
+// hideUnreleasedGrade checks that students only read their own submission
+// and strips the grade from it until the grade is released.
+func (t *SubmissionContract) hideUnreleasedGrade(ctx contractapi.TransactionContextInterface, submission *Submission) error {
+	staff, err := auth.HasRole(ctx, auth.Staff...)
+	if err != nil {
+		return err
+	}
+	if staff {
+		return nil
+	}
+
+	clientID, err := t.GetSubmittingClientIdentity(ctx)
+	if err != nil {
+		return err
+	}
+	if clientID != submission.Owner {
+		return fmt.Errorf("submitting client not authorized to read submission, does not own submission")
+	}
+
+	if !submission.Grade.released() {
+		submission.Grade = nil
+	}
+
+	return nil
+}
+
+// scoreRubric checks scores against the rubric and returns the total
+func scoreRubric(rubric []Criterion, scores []CriterionScore) (uint32, error) {
+	maxPoints := make(map[string]uint32)
+	for _, criterion := range rubric {
+		maxPoints[criterion.Name] = criterion.MaxPoints
+	}
+
+	var total uint32
+	scored := make(map[string]bool)
+	for _, score := range scores {
+		max, ok := maxPoints[score.Criterion]
+		if !ok {
+			return 0, fmt.Errorf("criterion %s is not part of the rubric", score.Criterion)
+		}
+		if scored[score.Criterion] {
+			return 0, fmt.Errorf("criterion %s is scored twice", score.Criterion)
+		}
+		if score.Points > max {
+			return 0, fmt.Errorf("criterion %s is worth at most %d points, got %d", score.Criterion, max, score.Points)
+		}
+		scored[score.Criterion] = true
+		total += score.Points
+	}
+
+	for _, criterion := range rubric {
+		if !scored[criterion.Name] {
+			return 0, fmt.Errorf("criterion %s is not scored", criterion.Name)
+		}
+	}
+
+	return total, nil
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/hyperledger/fabric-protos-go/ledger/queryresult"
+)
+
+// ClassHistory is a version of a class. Timestamp is the RFC 3339 timestamp
+// of the transaction that wrote the version, Class is left out for deletes.
+// The other history entries are alike.
+type ClassHistory struct {
+	TxID      string `json:"txID"`
+	Timestamp string `json:"timestamp"`
+	IsDelete  bool   `json:"isDelete"`
+	Class     *Class `json:"class,omitempty" metadata:",optional"`
+}
+
+// LabHistory is a version of a lab
+type LabHistory struct {
+	TxID      string `json:"txID"`
+	Timestamp string `json:"timestamp"`
+	IsDelete  bool   `json:"isDelete"`
+	Lab       *Lab   `json:"lab,omitempty" metadata:",optional"`
+}
+
+// InstanceHistory is a version of an instance
+type InstanceHistory struct {
+	TxID      string    `json:"txID"`
+	Timestamp string    `json:"timestamp"`
+	IsDelete  bool      `json:"isDelete"`
+	Instance  *Instance `json:"instance,omitempty" metadata:",optional"`
+}
+
+// SubmissionHistory is a version of a submission
+type SubmissionHistory struct {
+	TxID       string      `json:"txID"`
+	Timestamp  string      `json:"timestamp"`
+	IsDelete   bool        `json:"isDelete"`
+	Submission *Submission `json:"submission,omitempty" metadata:",optional"`
+}
+
+// GetClassHistory returns every version of a class
+func (s *ClassContract) GetClassHistory(ctx contractapi.TransactionContextInterface, id string) ([]*ClassHistory, error) {
+	var history []*ClassHistory
+	err := forEachVersion(ctx, docClass, id, func(txID, timestamp string, value []byte) error {
+		entry := &ClassHistory{TxID: txID, Timestamp: timestamp, IsDelete: value == nil}
+		if value != nil {
+			entry.Class = new(Class)
+			err := json.Unmarshal(value, entry.Class)
+			if err != nil {
+				return err
+			}
+		}
+		history = append(history, entry)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return history, nil
+}
+
+// GetLabHistory returns every version of a lab
+func (t *LabContract) GetLabHistory(ctx contractapi.TransactionContextInterface, labID string) ([]*LabHistory, error) {
+	var history []*LabHistory
+	err := forEachVersion(ctx, docLab, labID, func(txID, timestamp string, value []byte) error {
+		entry := &LabHistory{TxID: txID, Timestamp: timestamp, IsDelete: value == nil}
+		if value != nil {
+			entry.Lab = new(Lab)
+			err := json.Unmarshal(value, entry.Lab)
+			if err != nil {
+				return err
+			}
+		}
+		history = append(history, entry)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return history, nil
+}
+
+// GetInstanceHistory returns every version of an instance
+func (t *InstanceContract) GetInstanceHistory(ctx contractapi.TransactionContextInterface, instanceID string) ([]*InstanceHistory, error) {
+	var history []*InstanceHistory
+	err := forEachVersion(ctx, docInstance, instanceID, func(txID, timestamp string, value []byte) error {
+		entry := &InstanceHistory{TxID: txID, Timestamp: timestamp, IsDelete: value == nil}
+		if value != nil {
+			entry.Instance = new(Instance)
+			err := json.Unmarshal(value, entry.Instance)
+			if err != nil {
+				return err
+			}
+		}
+		history = append(history, entry)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return history, nil
+}
+
+// GetSubmissionHistory returns every version of a submission, including who
+// graded it when. It is meant for settling grade disputes and only open to
+// staff, see submissionPermissions. The points are private, disclosed
+// grades are checked with VerifyGrade.
+func (t *SubmissionContract) GetSubmissionHistory(ctx contractapi.TransactionContextInterface, submissionID string) ([]*SubmissionHistory, error) {
+	var history []*SubmissionHistory
+	err := forEachVersion(ctx, docSubmission, submissionID, func(txID, timestamp string, value []byte) error {
+		entry := &SubmissionHistory{TxID: txID, Timestamp: timestamp, IsDelete: value == nil}
+		if value != nil {
+			entry.Submission = new(Submission)
+			err := json.Unmarshal(value, entry.Submission)
+			if err != nil {
+				return err
+			}
+		}
+		history = append(history, entry)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return history, nil
+}
+
+// forEachVersion calls fn with every version of a document, oldest first.
+// value is nil for deletes.
+func forEachVersion(ctx contractapi.TransactionContextInterface, docType, id string, fn func(txID, timestamp string, value []byte) error) error {
+	key, err := docKey(ctx, docType, id)
+	if err != nil {
+		return err
+	}
+
+	resultsIterator, err := ctx.GetStub().GetHistoryForKey(key)
+	if err != nil {
+		return err
+	}
+	defer resultsIterator.Close()
+
+	var modifications []*queryresult.KeyModification
+	for resultsIterator.HasNext() {
+		modification, err := resultsIterator.Next()
+		if err != nil {
+			return err
+		}
+		modifications = append(modifications, modification)
+	}
+
+	// The peer returns the newest version first
+	for i := len(modifications) - 1; i >= 0; i-- {
+		modification := modifications[i]
+
+		var timestamp string
+		if modification.Timestamp != nil {
+			timestamp = time.Unix(modification.Timestamp.Seconds, int64(modification.Timestamp.Nanos)).UTC().Format(time.RFC3339)
+		}
+		var value []byte
+		if !modification.IsDelete {
+			value = modification.Value
+		}
+
+		err = fn(modification.TxId, timestamp, value)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+
+	"ledgertest"
+)
+
+// TestIndexesCoverContractQueries runs every rich query of the contracts on a
+// ledger requiring the indexes of the chaincode, and fails on indexes none
+// of them uses
+func TestIndexesCoverContractQueries(t *testing.T) {
+	p := newClassroom(t)
+
+	queries := []struct {
+		identity *ledgertest.Identity
+		contract contractapi.ContractInterface
+		function string
+		fn       func(ctx contractapi.TransactionContextInterface) error
+	}{
+		{instructor, p.lab, "QueryLabsByClass", func(ctx contractapi.TransactionContextInterface) error {
+			_, err := p.lab.QueryLabsByClass(ctx, "class1", 0, "")
+			return err
+		}},
+		{instructor, p.lab, "QueryLabs", func(ctx contractapi.TransactionContextInterface) error {
+			_, err := p.lab.QueryLabs(ctx, Query{}, 0, "")
+			return err
+		}},
+		{instructor, p.instance, "QueryInstanceByClass", func(ctx contractapi.TransactionContextInterface) error {
+			_, err := p.instance.QueryInstanceByClass(ctx, "class1", 0, "")
+			return err
+		}},
+		{instructor, p.instance, "QueryInstanceByLab", func(ctx contractapi.TransactionContextInterface) error {
+			_, err := p.instance.QueryInstanceByLab(ctx, "lab1", 0, "")
+			return err
+		}},
+		{instructor, p.instance, "QueryInstanceByOwner", func(ctx contractapi.TransactionContextInterface) error {
+			_, err := p.instance.QueryInstanceByOwner(ctx, student.String(), 0, "")
+			return err
+		}},
+		{instructor, p.submission, "QuerySubmissionByClass", func(ctx contractapi.TransactionContextInterface) error {
+			_, err := p.submission.QuerySubmissionByClass(ctx, "class1", 0, "")
+			return err
+		}},
+		{instructor, p.submission, "QuerySubmissionByLab", func(ctx contractapi.TransactionContextInterface) error {
+			_, err := p.submission.QuerySubmissionByLab(ctx, "lab1", 0, "")
+			return err
+		}},
+		{instructor, p.submission, "QuerySubmissionByOwner", func(ctx contractapi.TransactionContextInterface) error {
+			_, err := p.submission.QuerySubmissionByOwner(ctx, student.String(), 0, "")
+			return err
+		}},
+	}
+
+	for _, query := range queries {
+		err := p.call(query.identity, query.contract, query.function, query.fn)
+		if err != nil {
+			t.Errorf("%s:%s: %v", query.contract.GetName(), query.function, err)
+		}
+	}
+
+	for _, query := range labQueries() {
+		err := p.call(instructor, p.lab, "QueryLabs", func(ctx contractapi.TransactionContextInterface) error {
+			_, err := p.lab.QueryLabs(ctx, query, 0, "")
+			return err
+		})
+		if err != nil {
+			t.Errorf("lab:QueryLabs %+v: %v", query, err)
+		}
+	}
+
+	// Indexes cost every write to the state database, keep only the ones
+	// some query needs
+	if unused := p.ledger.UnusedIndexes(); len(unused) > 0 {
+		t.Errorf("no query uses the indexes %v", unused)
+	}
+}
+
+// labQueries returns QueryLabs queries on every field a client can filter
+// or sort labs by
+func labQueries() []Query {
+	var queries []Query
+	for field := range labQueryFields {
+		value := "x"
+		if labQueryFields[field] == numberField {
+			value = "1"
+		}
+		queries = append(queries,
+			Query{Filters: []Filter{{Field: field, Operator: OpEq, Value: value}}},
+			Query{Filters: []Filter{{Field: field, Operator: OpGt, Value: value}}},
+			Query{Sort: []SortField{{Field: field}}},
+			Query{Sort: []SortField{{Field: field, Descending: true}}},
+			Query{
+				Filters: []Filter{{Field: "classID", Operator: OpEq, Value: "class1"}},
+				Sort:    []SortField{{Field: field, Descending: true}},
+			},
+		)
+	}
+
+	return queries
+}
+
+func TestIndexesCoverLabQueries(t *testing.T) {
+	indexes, err := ledgertest.LoadIndexes(indexDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, query := range labQueries() {
+		s, err := query.selector(docLab, labQueryFields)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		index, err := ledgertest.CoveringIndex(indexes, s.String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if index == nil {
+			t.Errorf("no index covers %s", s)
+		}
+	}
+}
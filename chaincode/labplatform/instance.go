@@ -0,0 +1,423 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// InstanceContract manages the lab environments students run, their
+// sessions and the usage counted against the quota of their lab
+type InstanceContract struct {
+	contractapi.Contract
+}
+
+// Instance is a lab environment of a student. Config is derived from the
+// config of the lab, see labconfig. UsedTime is the number of seconds the
+// instance ran in closed sessions, ActiveSession is the number of the open
+// session or 0.
+type Instance struct {
+	DocType       string `json:"docType"` //docType is used to distinguish the various types of objects in state database
+	ID            string `json:"ID"`
+	ClassID       string `json:"classID"`
+	LabID         string `json:"labID"`
+	Config        string `json:"config"`
+	Owner         string `json:"owner"`
+	UsedTime      uint64 `json:"usedtime"`
+	ActiveSession uint32 `json:"activeSession"`
+}
+
+// Indexes of instances by lab, class and owner
+const (
+	instanceLabIndex   = "labID~instanceID"
+	instanceClassIndex = "classID~instanceID"
+	instanceOwnerIndex = "owner~instanceID"
+)
+
+// PaginatedInstanceResult is a page of instances and the bookmark of the next
+// page, empty after the last page
+type PaginatedInstanceResult struct {
+	Instances           []*Instance `json:"records"`
+	FetchedRecordsCount int32       `json:"fetchedRecordsCount"`
+	Bookmark            string      `json:"bookmark"`
+}
+
+// CreateInstance creates an instance of a lab for the submitting client. An
+// empty owner is the submitting client, staff owning the class may create
+// instances for the students of the class instead. When the quota of the lab
+// refuses the instance, it returns the committed QuotaDenial instead.
+func (t *InstanceContract) CreateInstance(ctx contractapi.TransactionContextInterface, instanceID, labID, classID, config, owner string) (*QuotaDenial, error) {
+	clientID, err := t.GetSubmittingClientIdentity(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	owner, err = ownerFor(ctx, clientID, classID, owner)
+	if err != nil {
+		return nil, err
+	}
+
+	return t.createInstance(ctx, instanceID, labID, classID, config, owner)
+}
+
+// createInstance creates an instance for owner without checking the caller
+func (t *InstanceContract) createInstance(ctx contractapi.TransactionContextInterface, instanceID, labID, classID, config, owner string) (*QuotaDenial, error) {
+	exists, err := t.InstanceExists(ctx, instanceID)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to get instance: %v", err)
+	}
+	if exists {
+		return nil, fmt.Errorf("instance already exists: %s", labID)
+	}
+
+	err = assertEnrolled(ctx, classID, owner)
+	if err != nil {
+		return nil, err
+	}
+
+	lab, err := readLab(ctx, labID)
+	if err != nil {
+		return nil, err
+	}
+	if lab.ClassID != classID {
+		return nil, fmt.Errorf("lab %s does not belong to class %s", labID, classID)
+	}
+
+	config, err = deriveConfig(lab, config)
+	if err != nil {
+		return nil, err
+	}
+
+	exceeded, err := checkCreateQuota(ctx, lab, owner, config)
+	if err != nil {
+		return nil, err
+	}
+	if exceeded != nil {
+		return denyQuota(ctx, "CreateInstance", exceeded)
+	}
+
+	instance := &Instance{
+		DocType:  docInstance,
+		ID:       instanceID,
+		ClassID:  classID,
+		LabID:    labID,
+		Config:   config,
+		Owner:    owner,
+		UsedTime: 0,
+	}
+	instanceBytes, err := json.Marshal(instance)
+	if err != nil {
+		return nil, err
+	}
+
+	err = putState(ctx, docInstance, instanceID, instanceBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	// Index the instance by lab, class and owner. Only the key is needed, the
+	// value is a null character since a nil value would delete the key.
+	labIndexKey, err := ctx.GetStub().CreateCompositeKey(instanceLabIndex, []string{instance.LabID, instance.ID})
+	if err != nil {
+		return nil, err
+	}
+	value := []byte{0x00}
+	err = ctx.GetStub().PutState(labIndexKey, value)
+	if err != nil {
+		return nil, err
+	}
+
+	classIndexKey, err := ctx.GetStub().CreateCompositeKey(instanceClassIndex, []string{instance.ClassID, instance.ID})
+	if err != nil {
+		return nil, err
+	}
+	value = []byte{0x00}
+	err = ctx.GetStub().PutState(classIndexKey, value)
+	if err != nil {
+		return nil, err
+	}
+
+	ownerIndexKey, err := ctx.GetStub().CreateCompositeKey(instanceOwnerIndex, []string{instance.Owner, instance.ID})
+	if err != nil {
+		return nil, err
+	}
+	value = []byte{0x00}
+	err = ctx.GetStub().PutState(ownerIndexKey, value)
+	if err != nil {
+		return nil, err
+	}
+
+	return nil, emit(ctx, InstanceCreatedEvent, instance)
+}
+
+// AssetExists returns true when asset with given ID exists in the ledger.
+func (t *InstanceContract) InstanceExists(ctx contractapi.TransactionContextInterface, instanceID string) (bool, error) {
+	instanceBytes, err := getState(ctx, docInstance, instanceID)
+	if err != nil {
+		return false, fmt.Errorf("failed to read lab %s from world state. %v", instanceID, err)
+	}
+
+	return instanceBytes != nil, nil
+}
+
+// ReadAsset retrieves an asset from the ledger
+func (t *InstanceContract) ReadInstance(ctx contractapi.TransactionContextInterface, instanceID string) (*Instance, error) {
+	return readInstance(ctx, instanceID)
+}
+
+// readInstance retrieves an instance from the ledger
+func readInstance(ctx contractapi.TransactionContextInterface, instanceID string) (*Instance, error) {
+	instanceBytes, err := getState(ctx, docInstance, instanceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get asset %s: %v", instanceID, err)
+	}
+	if instanceBytes == nil {
+		return nil, fmt.Errorf("instance %s does not exist", instanceID)
+	}
+
+	var instance Instance
+	err = json.Unmarshal(instanceBytes, &instance)
+	if err != nil {
+		return nil, err
+	}
+
+	return &instance, nil
+}
+
+// DeleteInstance removes an instance of the submitting client from the ledger
+func (t *InstanceContract) DeleteInstance(ctx contractapi.TransactionContextInterface, instanceID string) error {
+	clientID, err := t.GetSubmittingClientIdentity(ctx)
+	if err != nil {
+		return err
+	}
+
+	instance, err := t.ReadInstance(ctx, instanceID)
+	if err != nil {
+		return err
+	}
+
+	if clientID != instance.Owner {
+		return fmt.Errorf("submitting client not authorized to delete instance, does not own instance")
+	}
+
+	if instance.ActiveSession != 0 {
+		return fmt.Errorf("instance %s has an open session, stop it first", instanceID)
+	}
+
+	err = deleteInstance(ctx, instance)
+	if err != nil {
+		return err
+	}
+
+	return emit(ctx, InstanceDeletedEvent, &DeleteEvent{ID: instanceID})
+}
+
+// deleteInstance removes an instance and its index entries from the ledger
+func deleteInstance(ctx contractapi.TransactionContextInterface, instance *Instance) error {
+	err := delState(ctx, docInstance, instance.ID)
+	if err != nil {
+		return fmt.Errorf("failed to delete asset %s: %v", instance.ID, err)
+	}
+
+	labIndexKey, err := ctx.GetStub().CreateCompositeKey(instanceLabIndex, []string{instance.LabID, instance.ID})
+	if err != nil {
+		return err
+	}
+
+	err = ctx.GetStub().DelState(labIndexKey)
+
+	if err != nil {
+		return err
+	}
+
+	classIndexKey, err := ctx.GetStub().CreateCompositeKey(instanceClassIndex, []string{instance.ClassID, instance.ID})
+	if err != nil {
+		return err
+	}
+
+	// Delete index entry
+	err = ctx.GetStub().DelState(classIndexKey)
+	if err != nil {
+		return err
+	}
+
+	ownerIndexKey, err := ctx.GetStub().CreateCompositeKey(instanceOwnerIndex, []string{instance.Owner, instance.ID})
+	if err != nil {
+		return err
+	}
+
+	// Delete index entry
+	err = ctx.GetStub().DelState(ownerIndexKey)
+	if err != nil {
+		return err
+	}
+
+	return deleteSessions(ctx, instance.ID)
+}
+
+// UpdateInstanceUsedTime corrects the used time of an instance. Used time
+// normally only grows by closing sessions with StopSession. The difference is
+// applied to the usage quota of the owner as well.
+func (t *InstanceContract) UpdateInstanceUsedTime(ctx contractapi.TransactionContextInterface, instanceID string, newUsedTime uint64) error {
+	instance, err := t.ReadInstance(ctx, instanceID)
+	if err != nil {
+		return err
+	}
+
+	lab, err := readLab(ctx, instance.LabID)
+	if err != nil {
+		return err
+	}
+
+	exceeded, err := addUsage(ctx, lab, instance.Owner, instanceID, int64(newUsedTime)-int64(instance.UsedTime))
+	if err != nil {
+		return err
+	}
+
+	instance.UsedTime = newUsedTime
+	instanceBytes, err := json.Marshal(instance)
+	if err != nil {
+		return err
+	}
+
+	err = putState(ctx, docInstance, instanceID, instanceBytes)
+	if err != nil {
+		return err
+	}
+
+	return emit(ctx, InstanceUpdatedEvent, &InstanceUsageEvent{Instance: instance, QuotaExceeded: exceeded})
+}
+
+// GetInstanceByRange returns a page of the instances with an ID in [startKey,
+// endKey). Paginated queries are only valid for read only transactions.
+func (t *InstanceContract) GetInstanceByRange(ctx contractapi.TransactionContextInterface, startKey, endKey string, pageSize int, bookmark string) (*PaginatedInstanceResult, error) {
+	values, nextBookmark, err := listStatePage(ctx, docInstance, startKey, endKey, pageSize, bookmark)
+	if err != nil {
+		return nil, err
+	}
+
+	return instancePage(values, nextBookmark)
+}
+
+func (t *InstanceContract) QueryInstanceByClass(ctx contractapi.TransactionContextInterface, class string, pageSize int, bookmark string) (*PaginatedInstanceResult, error) {
+	return queryInstancePage(ctx, newSelector(docInstance).eq("classID", class).String(), pageSize, bookmark)
+}
+
+func (t *InstanceContract) QueryInstanceByLab(ctx contractapi.TransactionContextInterface, lab string, pageSize int, bookmark string) (*PaginatedInstanceResult, error) {
+	return queryInstancePage(ctx, newSelector(docInstance).eq("labID", lab).String(), pageSize, bookmark)
+}
+
+func (t *InstanceContract) QueryInstanceByOwner(ctx contractapi.TransactionContextInterface, owner string, pageSize int, bookmark string) (*PaginatedInstanceResult, error) {
+	return queryInstancePage(ctx, newSelector(docInstance).eq("owner", owner).String(), pageSize, bookmark)
+}
+
+// queryInstancePage returns a page of the instances matching a CouchDB query
+func queryInstancePage(ctx contractapi.TransactionContextInterface, queryString string, pageSize int, bookmark string) (*PaginatedInstanceResult, error) {
+	values, nextBookmark, err := queryPage(ctx, queryString, pageSize, bookmark)
+	if err != nil {
+		return nil, err
+	}
+
+	return instancePage(values, nextBookmark)
+}
+
+// instancePage decodes a page of instances
+func instancePage(values [][]byte, bookmark string) (*PaginatedInstanceResult, error) {
+	instances := []*Instance{}
+	for _, value := range values {
+		var instance Instance
+		err := json.Unmarshal(value, &instance)
+		if err != nil {
+			return nil, err
+		}
+		instances = append(instances, &instance)
+	}
+
+	return &PaginatedInstanceResult{
+		Instances:           instances,
+		FetchedRecordsCount: int32(len(instances)),
+		Bookmark:            bookmark,
+	}, nil
+}
+
+// GetInstancesByClass returns a page of the instances of a class, read
+// through the classID~instanceID index. Unlike QueryInstanceByClass it works
+// on LevelDB too.
+func (t *InstanceContract) GetInstancesByClass(ctx contractapi.TransactionContextInterface, classID string, pageSize int, bookmark string) (*PaginatedInstanceResult, error) {
+	return getInstancesByIndex(ctx, instanceClassIndex, classID, pageSize, bookmark)
+}
+
+// GetInstancesByLab returns a page of the instances of a lab, read through
+// the labID~instanceID index. Unlike QueryInstanceByLab it works on LevelDB
+// too.
+func (t *InstanceContract) GetInstancesByLab(ctx contractapi.TransactionContextInterface, labID string, pageSize int, bookmark string) (*PaginatedInstanceResult, error) {
+	return getInstancesByIndex(ctx, instanceLabIndex, labID, pageSize, bookmark)
+}
+
+// GetInstancesByOwner returns a page of the instances of an owner, read
+// through the owner~instanceID index. Unlike QueryInstanceByOwner it works
+// on LevelDB too.
+func (t *InstanceContract) GetInstancesByOwner(ctx contractapi.TransactionContextInterface, owner string, pageSize int, bookmark string) (*PaginatedInstanceResult, error) {
+	return getInstancesByIndex(ctx, instanceOwnerIndex, owner, pageSize, bookmark)
+}
+
+// getInstancesByIndex returns a page of the instances whose index entries
+// start with key
+func getInstancesByIndex(ctx contractapi.TransactionContextInterface, index, key string, pageSize int, bookmark string) (*PaginatedInstanceResult, error) {
+	ids, nextBookmark, err := listIndexPage(ctx, index, key, pageSize, bookmark)
+	if err != nil {
+		return nil, err
+	}
+
+	instances := []*Instance{}
+	for _, id := range ids {
+		instance, err := readInstance(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		instances = append(instances, instance)
+	}
+
+	return &PaginatedInstanceResult{
+		Instances:           instances,
+		FetchedRecordsCount: int32(len(instances)),
+		Bookmark:            nextBookmark,
+	}, nil
+}
+
+// GetSubmittingClientIdentity returns the name and issuer of the identity that
+// invokes the smart contract. This function base64 decodes the identity string
+// before returning the value to the client or smart contract.
+func (t *InstanceContract) GetSubmittingClientIdentity(ctx contractapi.TransactionContextInterface) (string, error) {
+
+	b64ID, err := ctx.GetClientIdentity().GetID()
+	if err != nil {
+		return "", fmt.Errorf("Failed to read clientID: %v", err)
+	}
+	decodeID, err := base64.StdEncoding.DecodeString(b64ID)
+	if err != nil {
+		return "", fmt.Errorf("failed to base64 decode clientID: %v", err)
+	}
+	return string(decodeID), nil
+}
+
+// InitLedger creates the initial set of assets in the ledger.
+func (t *InstanceContract) InitLedger(ctx contractapi.TransactionContextInterface) error {
+	instances := []Instance{
+		{ID: "instance1", ClassID: "class1", LabID: "lab1", Config: sampleConfig, Owner: "Tom", UsedTime: 0},
+		{ID: "instance2", ClassID: "class1", LabID: "lab1", Config: sampleConfig, Owner: "Tom", UsedTime: 11},
+		{ID: "instance3", ClassID: "class1", LabID: "lab2", Config: sampleConfig, Owner: "Sam", UsedTime: 10},
+	}
+
+	for _, instance := range instances {
+		_, err := t.createInstance(ctx, instance.ID, instance.LabID, instance.ClassID, instance.Config, instance.Owner)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
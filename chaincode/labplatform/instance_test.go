@@ -0,0 +1,241 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+
+	"ledgertest"
+)
+
+// instanceIndexes are the composite key indexes every instance is listed in
+var instanceIndexes = []string{instanceLabIndex, instanceClassIndex, instanceOwnerIndex}
+
+func createInstance(p *platform) {
+	p.t.Helper()
+
+	p.must(p.call(student, p.instance, "CreateInstance", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := p.instance.CreateInstance(ctx, "instance1", "lab1", "class1", sampleConfig, "")
+		return err
+	}))
+}
+
+func TestCreateInstanceWritesIndexes(t *testing.T) {
+	p := newClassroom(t)
+	createInstance(p)
+
+	var instance Instance
+	if !p.document(docInstance, "instance1", &instance) {
+		t.Fatal("instance was not stored")
+	}
+	if instance.Owner != student.String() || instance.LabID != "lab1" || instance.ClassID != "class1" {
+		t.Errorf("got instance %+v", instance)
+	}
+
+	want := map[string][][]string{
+		instanceLabIndex:   {{"lab1", "instance1"}},
+		instanceClassIndex: {{"class1", "instance1"}},
+		instanceOwnerIndex: {{student.String(), "instance1"}},
+	}
+	for _, index := range instanceIndexes {
+		if keys := p.ledger.CompositeKeys(index); !reflect.DeepEqual(keys, want[index]) {
+			t.Errorf("%s: got %v, want %v", index, keys, want[index])
+		}
+	}
+	if p.lastEvent() != InstanceCreatedEvent {
+		t.Errorf("got event %s", p.lastEvent())
+	}
+}
+
+func TestCreateInstanceChecks(t *testing.T) {
+	p := newClassroom(t)
+
+	tests := map[string]struct {
+		identity                      *ledgertest.Identity
+		labID, classID, config, owner string
+		wantErr                       string
+	}{
+		"not enrolled":        {otherStudent, "lab1", "class1", sampleConfig, "", "is not enrolled"},
+		"for another student": {student, "lab1", "class1", sampleConfig, otherStudent.String(), "not authorized"},
+		"not the class owner": {otherInstructor, "lab1", "class1", sampleConfig, student.String(), "does not own class"},
+		"unknown lab":         {student, "lab2", "class1", sampleConfig, "", "does not exist"},
+		"invalid config":      {student, "lab1", "class1", `{}`, "", "invalid config"},
+	}
+
+	for name, test := range tests {
+		err := p.call(test.identity, p.instance, "CreateInstance", func(ctx contractapi.TransactionContextInterface) error {
+			_, err := p.instance.CreateInstance(ctx, "instance1", test.labID, test.classID, test.config, test.owner)
+			return err
+		})
+		if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+			t.Errorf("%s: got %v, want %q", name, err, test.wantErr)
+		}
+	}
+
+	for _, index := range instanceIndexes {
+		if keys := p.ledger.CompositeKeys(index); len(keys) != 0 {
+			t.Errorf("%s entries written: %v", index, keys)
+		}
+	}
+}
+
+func TestCreateInstanceForStudent(t *testing.T) {
+	p := newClassroom(t)
+
+	p.must(p.call(instructor, p.instance, "CreateInstance", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := p.instance.CreateInstance(ctx, "instance1", "lab1", "class1", sampleConfig, student.String())
+		return err
+	}))
+
+	var instance Instance
+	if !p.document(docInstance, "instance1", &instance) {
+		t.Fatal("instance was not stored")
+	}
+	if instance.Owner != student.String() {
+		t.Errorf("instance belongs to %s, want the student", instance.Owner)
+	}
+}
+
+func TestDeleteInstanceRemovesIndexes(t *testing.T) {
+	p := newClassroom(t)
+	createInstance(p)
+
+	err := p.call(student, p.instance, "DeleteInstance", func(ctx contractapi.TransactionContextInterface) error {
+		return p.instance.DeleteInstance(ctx, "instance1")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if p.document(docInstance, "instance1", nil) {
+		t.Error("instance was not deleted")
+	}
+	for _, index := range instanceIndexes {
+		if keys := p.ledger.CompositeKeys(index); len(keys) != 0 {
+			t.Errorf("%s entries left: %v", index, keys)
+		}
+	}
+	if p.lastEvent() != InstanceDeletedEvent {
+		t.Errorf("got event %s", p.lastEvent())
+	}
+}
+
+func TestDeleteInstanceChecksOwner(t *testing.T) {
+	p := newClassroom(t)
+	createInstance(p)
+
+	err := p.call(otherStudent, p.instance, "DeleteInstance", func(ctx contractapi.TransactionContextInterface) error {
+		return p.instance.DeleteInstance(ctx, "instance1")
+	})
+	if err == nil || !strings.Contains(err.Error(), "not authorized") {
+		t.Errorf("got %v deleting the instance of another student", err)
+	}
+
+	if !p.document(docInstance, "instance1", nil) {
+		t.Error("instance was deleted")
+	}
+	for _, index := range instanceIndexes {
+		if keys := p.ledger.CompositeKeys(index); len(keys) != 1 {
+			t.Errorf("%s: got %v, want the entry of instance1", index, keys)
+		}
+	}
+}
+
+func TestQueryInstances(t *testing.T) {
+	p := newClassroom(t)
+	createInstance(p)
+
+	queries := map[string]func(ctx contractapi.TransactionContextInterface) (*PaginatedInstanceResult, error){
+		"QueryInstanceByClass": func(ctx contractapi.TransactionContextInterface) (*PaginatedInstanceResult, error) {
+			return p.instance.QueryInstanceByClass(ctx, "class1", 0, "")
+		},
+		"QueryInstanceByLab": func(ctx contractapi.TransactionContextInterface) (*PaginatedInstanceResult, error) {
+			return p.instance.QueryInstanceByLab(ctx, "lab1", 0, "")
+		},
+		"QueryInstanceByOwner": func(ctx contractapi.TransactionContextInterface) (*PaginatedInstanceResult, error) {
+			return p.instance.QueryInstanceByOwner(ctx, student.String(), 0, "")
+		},
+	}
+
+	for function, query := range queries {
+		var page *PaginatedInstanceResult
+		err := p.call(instructor, p.instance, function, func(ctx contractapi.TransactionContextInterface) (err error) {
+			page, err = query(ctx)
+			return err
+		})
+		if err != nil {
+			t.Errorf("%s: %v", function, err)
+			continue
+		}
+		if len(page.Instances) != 1 || page.Instances[0].ID != "instance1" || page.Bookmark != "" {
+			t.Errorf("%s: got %+v", function, page)
+		}
+	}
+}
+
+func TestGetInstancesByIndex(t *testing.T) {
+	p := newClassroom(t)
+	createInstance(p)
+	p.must(p.call(instructor, p.class, "EnrollStudent", func(ctx contractapi.TransactionContextInterface) error {
+		return p.class.EnrollStudent(ctx, "class1", otherStudent.String())
+	}))
+	p.must(p.call(otherStudent, p.instance, "CreateInstance", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := p.instance.CreateInstance(ctx, "instance2", "lab1", "class1", sampleConfig, otherStudent.String())
+		return err
+	}))
+
+	queries := map[string]func(ctx contractapi.TransactionContextInterface, bookmark string) (*PaginatedInstanceResult, error){
+		"GetInstancesByClass": func(ctx contractapi.TransactionContextInterface, bookmark string) (*PaginatedInstanceResult, error) {
+			return p.instance.GetInstancesByClass(ctx, "class1", 1, bookmark)
+		},
+		"GetInstancesByLab": func(ctx contractapi.TransactionContextInterface, bookmark string) (*PaginatedInstanceResult, error) {
+			return p.instance.GetInstancesByLab(ctx, "lab1", 1, bookmark)
+		},
+	}
+
+	for function, query := range queries {
+		var first, second *PaginatedInstanceResult
+		err := p.call(instructor, p.instance, function, func(ctx contractapi.TransactionContextInterface) (err error) {
+			first, err = query(ctx, "")
+			return err
+		})
+		if err == nil {
+			err = p.call(instructor, p.instance, function, func(ctx contractapi.TransactionContextInterface) (err error) {
+				second, err = query(ctx, first.Bookmark)
+				return err
+			})
+		}
+		if err != nil {
+			t.Errorf("%s: %v", function, err)
+			continue
+		}
+		if len(first.Instances) != 1 || first.Instances[0].ID != "instance1" || first.Bookmark == "" {
+			t.Errorf("%s: got first page %+v", function, first)
+		}
+		if len(second.Instances) != 1 || second.Instances[0].ID != "instance2" || second.Bookmark != "" {
+			t.Errorf("%s: got second page %+v", function, second)
+		}
+	}
+
+	var page *PaginatedInstanceResult
+	err := p.call(instructor, p.instance, "GetInstancesByOwner", func(ctx contractapi.TransactionContextInterface) (err error) {
+		page, err = p.instance.GetInstancesByOwner(ctx, otherStudent.String(), 0, "")
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Instances) != 1 || page.Instances[0].ID != "instance2" {
+		t.Errorf("got instances %+v of %s", page.Instances, otherStudent)
+	}
+
+	err = p.call(student, p.instance, "GetInstancesByOwner", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := p.instance.GetInstancesByOwner(ctx, otherStudent.String(), 0, "")
+		return err
+	})
+	if err == nil {
+		t.Error("student listed the instances of another student")
+	}
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// Delete policies decide what happens to the dependents of a deleted class
+// or lab: restrict refuses to delete while there are any, cascade deletes
+// them along.
+const (
+	DeleteRestrict = "restrict"
+	DeleteCascade  = "cascade"
+)
+
+// checkDeletePolicy returns an error for unknown delete policies
+func checkDeletePolicy(policy string) error {
+	if policy != DeleteRestrict && policy != DeleteCascade {
+		return fmt.Errorf("unknown delete policy %q, expected %s or %s", policy, DeleteRestrict, DeleteCascade)
+	}
+
+	return nil
+}
+
+// deleteClassLabs applies policy to the labs of a class
+func deleteClassLabs(ctx contractapi.TransactionContextInterface, classID string, policy string) error {
+	err := checkDeletePolicy(policy)
+	if err != nil {
+		return err
+	}
+
+	labIDs, err := listIndex(ctx, labClassIndex, classID)
+	if err != nil {
+		return err
+	}
+
+	if policy == DeleteRestrict {
+		if len(labIDs) > 0 {
+			return fmt.Errorf("class %s still has %d labs, delete them first or use the %s policy", classID, len(labIDs), DeleteCascade)
+		}
+
+		return nil
+	}
+
+	for _, labID := range labIDs {
+		lab, err := readLab(ctx, labID)
+		if err != nil {
+			return err
+		}
+
+		err = deleteLab(ctx, lab, DeleteCascade)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// deleteDependents applies policy to the instances and submissions of a lab
+func deleteDependents(ctx contractapi.TransactionContextInterface, labID, policy string) error {
+	instanceIDs, err := listIndex(ctx, instanceLabIndex, labID)
+	if err != nil {
+		return err
+	}
+	submissionIDs, err := listIndex(ctx, submissionLabIndex, labID)
+	if err != nil {
+		return err
+	}
+
+	if policy == DeleteCascade {
+		err = deleteLabInstances(ctx, instanceIDs)
+		if err != nil {
+			return err
+		}
+
+		return deleteLabSubmissions(ctx, submissionIDs)
+	}
+
+	if len(instanceIDs) > 0 || len(submissionIDs) > 0 {
+		return fmt.Errorf("lab %s still has %d instances and %d submissions, delete them first or use the %s policy", labID, len(instanceIDs), len(submissionIDs), DeleteCascade)
+	}
+
+	return nil
+}
+
+// deleteLabInstances deletes the instances of a lab along with their
+// sessions. The time of open sessions is not added to the usage quota since
+// the lab goes away.
+func deleteLabInstances(ctx contractapi.TransactionContextInterface, instanceIDs []string) error {
+	for _, instanceID := range instanceIDs {
+		instance, err := readInstance(ctx, instanceID)
+		if err != nil {
+			return err
+		}
+
+		err = deleteInstance(ctx, instance)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// deleteLabSubmissions deletes the submissions of a lab
+func deleteLabSubmissions(ctx contractapi.TransactionContextInterface, submissionIDs []string) error {
+	for _, submissionID := range submissionIDs {
+		submission, err := readSubmission(ctx, submissionID)
+		if err != nil {
+			return err
+		}
+
+		err = deleteSubmission(ctx, submission)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// assertClassExists returns an error when classID does not exist
+func assertClassExists(ctx contractapi.TransactionContextInterface, classID string) error {
+	exists, err := classExists(ctx, classID)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("class %s does not exist", classID)
+	}
+
+	return nil
+}
+
+// assertClassOwner returns an error unless clientID owns classID. action
+// names what the client tried.
+func assertClassOwner(ctx contractapi.TransactionContextInterface, clientID, classID, action string) error {
+	err := assertClassExists(ctx, classID)
+	if err != nil {
+		return err
+	}
+
+	class, err := readClass(ctx, classID)
+	if err != nil {
+		return err
+	}
+
+	if clientID != class.Owner {
+		return fmt.Errorf("submitting client not authorized to %s, does not own class %s", action, classID)
+	}
+
+	return nil
+}
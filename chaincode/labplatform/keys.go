@@ -0,0 +1,218 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// Document types. Documents are stored under a composite key made of their
+// docType and ID, so that the documents of one type can be listed without
+// running into index entries or documents of another type. Index names all
+// contain a '~' so they never clash with a docType.
+//
+// The chaincode does not read the plain ID keys of the separate class, lab,
+// instance and submission chaincodes it replaces. It is deployed under its
+// own name and starts from an empty namespace, see the README.
+const (
+	docClass      = "class"
+	docLab        = "lab"
+	docInstance   = "instance"
+	docSubmission = "submission"
+)
+
+// docKey returns the key of a document
+func docKey(ctx contractapi.TransactionContextInterface, docType, id string) (string, error) {
+	return ctx.GetStub().CreateCompositeKey(docType, []string{id})
+}
+
+// getState reads a document, nil when it does not exist
+func getState(ctx contractapi.TransactionContextInterface, docType, id string) ([]byte, error) {
+	key, err := docKey(ctx, docType, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return ctx.GetStub().GetState(key)
+}
+
+// putState writes a document
+func putState(ctx contractapi.TransactionContextInterface, docType, id string, value []byte) error {
+	key, err := docKey(ctx, docType, id)
+	if err != nil {
+		return err
+	}
+
+	return ctx.GetStub().PutState(key, value)
+}
+
+// delState deletes a document
+func delState(ctx contractapi.TransactionContextInterface, docType, id string) error {
+	key, err := docKey(ctx, docType, id)
+	if err != nil {
+		return err
+	}
+
+	return ctx.GetStub().DelState(key)
+}
+
+// listIndex returns the second attribute of every index entry whose first
+// attribute is key.
+func listIndex(ctx contractapi.TransactionContextInterface, index string, key string) ([]string, error) {
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(index, []string{key})
+	if err != nil {
+		return nil, err
+	}
+	defer resultsIterator.Close()
+
+	var values []string
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		_, attributes, err := ctx.GetStub().SplitCompositeKey(queryResponse.Key)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, attributes[1])
+	}
+
+	return values, nil
+}
+
+// Page sizes of the list transactions. They return pages of at most
+// maxPageSize records, a page size of 0 selects defaultPageSize.
+const (
+	defaultPageSize = 100
+	maxPageSize     = 1000
+)
+
+// checkPageSize returns the number of records to fetch for a page size
+func checkPageSize(pageSize int) (int32, error) {
+	if pageSize == 0 {
+		return defaultPageSize, nil
+	}
+	if pageSize < 0 || pageSize > maxPageSize {
+		return 0, fmt.Errorf("page size %d is not between 1 and %d", pageSize, maxPageSize)
+	}
+
+	return int32(pageSize), nil
+}
+
+// listStatePage returns a page of the documents of a type with an ID in
+// [startKey, endKey) and the bookmark of the next page, empty after the last
+// page. Empty bounds are open, like with GetStateByRange.
+func listStatePage(ctx contractapi.TransactionContextInterface, docType, startKey, endKey string, pageSize int, bookmark string) ([][]byte, string, error) {
+	size, err := checkPageSize(pageSize)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// the prefix of every key of the docType ends with the separator in
+	// front of the empty ID
+	prefix, err := docKey(ctx, docType, "")
+	if err != nil {
+		return nil, "", err
+	}
+	err = checkBookmark(bookmark, prefix[:len(prefix)-1], docType)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if bookmark == "" && startKey != "" {
+		bookmark, err = docKey(ctx, docType, startKey)
+		if err != nil {
+			return nil, "", err
+		}
+	}
+
+	resultsIterator, responseMetadata, err := ctx.GetStub().GetStateByPartialCompositeKeyWithPagination(docType, []string{}, size, bookmark)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resultsIterator.Close()
+
+	values := [][]byte{}
+	nextBookmark := responseMetadata.Bookmark
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, "", err
+		}
+
+		_, attributes, err := ctx.GetStub().SplitCompositeKey(queryResponse.Key)
+		if err != nil {
+			return nil, "", err
+		}
+		if len(attributes) != 1 {
+			return nil, "", fmt.Errorf("invalid %s key %q", docType, queryResponse.Key)
+		}
+		if endKey != "" && attributes[0] >= endKey {
+			// the range ends within this page
+			nextBookmark = ""
+			break
+		}
+
+		values = append(values, queryResponse.Value)
+	}
+
+	return values, nextBookmark, nil
+}
+
+// listIndexPage returns a page of the second attributes of the index entries
+// whose first attribute is key, and the bookmark of the next page. It only
+// reads keys, so it works on LevelDB as well as CouchDB.
+func listIndexPage(ctx contractapi.TransactionContextInterface, index string, key string, pageSize int, bookmark string) ([]string, string, error) {
+	size, err := checkPageSize(pageSize)
+	if err != nil {
+		return nil, "", err
+	}
+
+	prefix, err := ctx.GetStub().CreateCompositeKey(index, []string{key})
+	if err != nil {
+		return nil, "", err
+	}
+	err = checkBookmark(bookmark, prefix, index)
+	if err != nil {
+		return nil, "", err
+	}
+
+	resultsIterator, responseMetadata, err := ctx.GetStub().GetStateByPartialCompositeKeyWithPagination(index, []string{key}, size, bookmark)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resultsIterator.Close()
+
+	values := []string{}
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, "", err
+		}
+
+		_, attributes, err := ctx.GetStub().SplitCompositeKey(queryResponse.Key)
+		if err != nil {
+			return nil, "", err
+		}
+		if len(attributes) != 2 {
+			return nil, "", fmt.Errorf("invalid %s key %q", index, queryResponse.Key)
+		}
+		values = append(values, attributes[1])
+	}
+
+	return values, responseMetadata.Bookmark, nil
+}
+
+// checkBookmark refuses bookmarks of another list than the keys starting with
+// prefix. Fabric starts the page at the bookmark, a foreign bookmark would
+// page through other documents or index entries.
+func checkBookmark(bookmark, prefix, list string) error {
+	if bookmark != "" && !strings.HasPrefix(bookmark, prefix) {
+		return fmt.Errorf("invalid bookmark %q for %s", bookmark, list)
+	}
+
+	return nil
+}
@@ -0,0 +1,589 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+
+	"auth"
+	"labconfig"
+)
+
+// LabContract manages the labs of classes and their policies
+type LabContract struct {
+	contractapi.Contract
+}
+
+// Lab describes a lab of a class. Config is the environment of the lab in
+// the canonical encoding of the labconfig package. StartTime and EndTime are
+// RFC 3339 timestamps bounding when submissions are accepted. MaxAttempts
+// limits the attempts per student, 0 means no limit. Quota limits the
+// instances of the lab, a lab without quota has no limits.
+type Lab struct {
+	DocType       string      `json:"docType"` //docType is used to distinguish the various types of objects in state database
+	ID            string      `json:"ID"`
+	ClassID       string      `json:"classID"`
+	Name          string      `json:"name"`
+	Content       string      `json:"content"`
+	Config        string      `json:"config"`
+	StartTime     string      `json:"startTime"`
+	EndTime       string      `json:"endTime"`
+	LatePolicy    string      `json:"latePolicy"`
+	Rubric        []Criterion `json:"rubric,omitempty" metadata:",optional"`
+	MaxAttempts   uint32      `json:"maxAttempts"`
+	GradingPolicy string      `json:"gradingPolicy"`
+	Quota         *Quota      `json:"quota,omitempty" metadata:",optional"`
+	Owner         string      `json:"owner"`
+}
+
+const labClassIndex = "classID~labID"
+
+// PaginatedLabResult is a page of labs, returned by every list of labs.
+// Bookmark is passed to get the next page, it is empty after the last page.
+type PaginatedLabResult struct {
+	Labs                []*Lab `json:"records"`
+	FetchedRecordsCount int32  `json:"fetchedRecordsCount"`
+	Bookmark            string `json:"bookmark"`
+}
+
+// CreateLab adds a lab to a class the submitting client owns
+func (t *LabContract) CreateLab(ctx contractapi.TransactionContextInterface, labID, classID, name, content, config, startTime, endTime string) error {
+	exists, err := t.LabExists(ctx, labID)
+	if err != nil {
+		return fmt.Errorf("failed to get lab: %v", err)
+	}
+	if exists {
+		return fmt.Errorf("lab already exists: %s", labID)
+	}
+
+	_, _, err = parseWindow(startTime, endTime)
+	if err != nil {
+		return err
+	}
+
+	// Get ID of submitting client identity
+	clientID, err := t.GetSubmittingClientIdentity(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Labs are deleted along with their class, so only its owner adds them
+	err = assertClassOwner(ctx, clientID, classID, "create lab")
+	if err != nil {
+		return err
+	}
+
+	config, err = labconfig.Normalize(config)
+	if err != nil {
+		return err
+	}
+
+	lab := &Lab{
+		DocType:       docLab,
+		ID:            labID,
+		ClassID:       classID,
+		Name:          name,
+		Content:       content,
+		Config:        config,
+		StartTime:     startTime,
+		EndTime:       endTime,
+		LatePolicy:    LateReject,
+		GradingPolicy: GradeLatest,
+		Owner:         clientID,
+	}
+	classBytes, err := json.Marshal(lab)
+	if err != nil {
+		return err
+	}
+
+	err = putState(ctx, docLab, labID, classBytes)
+	if err != nil {
+		return err
+	}
+
+	//  Create an index to enable color-based range queries, e.g. return all blue assets.
+	//  An 'index' is a normal key-value entry in the ledger.
+	//  The key is a composite key, with the elements that you want to range query on listed first.
+	//  In our case, the composite key is based on indexName~color~name.
+	//  This will enable very efficient state range queries based on composite keys matching indexName~color~*
+	labNameIndexKey, err := ctx.GetStub().CreateCompositeKey(labClassIndex, []string{lab.ClassID, lab.ID})
+	if err != nil {
+		return err
+	}
+	//  Save index entry to world state. Only the key name is needed, no need to store a duplicate copy of the asset.
+	//  Note - passing a 'nil' value will effectively delete the key from state, therefore we pass null character as value
+	value := []byte{0x00}
+	err = ctx.GetStub().PutState(labNameIndexKey, value)
+	if err != nil {
+		return err
+	}
+
+	return emit(ctx, LabCreatedEvent, lab)
+}
+
+// ReadLab retrieves a lab from the ledger. Students must be enrolled in the
+// class the lab belongs to.
+func (t *LabContract) ReadLab(ctx contractapi.TransactionContextInterface, labID string) (*Lab, error) {
+	lab, err := readLab(ctx, labID)
+	if err != nil {
+		return nil, err
+	}
+
+	clientID, err := t.GetSubmittingClientIdentity(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	staff, err := auth.HasRole(ctx, auth.Staff...)
+	if err != nil {
+		return nil, err
+	}
+
+	if clientID != lab.Owner && !staff {
+		err = assertEnrolled(ctx, lab.ClassID, clientID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return lab, nil
+}
+
+// readableLabs drops the labs of a page the submitting client may not read,
+// like ReadLab refuses them. Pages of students may end up shorter than their
+// page size.
+func (t *LabContract) readableLabs(ctx contractapi.TransactionContextInterface, page *PaginatedLabResult) (*PaginatedLabResult, error) {
+	staff, err := auth.HasRole(ctx, auth.Staff...)
+	if err != nil {
+		return nil, err
+	}
+	if staff {
+		return page, nil
+	}
+
+	clientID, err := t.GetSubmittingClientIdentity(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	enrolled := make(map[string]bool)
+	labs := []*Lab{}
+	for _, lab := range page.Labs {
+		if clientID != lab.Owner {
+			ok, checked := enrolled[lab.ClassID]
+			if !checked {
+				ok, err = isEnrolled(ctx, lab.ClassID, clientID)
+				if err != nil {
+					return nil, err
+				}
+				enrolled[lab.ClassID] = ok
+			}
+			if !ok {
+				continue
+			}
+		}
+		labs = append(labs, lab)
+	}
+	page.Labs = labs
+	page.FetchedRecordsCount = int32(len(labs))
+
+	return page, nil
+}
+
+// readLab retrieves a lab from the ledger without checking the caller, the
+// other contracts use it as well
+func readLab(ctx contractapi.TransactionContextInterface, labID string) (*Lab, error) {
+	labBytes, err := getState(ctx, docLab, labID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get asset %s: %v", labID, err)
+	}
+	if labBytes == nil {
+		return nil, fmt.Errorf("asset %s does not exist", labID)
+	}
+
+	var lab Lab
+	err = json.Unmarshal(labBytes, &lab)
+	if err != nil {
+		return nil, err
+	}
+
+	return &lab, nil
+}
+
+// ReadLabs returns a page of every lab the submitting client may read
+func (t *LabContract) ReadLabs(ctx contractapi.TransactionContextInterface, pageSize int, bookmark string) (*PaginatedLabResult, error) {
+	return t.GetLabsByRange(ctx, "", "", pageSize, bookmark)
+}
+
+// DeleteLab removes a lab from the ledger. policy decides what happens to
+// its instances and submissions, see DeleteRestrict and DeleteCascade.
+func (t *LabContract) DeleteLab(ctx contractapi.TransactionContextInterface, labID, policy string) error {
+	err := checkDeletePolicy(policy)
+	if err != nil {
+		return err
+	}
+
+	lab, err := readLab(ctx, labID)
+	if err != nil {
+		return err
+	}
+
+	clientID, err := t.GetSubmittingClientIdentity(ctx)
+	if err != nil {
+		return err
+	}
+
+	if clientID != lab.Owner {
+		return fmt.Errorf("submitting client not authorized to delete lab, does not own lab")
+	}
+
+	err = deleteLab(ctx, lab, policy)
+	if err != nil {
+		return err
+	}
+
+	return emit(ctx, LabDeletedEvent, &DeleteEvent{ID: labID, Policy: policy})
+}
+
+// deleteLab applies policy to the dependents of a lab and removes the lab
+// and its index entry from the ledger.
+func deleteLab(ctx contractapi.TransactionContextInterface, lab *Lab, policy string) error {
+	err := deleteDependents(ctx, lab.ID, policy)
+	if err != nil {
+		return err
+	}
+
+	err = delState(ctx, docLab, lab.ID)
+	if err != nil {
+		return fmt.Errorf("failed to delete asset %s: %v", lab.ID, err)
+	}
+
+	labNameIndexKey, err := ctx.GetStub().CreateCompositeKey(labClassIndex, []string{lab.ClassID, lab.ID})
+	if err != nil {
+		return err
+	}
+
+	// Delete index entry
+	return ctx.GetStub().DelState(labNameIndexKey)
+}
+
+// TransferAsset transfers an asset by setting a new owner name on the asset
+func (t *LabContract) UpdateLabContent(ctx contractapi.TransactionContextInterface, labID, newContent string) error {
+	lab, err := readLab(ctx, labID)
+	if err != nil {
+		return err
+	}
+
+	clientID, err := t.GetSubmittingClientIdentity(ctx)
+	if err != nil {
+		return err
+	}
+
+	if clientID != lab.Owner {
+		return fmt.Errorf("submitting client not authorized to update lab, does not own lab")
+	}
+
+	lab.Content = newContent
+	labBytes, err := json.Marshal(lab)
+	if err != nil {
+		return err
+	}
+
+	err = putState(ctx, docLab, labID, labBytes)
+	if err != nil {
+		return err
+	}
+
+	return emit(ctx, LabUpdatedEvent, lab)
+}
+
+// UpdateLabConfig replaces the environment config of a lab. Configs the quota
+// of the lab allows have to stay derived from it.
+func (t *LabContract) UpdateLabConfig(ctx contractapi.TransactionContextInterface, labID, newConfig string) error {
+	newConfig, err := labconfig.Normalize(newConfig)
+	if err != nil {
+		return err
+	}
+
+	lab, err := readLab(ctx, labID)
+	if err != nil {
+		return err
+	}
+
+	clientID, err := t.GetSubmittingClientIdentity(ctx)
+	if err != nil {
+		return err
+	}
+
+	if clientID != lab.Owner {
+		return fmt.Errorf("submitting client not authorized to update lab, does not own lab")
+	}
+
+	err = checkAllowedConfigs(newConfig, lab.Quota)
+	if err != nil {
+		return err
+	}
+
+	lab.Config = newConfig
+	labBytes, err := json.Marshal(lab)
+	if err != nil {
+		return err
+	}
+
+	err = putState(ctx, docLab, labID, labBytes)
+	if err != nil {
+		return err
+	}
+
+	return emit(ctx, LabUpdatedEvent, lab)
+}
+
+// TransferAsset transfers an asset by setting a new owner name on the asset
+func (t *LabContract) UpdateLabEndtime(ctx contractapi.TransactionContextInterface, labID, newTime string) error {
+	lab, err := readLab(ctx, labID)
+	if err != nil {
+		return err
+	}
+
+	clientID, err := t.GetSubmittingClientIdentity(ctx)
+	if err != nil {
+		return err
+	}
+
+	if clientID != lab.Owner {
+		return fmt.Errorf("submitting client not authorized to update lab, does not own lab")
+	}
+
+	_, _, err = parseWindow(lab.StartTime, newTime)
+	if err != nil {
+		return err
+	}
+
+	lab.EndTime = newTime
+	labBytes, err := json.Marshal(lab)
+	if err != nil {
+		return err
+	}
+
+	err = putState(ctx, docLab, labID, labBytes)
+	if err != nil {
+		return err
+	}
+
+	return emit(ctx, LabUpdatedEvent, lab)
+}
+
+func (t *LabContract) UpdateLab(ctx contractapi.TransactionContextInterface, labID, newConfig, newName, newContent, newStartTime, newEndTime string) error {
+	lab, err := readLab(ctx, labID)
+	if err != nil {
+		return err
+	}
+
+	clientID, err := t.GetSubmittingClientIdentity(ctx)
+	if err != nil {
+		return err
+	}
+
+	if clientID != lab.Owner {
+		return fmt.Errorf("submitting client not authorized to update lab, does not own lab")
+	}
+
+	_, _, err = parseWindow(newStartTime, newEndTime)
+	if err != nil {
+		return err
+	}
+
+	newConfig, err = labconfig.Normalize(newConfig)
+	if err != nil {
+		return err
+	}
+
+	err = checkAllowedConfigs(newConfig, lab.Quota)
+	if err != nil {
+		return err
+	}
+
+	lab.Config = newConfig
+	lab.Name = newName
+	lab.Content = newContent
+	lab.StartTime = newStartTime
+	lab.EndTime = newEndTime
+	labBytes, err := json.Marshal(lab)
+	if err != nil {
+		return err
+	}
+
+	err = putState(ctx, docLab, labID, labBytes)
+	if err != nil {
+		return err
+	}
+
+	return emit(ctx, LabUpdatedEvent, lab)
+}
+
+// labPage decodes a page of labs
+func labPage(values [][]byte, bookmark string) (*PaginatedLabResult, error) {
+	labs := []*Lab{}
+	for _, value := range values {
+		var lab Lab
+		err := json.Unmarshal(value, &lab)
+		if err != nil {
+			return nil, err
+		}
+		labs = append(labs, &lab)
+	}
+
+	return &PaginatedLabResult{
+		Labs:                labs,
+		FetchedRecordsCount: int32(len(labs)),
+		Bookmark:            bookmark,
+	}, nil
+}
+
+// GetAssetsByRange performs a range query based on the start and end keys provided.
+// Read-only function results are not typically submitted to ordering. If the read-only
+// results are submitted to ordering, or if the query is used in an update transaction
+// and submitted to ordering, then the committing peers will re-execute to guarantee that
+// result sets are stable between endorsement time and commit time. The transaction is
+// invalidated by the committing peers if the result set has changed between endorsement
+// time and commit time.
+// Therefore, range queries are a safe option for performing update transactions based on query results.
+// startKey and endKey are lab IDs. Paginated queries are only valid for
+// read only transactions. Students only get the labs of their classes.
+func (t *LabContract) GetLabsByRange(ctx contractapi.TransactionContextInterface, startKey, endKey string, pageSize int, bookmark string) (*PaginatedLabResult, error) {
+	values, nextBookmark, err := listStatePage(ctx, docLab, startKey, endKey, pageSize, bookmark)
+	if err != nil {
+		return nil, err
+	}
+
+	page, err := labPage(values, nextBookmark)
+	if err != nil {
+		return nil, err
+	}
+
+	return t.readableLabs(ctx, page)
+}
+
+// QueryAssetsByOwner queries for assets based on the owners name.
+// This is an example of a parameterized query where the query logic is baked into the chaincode,
+// and accepting a single query parameter (owner).
+// Only available on state databases that support rich query (e.g. CouchDB)
+// Example: Parameterized rich query
+// Students only get the labs of their classes.
+func (t *LabContract) QueryLabsByClass(ctx contractapi.TransactionContextInterface, class string, pageSize int, bookmark string) (*PaginatedLabResult, error) {
+	values, nextBookmark, err := queryPage(ctx, newSelector(docLab).eq("classID", class).String(), pageSize, bookmark)
+	if err != nil {
+		return nil, err
+	}
+
+	page, err := labPage(values, nextBookmark)
+	if err != nil {
+		return nil, err
+	}
+
+	return t.readableLabs(ctx, page)
+}
+
+// GetLabsByClass returns a page of the labs of a class, read through the
+// classID~labID index. Unlike QueryLabsByClass it works on LevelDB too.
+// Paginated queries are only valid for read only transactions. Students only
+// get the labs of their classes.
+func (t *LabContract) GetLabsByClass(ctx contractapi.TransactionContextInterface, classID string, pageSize int, bookmark string) (*PaginatedLabResult, error) {
+	labIDs, nextBookmark, err := listIndexPage(ctx, labClassIndex, classID, pageSize, bookmark)
+	if err != nil {
+		return nil, err
+	}
+
+	labs := []*Lab{}
+	for _, labID := range labIDs {
+		lab, err := readLab(ctx, labID)
+		if err != nil {
+			return nil, err
+		}
+		labs = append(labs, lab)
+	}
+
+	return t.readableLabs(ctx, &PaginatedLabResult{
+		Labs:                labs,
+		FetchedRecordsCount: int32(len(labs)),
+		Bookmark:            nextBookmark,
+	})
+}
+
+// QueryLabs runs a Query on the labs. Only the fields in labQueryFields can
+// be used, raw CouchDB queries are not accepted.
+// Only available on state databases that support rich query (e.g. CouchDB)
+// Paginated queries are only valid for read only transactions.
+func (t *LabContract) QueryLabs(ctx contractapi.TransactionContextInterface, query Query, pageSize int, bookmark string) (*PaginatedLabResult, error) {
+	s, err := query.selector(docLab, labQueryFields)
+	if err != nil {
+		return nil, err
+	}
+
+	values, nextBookmark, err := queryPage(ctx, s.String(), pageSize, bookmark)
+	if err != nil {
+		return nil, err
+	}
+
+	return labPage(values, nextBookmark)
+}
+
+// GetAssetsByRangeWithPagination is GetLabsByRange, kept for existing
+// clients
+func (t *LabContract) GetAssetsByRangeWithPagination(ctx contractapi.TransactionContextInterface, startKey string, endKey string, pageSize int, bookmark string) (*PaginatedLabResult, error) {
+	return t.GetLabsByRange(ctx, startKey, endKey, pageSize, bookmark)
+}
+
+// QueryAssetsWithPagination is QueryLabs, kept for existing clients
+func (t *LabContract) QueryAssetsWithPagination(ctx contractapi.TransactionContextInterface, query Query, pageSize int, bookmark string) (*PaginatedLabResult, error) {
+	return t.QueryLabs(ctx, query, pageSize, bookmark)
+}
+
+// AssetExists returns true when asset with given ID exists in the ledger.
+func (t *LabContract) LabExists(ctx contractapi.TransactionContextInterface, labID string) (bool, error) {
+	labBytes, err := getState(ctx, docLab, labID)
+	if err != nil {
+		return false, fmt.Errorf("failed to read lab %s from world state. %v", labID, err)
+	}
+
+	return labBytes != nil, nil
+}
+
+// GetSubmittingClientIdentity returns the name and issuer of the identity that
+// invokes the smart contract. This function base64 decodes the identity string
+// before returning the value to the client or smart contract.
+func (t *LabContract) GetSubmittingClientIdentity(ctx contractapi.TransactionContextInterface) (string, error) {
+
+	b64ID, err := ctx.GetClientIdentity().GetID()
+	if err != nil {
+		return "", fmt.Errorf("Failed to read clientID: %v", err)
+	}
+	decodeID, err := base64.StdEncoding.DecodeString(b64ID)
+	if err != nil {
+		return "", fmt.Errorf("failed to base64 decode clientID: %v", err)
+	}
+	return string(decodeID), nil
+}
+
+// sampleConfig is the environment of the labs created by InitLedger
+const sampleConfig = `{"version":1,"image":"ubuntu:20.04","resources":{"cpuMillis":1000,"memoryMiB":1024},"ports":[{"port":22,"protocol":"tcp"}],"timeLimitMinutes":120}`
+
+// InitLedger creates the initial set of assets in the ledger.
+func (t *LabContract) InitLedger(ctx contractapi.TransactionContextInterface) error {
+	labs := []Lab{
+		{DocType: docLab, ID: "lab1", ClassID: "class1", Name: "class1", Content: "test", Config: sampleConfig, StartTime: "2022-09-01T00:00:00Z", EndTime: "2030-01-01T00:00:00Z"},
+		{DocType: docLab, ID: "lab2", ClassID: "class1", Name: "class1", Content: "test", Config: sampleConfig, StartTime: "2022-09-01T00:00:00Z", EndTime: "2030-01-01T00:00:00Z"},
+		{DocType: docLab, ID: "lab3", ClassID: "class1", Name: "class1", Content: "test", Config: sampleConfig, StartTime: "2022-09-01T00:00:00Z", EndTime: "2030-01-01T00:00:00Z"},
+	}
+
+	for _, lab := range labs {
+		err := t.CreateLab(ctx, lab.ID, lab.ClassID, lab.Name, lab.Content, lab.Config, lab.StartTime, lab.EndTime)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
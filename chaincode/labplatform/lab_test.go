@@ -0,0 +1,308 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+
+	"auth"
+	"ledgertest"
+)
+
+func TestCreateLabWritesClassIndex(t *testing.T) {
+	p := newClassroom(t)
+
+	var lab Lab
+	if !p.document(docLab, "lab1", &lab) {
+		t.Fatal("lab was not stored")
+	}
+	if lab.Owner != instructor.String() || lab.LatePolicy != LateReject || lab.GradingPolicy != GradeLatest {
+		t.Errorf("got lab %+v", lab)
+	}
+
+	want := [][]string{{"class1", "lab1"}}
+	if keys := p.ledger.CompositeKeys(labClassIndex); !reflect.DeepEqual(keys, want) {
+		t.Errorf("got index %v, want %v", keys, want)
+	}
+
+	var page *PaginatedLabResult
+	err := p.call(instructor, p.lab, "QueryLabsByClass", func(ctx contractapi.TransactionContextInterface) (err error) {
+		page, err = p.lab.QueryLabsByClass(ctx, "class1", 0, "")
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Labs) != 1 || page.Labs[0].ID != "lab1" || page.Bookmark != "" {
+		t.Errorf("got labs %+v of class1", page)
+	}
+}
+
+func TestCreateLabChecks(t *testing.T) {
+	p := newClassroom(t)
+
+	tests := map[string]struct {
+		identity           *ledgertest.Identity
+		classID, config    string
+		startTime, endTime string
+		wantErr            string
+	}{
+		"unknown class":  {instructor, "class2", sampleConfig, "2022-09-01T00:00:00Z", "2022-12-01T00:00:00Z", "class class2 does not exist"},
+		"invalid config": {instructor, "class1", `{"version":2}`, "2022-09-01T00:00:00Z", "2022-12-01T00:00:00Z", "invalid config"},
+		"closed window":  {instructor, "class1", sampleConfig, "2022-12-01T00:00:00Z", "2022-09-01T00:00:00Z", "is not before end time"},
+		"student":        {student, "class1", sampleConfig, "2022-09-01T00:00:00Z", "2022-12-01T00:00:00Z", "access denied"},
+		"other class":    {otherInstructor, "class1", sampleConfig, "2022-09-01T00:00:00Z", "2022-12-01T00:00:00Z", "does not own class class1"},
+	}
+
+	for name, test := range tests {
+		err := p.call(test.identity, p.lab, "CreateLab", func(ctx contractapi.TransactionContextInterface) error {
+			return p.lab.CreateLab(ctx, "lab2", test.classID, "Switching", "", test.config, test.startTime, test.endTime)
+		})
+		if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+			t.Errorf("%s: got %v, want %q", name, err, test.wantErr)
+		}
+	}
+
+	if p.document(docLab, "lab2", nil) {
+		t.Error("lab2 was stored")
+	}
+}
+
+func TestReadLabRequiresEnrollment(t *testing.T) {
+	p := newClassroom(t)
+
+	read := func(ctx contractapi.TransactionContextInterface) error {
+		_, err := p.lab.ReadLab(ctx, "lab1")
+		return err
+	}
+
+	if err := p.call(student, p.lab, "ReadLab", read); err != nil {
+		t.Errorf("enrolled student: %v", err)
+	}
+	if err := p.call(otherInstructor, p.lab, "ReadLab", read); err != nil {
+		t.Errorf("staff: %v", err)
+	}
+
+	err := p.call(otherStudent, p.lab, "ReadLab", read)
+	if err == nil || !strings.Contains(err.Error(), "is not enrolled") {
+		t.Errorf("got %v reading as a student of another class", err)
+	}
+}
+
+func TestLabListsRequireEnrollment(t *testing.T) {
+	p := newClassroom(t)
+	p.must(p.call(otherInstructor, p.class, "CreateClass", func(ctx contractapi.TransactionContextInterface) error {
+		return p.class.CreateClass(ctx, "class2", "Security", "", "")
+	}))
+	p.must(p.call(otherInstructor, p.lab, "CreateLab", func(ctx contractapi.TransactionContextInterface) error {
+		return p.lab.CreateLab(ctx, "lab2", "class2", "Firewalls", "", sampleConfig, "2022-09-01T00:00:00Z", "2022-12-01T00:00:00Z")
+	}))
+
+	lists := map[string]func(ctx contractapi.TransactionContextInterface) (*PaginatedLabResult, error){
+		"ReadLabs": func(ctx contractapi.TransactionContextInterface) (*PaginatedLabResult, error) {
+			return p.lab.ReadLabs(ctx, 0, "")
+		},
+		"GetLabsByRange": func(ctx contractapi.TransactionContextInterface) (*PaginatedLabResult, error) {
+			return p.lab.GetLabsByRange(ctx, "", "", 0, "")
+		},
+		"QueryLabsByClass": func(ctx contractapi.TransactionContextInterface) (*PaginatedLabResult, error) {
+			return p.lab.QueryLabsByClass(ctx, "class2", 0, "")
+		},
+		"GetLabsByClass": func(ctx contractapi.TransactionContextInterface) (*PaginatedLabResult, error) {
+			return p.lab.GetLabsByClass(ctx, "class2", 0, "")
+		},
+	}
+
+	for function, list := range lists {
+		list := list
+		for _, identity := range []*ledgertest.Identity{student, otherInstructor} {
+			var page *PaginatedLabResult
+			err := p.call(identity, p.lab, function, func(ctx contractapi.TransactionContextInterface) (err error) {
+				page, err = list(ctx)
+				return err
+			})
+			if err != nil {
+				t.Fatalf("%s: %v", function, err)
+			}
+
+			readsLab2 := false
+			for _, lab := range page.Labs {
+				readsLab2 = readsLab2 || lab.ID == "lab2"
+			}
+			if readsLab2 != (identity == otherInstructor) {
+				t.Errorf("%s as %s: got labs %v", function, identity, page.Labs)
+			}
+			if page.FetchedRecordsCount != int32(len(page.Labs)) {
+				t.Errorf("%s: page counts %d records, has %d", function, page.FetchedRecordsCount, len(page.Labs))
+			}
+		}
+	}
+}
+
+func TestLabOwnership(t *testing.T) {
+	p := newClassroom(t)
+
+	err := p.call(otherInstructor, p.lab, "UpdateLabContent", func(ctx contractapi.TransactionContextInterface) error {
+		return p.lab.UpdateLabContent(ctx, "lab1", "Stolen")
+	})
+	if err == nil || !strings.Contains(err.Error(), "not authorized") {
+		t.Errorf("got %v updating the lab of another instructor", err)
+	}
+
+	err = p.call(student, p.lab, "UpdateLabContent", func(ctx contractapi.TransactionContextInterface) error {
+		return p.lab.UpdateLabContent(ctx, "lab1", "Stolen")
+	})
+	if !auth.IsAccessDenied(err) {
+		t.Errorf("got %v updating a lab as a student", err)
+	}
+
+	var lab Lab
+	p.document(docLab, "lab1", &lab)
+	if lab.Content != "Static routes" {
+		t.Errorf("content changed to %q", lab.Content)
+	}
+}
+
+func TestDeleteLab(t *testing.T) {
+	p := newClassroom(t)
+
+	err := p.call(instructor, p.lab, "DeleteLab", func(ctx contractapi.TransactionContextInterface) error {
+		return p.lab.DeleteLab(ctx, "lab1", DeleteRestrict)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if p.document(docLab, "lab1", nil) {
+		t.Error("lab was not deleted")
+	}
+	if keys := p.ledger.CompositeKeys(labClassIndex); len(keys) != 0 {
+		t.Errorf("index entries left: %v", keys)
+	}
+	if p.lastEvent() != LabDeletedEvent {
+		t.Errorf("got event %s", p.lastEvent())
+	}
+}
+
+func TestLabHistory(t *testing.T) {
+	p := newClassroom(t)
+	p.must(p.call(instructor, p.lab, "UpdateLabContent", func(ctx contractapi.TransactionContextInterface) error {
+		return p.lab.UpdateLabContent(ctx, "lab1", "Dynamic routes")
+	}))
+
+	var history []*LabHistory
+	err := p.call(instructor, p.lab, "GetLabHistory", func(ctx contractapi.TransactionContextInterface) (err error) {
+		history, err = p.lab.GetLabHistory(ctx, "lab1")
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var contents []string
+	for _, version := range history {
+		contents = append(contents, version.Lab.Content)
+	}
+	if !reflect.DeepEqual(contents, []string{"Static routes", "Dynamic routes"}) {
+		t.Errorf("got history %q, want oldest first", contents)
+	}
+}
+
+func TestGetLabsByClassPages(t *testing.T) {
+	p := newClassroom(t)
+	p.must(p.call(instructor, p.class, "CreateClass", func(ctx contractapi.TransactionContextInterface) error {
+		return p.class.CreateClass(ctx, "class2", "Security", "", "")
+	}))
+	for _, lab := range [][2]string{{"lab2", "class1"}, {"lab3", "class2"}, {"lab4", "class1"}} {
+		lab := lab
+		p.must(p.call(instructor, p.lab, "CreateLab", func(ctx contractapi.TransactionContextInterface) error {
+			return p.lab.CreateLab(ctx, lab[0], lab[1], "Lab "+lab[0], "", sampleConfig, "2022-09-01T00:00:00Z", "2022-12-01T00:00:00Z")
+		}))
+	}
+
+	var ids []string
+	bookmark := ""
+	for pages := 0; pages == 0 || bookmark != ""; pages++ {
+		if pages == 3 {
+			t.Fatal("bookmark never ends")
+		}
+
+		var page *PaginatedLabResult
+		err := p.call(student, p.lab, "GetLabsByClass", func(ctx contractapi.TransactionContextInterface) (err error) {
+			page, err = p.lab.GetLabsByClass(ctx, "class1", 2, bookmark)
+			return err
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if page.FetchedRecordsCount != int32(len(page.Labs)) {
+			t.Errorf("page counts %d records, has %d", page.FetchedRecordsCount, len(page.Labs))
+		}
+		for _, lab := range page.Labs {
+			ids = append(ids, lab.ID)
+		}
+		bookmark = page.Bookmark
+	}
+
+	if !reflect.DeepEqual(ids, []string{"lab1", "lab2", "lab4"}) {
+		t.Errorf("got labs %v of class1", ids)
+	}
+}
+
+func TestGetLabsByRangePages(t *testing.T) {
+	p := newClassroom(t)
+	for _, labID := range []string{"lab2", "lab3", "lab4"} {
+		labID := labID
+		p.must(p.call(instructor, p.lab, "CreateLab", func(ctx contractapi.TransactionContextInterface) error {
+			return p.lab.CreateLab(ctx, labID, "class1", "Lab "+labID, "", sampleConfig, "2022-09-01T00:00:00Z", "2022-12-01T00:00:00Z")
+		}))
+	}
+
+	var ids []string
+	bookmark := ""
+	for pages := 0; pages == 0 || bookmark != ""; pages++ {
+		if pages == 4 {
+			t.Fatal("bookmark never ends")
+		}
+
+		var page *PaginatedLabResult
+		err := p.call(instructor, p.lab, "GetAssetsByRangeWithPagination", func(ctx contractapi.TransactionContextInterface) (err error) {
+			page, err = p.lab.GetAssetsByRangeWithPagination(ctx, "lab2", "lab4", 1, bookmark)
+			return err
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, lab := range page.Labs {
+			ids = append(ids, lab.ID)
+		}
+		bookmark = page.Bookmark
+	}
+
+	if !reflect.DeepEqual(ids, []string{"lab2", "lab3"}) {
+		t.Errorf("got labs %v in [lab2, lab4)", ids)
+	}
+}
+
+func TestReadLabsRefusesForeignBookmark(t *testing.T) {
+	p := newClassroom(t)
+	createInstance(p)
+
+	err := p.call(instructor, p.lab, "ReadLabs", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := p.lab.ReadLabs(ctx, 0, "\x00instance\x00instance1\x00")
+		return err
+	})
+	if err == nil || !strings.Contains(err.Error(), "invalid bookmark") {
+		t.Errorf("got %v paging labs from an instance key", err)
+	}
+
+	err = p.call(student, p.lab, "GetLabsByClass", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := p.lab.GetLabsByClass(ctx, "class1", 0, "\x00classID~instanceID\x00class1\x00instance1\x00")
+		return err
+	})
+	if err == nil || !strings.Contains(err.Error(), "invalid bookmark") {
+		t.Errorf("got %v paging labs from another index", err)
+	}
+}
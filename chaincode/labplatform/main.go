@@ -0,0 +1,34 @@
+package main
+
+import (
+	"log"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+func main() {
+	classContract := new(ClassContract)
+	classContract.Name = "class"
+	classContract.BeforeTransaction = classPermissions.BeforeTransaction()
+
+	labContract := new(LabContract)
+	labContract.Name = "lab"
+	labContract.BeforeTransaction = labPermissions.BeforeTransaction()
+
+	instanceContract := new(InstanceContract)
+	instanceContract.Name = "instance"
+	instanceContract.BeforeTransaction = instancePermissions.BeforeTransaction()
+
+	submissionContract := new(SubmissionContract)
+	submissionContract.Name = "submission"
+	submissionContract.BeforeTransaction = submissionPermissions.BeforeTransaction()
+
+	chaincode, err := contractapi.NewChaincode(classContract, labContract, instanceContract, submissionContract)
+	if err != nil {
+		log.Panicf("Error creating labplatform chaincode: %v", err)
+	}
+
+	if err := chaincode.Start(); err != nil {
+		log.Panicf("Error starting labplatform chaincode: %v", err)
+	}
+}
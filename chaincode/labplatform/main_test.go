@@ -0,0 +1,137 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+
+	"auth"
+	"ledgertest"
+)
+
+// Identities of the tests. Students have no role attribute.
+var (
+	admin           = ledgertest.NewIdentity("Org1MSP", "admin", map[string]string{auth.RoleAttribute: "admin"})
+	instructor      = ledgertest.NewIdentity("Org1MSP", "instructor", map[string]string{auth.RoleAttribute: "instructor"})
+	otherInstructor = ledgertest.NewIdentity("Org1MSP", "instructor2", map[string]string{auth.RoleAttribute: "instructor"})
+	student         = ledgertest.NewIdentity("Org1MSP", "student", nil)
+	otherStudent    = ledgertest.NewIdentity("Org1MSP", "student2", nil)
+)
+
+// indexDir holds the CouchDB indexes of the chaincode. Tests fail on rich
+// queries none of them covers.
+const indexDir = "META-INF/statedb/couchdb/indexes"
+
+// platform is the chaincode running on an in-memory ledger, with the
+// contracts set up like main does
+type platform struct {
+	t          *testing.T
+	ledger     *ledgertest.Ledger
+	class      *ClassContract
+	lab        *LabContract
+	instance   *InstanceContract
+	submission *SubmissionContract
+}
+
+func newPlatform(t *testing.T) *platform {
+	p := &platform{
+		t:          t,
+		ledger:     ledgertest.NewLedger(),
+		class:      new(ClassContract),
+		lab:        new(LabContract),
+		instance:   new(InstanceContract),
+		submission: new(SubmissionContract),
+	}
+	p.ledger.AddCollections(orgCollection("Org1MSP"), orgCollection("Org2MSP"))
+
+	indexes, err := ledgertest.LoadIndexes(indexDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.ledger.RequireIndexes(indexes...)
+
+	p.class.Name = "class"
+	p.class.BeforeTransaction = classPermissions.BeforeTransaction()
+	p.lab.Name = "lab"
+	p.lab.BeforeTransaction = labPermissions.BeforeTransaction()
+	p.instance.Name = "instance"
+	p.instance.BeforeTransaction = instancePermissions.BeforeTransaction()
+	p.submission.Name = "submission"
+	p.submission.BeforeTransaction = submissionPermissions.BeforeTransaction()
+
+	return p
+}
+
+// newClassroom returns a platform with class1 and its lab1 owned by
+// instructor, and student enrolled in class1
+func newClassroom(t *testing.T) *platform {
+	p := newPlatform(t)
+
+	p.must(p.call(instructor, p.class, "CreateClass", func(ctx contractapi.TransactionContextInterface) error {
+		return p.class.CreateClass(ctx, "class1", "Networks", "Routing and switching", "")
+	}))
+	p.must(p.call(instructor, p.lab, "CreateLab", func(ctx contractapi.TransactionContextInterface) error {
+		return p.lab.CreateLab(ctx, "lab1", "class1", "Routing", "Static routes", sampleConfig, "2022-09-01T00:00:00Z", "2022-12-01T00:00:00Z")
+	}))
+	p.must(p.call(instructor, p.class, "EnrollStudent", func(ctx contractapi.TransactionContextInterface) error {
+		return p.class.EnrollStudent(ctx, "class1", student.String())
+	}))
+
+	return p
+}
+
+// call runs a transaction function of a contract as identity
+func (p *platform) call(identity *ledgertest.Identity, contract contractapi.ContractInterface, function string, fn func(ctx contractapi.TransactionContextInterface) error, options ...ledgertest.TxOption) error {
+	return p.ledger.Call(identity, contract, function, fn, options...)
+}
+
+// must fails the test on errors of setup transactions
+func (p *platform) must(err error) {
+	p.t.Helper()
+	if err != nil {
+		p.t.Fatal(err)
+	}
+}
+
+// document decodes a committed document into v and returns false when it
+// does not exist
+func (p *platform) document(docType, id string, v interface{}) bool {
+	p.t.Helper()
+
+	key := "\x00" + docType + "\x00" + id + "\x00"
+	value := p.ledger.GetState(key)
+	if value == nil {
+		return false
+	}
+	if v != nil {
+		err := json.Unmarshal(value, v)
+		if err != nil {
+			p.t.Fatal(err)
+		}
+	}
+
+	return true
+}
+
+// lastEvent returns the name of the last event, failing without one
+func (p *platform) lastEvent() string {
+	p.t.Helper()
+
+	event := p.ledger.LastEvent()
+	if event == nil {
+		p.t.Fatal("no event was emitted")
+	}
+
+	return event.Name
+}
+
+// transient returns a transient map with the JSON encoding of value at key
+func transient(key string, value interface{}) ledgertest.TxOption {
+	valueBytes, err := json.Marshal(value)
+	if err != nil {
+		panic(err)
+	}
+
+	return ledgertest.WithTransient(map[string][]byte{key: valueBytes})
+}
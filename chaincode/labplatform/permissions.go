@@ -0,0 +1,109 @@
+package main
+
+import (
+	"auth"
+)
+
+// classPermissions lists the roles allowed to call each transaction of the
+// ClassContract. Ownership of a class is checked on top of the role.
+var classPermissions = auth.Permissions{
+	"CreateClass":                 {auth.Admin, auth.Instructor},
+	"UpdateClass":                 {auth.Admin, auth.Instructor},
+	"DeleteClass":                 {auth.Admin, auth.Instructor},
+	"TransferClass":               {auth.Admin, auth.Instructor},
+	"ReadClass":                   auth.Everyone,
+	"GetAllClassses":              auth.Everyone,
+	"GetClassHistory":             auth.Staff,
+	"ClassExists":                 auth.Everyone,
+	"GetSubmittingClientIdentity": auth.Everyone,
+	"InitLedger":                  {auth.Admin},
+
+	"EnrollStudent":         {auth.Admin, auth.Instructor},
+	"DropStudent":           {auth.Admin, auth.Instructor},
+	"IsEnrolled":            auth.Everyone,
+	"ListRoster":            auth.Staff,
+	"ListClassesForStudent": auth.Everyone,
+}
+
+// labPermissions lists the roles allowed to call each transaction of the
+// LabContract. Ownership of a lab is checked on top of the role.
+var labPermissions = auth.Permissions{
+	"CreateLab":                      {auth.Admin, auth.Instructor},
+	"ReadLab":                        auth.Everyone,
+	"ReadLabs":                       auth.Everyone,
+	"DeleteLab":                      {auth.Admin, auth.Instructor},
+	"UpdateLabContent":               {auth.Admin, auth.Instructor},
+	"UpdateLabConfig":                {auth.Admin, auth.Instructor},
+	"UpdateLabEndtime":               {auth.Admin, auth.Instructor},
+	"UpdateLab":                      {auth.Admin, auth.Instructor},
+	"UpdateLabLatePolicy":            {auth.Admin, auth.Instructor},
+	"SetLabRubric":                   {auth.Admin, auth.Instructor},
+	"UpdateLabAttemptPolicy":         {auth.Admin, auth.Instructor},
+	"UpdateLabQuota":                 {auth.Admin, auth.Instructor},
+	"GetLabsByRange":                 auth.Everyone,
+	"GetLabHistory":                  auth.Staff,
+	"QueryLabsByClass":               auth.Everyone,
+	"GetLabsByClass":                 auth.Everyone,
+	"QueryLabs":                      auth.Staff,
+	"GetAssetsByRangeWithPagination": auth.Everyone,
+	"QueryAssetsWithPagination":      auth.Staff,
+	"LabExists":                      auth.Everyone,
+	"GetSubmittingClientIdentity":    auth.Everyone,
+	"InitLedger":                     {auth.Admin},
+}
+
+// instancePermissions lists the roles allowed to call each transaction of
+// the InstanceContract. Ownership of an instance is checked on top of the
+// role.
+var instancePermissions = auth.Permissions{
+	"CreateInstance":              auth.Everyone,
+	"InstanceExists":              auth.Everyone,
+	"ReadInstance":                auth.Everyone,
+	"DeleteInstance":              auth.Everyone,
+	"UpdateInstanceUsedTime":      {auth.Admin},
+	"StartSession":                auth.Everyone,
+	"StopSession":                 auth.Everyone,
+	"ListSessions":                auth.Staff,
+	"GetUsageReport":              auth.Everyone,
+	"GetSubmittingClientIdentity": auth.Everyone,
+	"GetInstanceByRange":          auth.Staff,
+	"GetInstanceHistory":          auth.Staff,
+	"QueryInstanceByClass":        auth.Staff,
+	"QueryInstanceByLab":          auth.Staff,
+	"QueryInstanceByOwner":        auth.Staff,
+	"GetInstancesByClass":         auth.Staff,
+	"GetInstancesByLab":           auth.Staff,
+	"GetInstancesByOwner":         auth.Staff,
+	"InitLedger":                  {auth.Admin},
+}
+
+// submissionPermissions lists the roles allowed to call each transaction of
+// the SubmissionContract.
+var submissionPermissions = auth.Permissions{
+	"CreateSubmission":       auth.Everyone,
+	"SubmissionExists":       auth.Everyone,
+	"ReadSubmission":         auth.Everyone,
+	"GetSubmissionContent":   auth.Everyone,
+	"DeleteSubmission":       {auth.Admin, auth.Instructor},
+	"UpdateSubmissionScore":  auth.Staff,
+	"GetSubmissionByRange":   auth.Staff,
+	"GetSubmissionHistory":   auth.Staff,
+	"QuerySubmissionByClass": auth.Staff,
+	"QuerySubmissionByLab":   auth.Staff,
+	"QuerySubmissionByOwner": auth.Staff,
+	"GetSubmissionsByClass":  auth.Staff,
+	"GetSubmissionsByLab":    auth.Staff,
+	"GetSubmissionsByOwner":  auth.Staff,
+	"InitLedger":             {auth.Admin},
+
+	"GradeSubmission":             auth.Staff,
+	"ReleaseGrade":                auth.Staff,
+	"GetGrade":                    auth.Everyone,
+	"VerifyGrade":                 auth.Everyone,
+	"GetSubmittingClientIdentity": auth.Everyone,
+
+	"SubmitAttempt":    auth.Everyone,
+	"ListAttempts":     auth.Everyone,
+	"GetLatestAttempt": auth.Everyone,
+	"GetGradedAttempt": auth.Everyone,
+}
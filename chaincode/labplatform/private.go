@@ -0,0 +1,115 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// Private documents are kept in the private data collection of the org of
+// their class, see collections_config.json. The public ledger only keeps
+// the hash of their value. Their inputs are passed in the transient map so
+// they don't end up in the transaction either.
+const (
+	docContent = "content"
+	docGrade   = "grade"
+)
+
+// minSaltLength is the shortest salt accepted with private documents whose
+// values are easy to guess, like grades, so their hash can't be reversed by
+// trying every value.
+const minSaltLength = 16
+
+// orgCollection returns the private data collection of an org
+func orgCollection(mspID string) string {
+	return mspID + "PrivateCollection"
+}
+
+// classCollection returns the private data collection of the org of a class
+func classCollection(ctx contractapi.TransactionContextInterface, classID string) (string, error) {
+	class, err := readClass(ctx, classID)
+	if err != nil {
+		return "", err
+	}
+	return orgCollection(class.OrgMSP), nil
+}
+
+// getPrivate reads a private document, nil when it does not exist
+func getPrivate(ctx contractapi.TransactionContextInterface, collection, docType, id string) ([]byte, error) {
+	key, err := docKey(ctx, docType, id)
+	if err != nil {
+		return nil, err
+	}
+
+	value, err := ctx.GetStub().GetPrivateData(collection, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s %s from collection %s: %v", docType, id, collection, err)
+	}
+
+	return value, nil
+}
+
+// putPrivate writes a private document
+func putPrivate(ctx contractapi.TransactionContextInterface, collection, docType, id string, value []byte) error {
+	key, err := docKey(ctx, docType, id)
+	if err != nil {
+		return err
+	}
+
+	return ctx.GetStub().PutPrivateData(collection, key, value)
+}
+
+// delPrivate deletes a private document
+func delPrivate(ctx contractapi.TransactionContextInterface, collection, docType, id string) error {
+	key, err := docKey(ctx, docType, id)
+	if err != nil {
+		return err
+	}
+
+	return ctx.GetStub().DelPrivateData(collection, key)
+}
+
+// matchesPrivate checks a disclosed value against the hash of a private
+// document. It works on peers outside the collection as well.
+func matchesPrivate(ctx contractapi.TransactionContextInterface, collection, docType, id string, value []byte) (bool, error) {
+	key, err := docKey(ctx, docType, id)
+	if err != nil {
+		return false, err
+	}
+
+	hash, err := ctx.GetStub().GetPrivateDataHash(collection, key)
+	if err != nil {
+		return false, fmt.Errorf("failed to read hash of %s %s from collection %s: %v", docType, id, collection, err)
+	}
+	if hash == nil {
+		return false, fmt.Errorf("%s %s does not exist", docType, id)
+	}
+
+	digest := sha256.Sum256(value)
+	return bytes.Equal(hash, digest[:]), nil
+}
+
+// readTransient decodes the JSON value of key in the transient map into v
+func readTransient(ctx contractapi.TransactionContextInterface, key string, v interface{}) error {
+	transient, err := ctx.GetStub().GetTransient()
+	if err != nil {
+		return fmt.Errorf("failed to read transient map: %v", err)
+	}
+
+	value, ok := transient[key]
+	if !ok {
+		return fmt.Errorf("%s must be passed in the transient map", key)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(value))
+	decoder.DisallowUnknownFields()
+	err = decoder.Decode(v)
+	if err != nil {
+		return fmt.Errorf("failed to parse transient %s: %v", key, err)
+	}
+
+	return nil
+}
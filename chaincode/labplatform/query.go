@@ -0,0 +1,237 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// selector builds a CouchDB query on the documents of one type. Values are
+// JSON encoded, so IDs and other input can't change the structure of the
+// query.
+type selector struct {
+	conditions map[string]map[string]interface{}
+	sort       []map[string]string
+}
+
+// newSelector returns a selector matching every document of docType
+func newSelector(docType string) *selector {
+	return (&selector{conditions: make(map[string]map[string]interface{})}).eq("docType", docType)
+}
+
+// eq matches documents whose field equals value
+func (s *selector) eq(field string, value interface{}) *selector {
+	return s.where(field, "$eq", value)
+}
+
+// where matches documents whose field compares to value with a CouchDB
+// condition operator like $gt
+func (s *selector) where(field, operator string, value interface{}) *selector {
+	if s.conditions[field] == nil {
+		s.conditions[field] = make(map[string]interface{})
+	}
+	s.conditions[field][operator] = value
+
+	return s
+}
+
+// sortBy orders the results by field. CouchDB only sorts with an index
+// starting with the sort fields, and only uses indexes whose fields all have
+// a condition in the selector, so the sort starts with docType and the field
+// gets a condition matching every value when it has none yet.
+func (s *selector) sortBy(field string, descending bool) *selector {
+	direction := "asc"
+	if descending {
+		direction = "desc"
+	}
+	if len(s.sort) == 0 {
+		s.sort = append(s.sort, map[string]string{"docType": direction})
+	}
+	s.sort = append(s.sort, map[string]string{field: direction})
+
+	if s.conditions[field] == nil {
+		s.where(field, "$gt", nil)
+	}
+
+	return s
+}
+
+// String returns the query as JSON. Fields that are only compared for
+// equality are written as plain values, like hand-written selectors.
+func (s *selector) String() string {
+	fields := make(map[string]interface{})
+	for field, condition := range s.conditions {
+		if value, ok := condition["$eq"]; ok && len(condition) == 1 {
+			fields[field] = value
+		} else {
+			fields[field] = condition
+		}
+	}
+
+	query := map[string]interface{}{"selector": fields}
+	if len(s.sort) > 0 {
+		query["sort"] = s.sort
+	}
+
+	// Maps of strings, numbers and booleans always marshal
+	queryBytes, _ := json.Marshal(query)
+
+	return string(queryBytes)
+}
+
+// Filter operators of a Query
+const (
+	OpEq  = "eq"
+	OpNe  = "ne"
+	OpGt  = "gt"
+	OpGte = "gte"
+	OpLt  = "lt"
+	OpLte = "lte"
+)
+
+// filterOperators maps the filter operators to CouchDB operators
+var filterOperators = map[string]string{
+	OpEq:  "$eq",
+	OpNe:  "$ne",
+	OpGt:  "$gt",
+	OpGte: "$gte",
+	OpLt:  "$lt",
+	OpLte: "$lte",
+}
+
+// Filter keeps the documents whose Field compares to Value with Operator.
+// Value is parsed according to the type of the field, numbers like
+// maxAttempts are compared as numbers.
+type Filter struct {
+	Field    string `json:"field"`
+	Operator string `json:"operator"`
+	Value    string `json:"value"`
+}
+
+// SortField orders the results of a Query by a field
+type SortField struct {
+	Field      string `json:"field"`
+	Descending bool   `json:"descending,omitempty" metadata:",optional"`
+}
+
+// Query is a rich query in place of a raw CouchDB query string. Only the
+// fields of the queried document type listed in labQueryFields can be
+// filtered and sorted on, each of them has a CouchDB index. Filters all have
+// to match, results are sorted on at most one field. The results are
+// returned in pages like every list.
+type Query struct {
+	Filters []Filter    `json:"filters,omitempty" metadata:",optional"`
+	Sort    []SortField `json:"sort,omitempty" metadata:",optional"`
+}
+
+// fieldType is the JSON type of a queryable field
+type fieldType int
+
+const (
+	stringField fieldType = iota
+	numberField
+)
+
+// labQueryFields are the fields of a Lab a Query may use
+var labQueryFields = map[string]fieldType{
+	"ID":            stringField,
+	"classID":       stringField,
+	"name":          stringField,
+	"owner":         stringField,
+	"startTime":     stringField,
+	"endTime":       stringField,
+	"latePolicy":    stringField,
+	"gradingPolicy": stringField,
+	"maxAttempts":   numberField,
+}
+
+// selector validates the query against the queryable fields of docType and
+// returns it as a selector
+func (q *Query) selector(docType string, fields map[string]fieldType) (*selector, error) {
+	s := newSelector(docType)
+	for _, filter := range q.Filters {
+		kind, ok := fields[filter.Field]
+		if !ok {
+			return nil, fmt.Errorf("field %q can't be queried, expected one of %s", filter.Field, fieldNames(fields))
+		}
+		operator, ok := filterOperators[filter.Operator]
+		if !ok {
+			return nil, fmt.Errorf("unknown query operator %q", filter.Operator)
+		}
+
+		value, err := parseFilterValue(kind, filter.Value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value of field %s: %v", filter.Field, err)
+		}
+		s.where(filter.Field, operator, value)
+	}
+
+	// Every sort needs an index of its own, there is one per field
+	if len(q.Sort) > 1 {
+		return nil, fmt.Errorf("query can only be sorted on one field")
+	}
+	for _, field := range q.Sort {
+		if _, ok := fields[field.Field]; !ok {
+			return nil, fmt.Errorf("field %q can't be sorted on, expected one of %s", field.Field, fieldNames(fields))
+		}
+		s.sortBy(field.Field, field.Descending)
+	}
+
+	return s, nil
+}
+
+// parseFilterValue parses the value of a filter on a field of type kind
+func parseFilterValue(kind fieldType, value string) (interface{}, error) {
+	switch kind {
+	case numberField:
+		return strconv.ParseFloat(value, 64)
+	default:
+		return value, nil
+	}
+}
+
+// fieldNames returns the names of fields, in order
+func fieldNames(fields map[string]fieldType) string {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return fmt.Sprint(names)
+}
+
+// queryPage returns a page of the results of a CouchDB query and the
+// bookmark of the next page. CouchDB returns a bookmark after the last page
+// too, it is dropped once a page is short so that the bookmark is empty
+// after the last page like for key ranges.
+func queryPage(ctx contractapi.TransactionContextInterface, queryString string, pageSize int, bookmark string) ([][]byte, string, error) {
+	size, err := checkPageSize(pageSize)
+	if err != nil {
+		return nil, "", err
+	}
+
+	resultsIterator, responseMetadata, err := ctx.GetStub().GetQueryResultWithPagination(queryString, size, bookmark)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resultsIterator.Close()
+
+	values := [][]byte{}
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, "", err
+		}
+		values = append(values, queryResponse.Value)
+	}
+
+	if len(values) < int(size) {
+		return values, "", nil
+	}
+
+	return values, responseMetadata.Bookmark, nil
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+func TestSelectorEncodesValues(t *testing.T) {
+	injected := `class1"},"docType":{"$gt":null},"x":{"$eq":"`
+	query := newSelector(docLab).eq("classID", injected).where("maxAttempts", "$gte", 3).sortBy("name", true).String()
+
+	var decoded map[string]interface{}
+	err := json.Unmarshal([]byte(query), &decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]interface{}{
+		"selector": map[string]interface{}{
+			"docType":     "lab",
+			"classID":     injected,
+			"maxAttempts": map[string]interface{}{"$gte": float64(3)},
+			"name":        map[string]interface{}{"$gt": nil},
+		},
+		"sort": []interface{}{map[string]interface{}{"docType": "desc"}, map[string]interface{}{"name": "desc"}},
+	}
+	if !reflect.DeepEqual(decoded, want) {
+		t.Errorf("got %s", query)
+	}
+}
+
+func TestQueryLabsByClassIgnoresInjection(t *testing.T) {
+	p := newClassroom(t)
+
+	var page *PaginatedLabResult
+	err := p.call(instructor, p.lab, "QueryLabsByClass", func(ctx contractapi.TransactionContextInterface) (err error) {
+		page, err = p.lab.QueryLabsByClass(ctx, `x","classID":{"$gt":null},"docType":"lab`, 0, "")
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Labs) != 0 {
+		t.Errorf("crafted class ID matched %d labs", len(page.Labs))
+	}
+}
+
+func TestQueryValidation(t *testing.T) {
+	tests := map[string]struct {
+		query   Query
+		wantErr string
+	}{
+		"unknown field":   {Query{Filters: []Filter{{Field: "docType", Operator: OpEq, Value: "class"}}}, `field "docType" can't be queried`},
+		"unknown op":      {Query{Filters: []Filter{{Field: "name", Operator: "regex", Value: ".*"}}}, `unknown query operator "regex"`},
+		"not a number":    {Query{Filters: []Filter{{Field: "maxAttempts", Operator: OpGt, Value: "many"}}}, "invalid value of field maxAttempts"},
+		"unknown sort":    {Query{Sort: []SortField{{Field: "content"}}}, `field "content" can't be sorted on`},
+		"two sort fields": {Query{Sort: []SortField{{Field: "name"}, {Field: "ID"}}}, "sorted on one field"},
+		"valid": {Query{
+			Filters: []Filter{{Field: "classID", Operator: OpEq, Value: "class1"}, {Field: "maxAttempts", Operator: OpLte, Value: "3"}},
+			Sort:    []SortField{{Field: "name"}},
+		}, ""},
+	}
+
+	for name, test := range tests {
+		_, err := test.query.selector(docLab, labQueryFields)
+		if test.wantErr == "" {
+			if err != nil {
+				t.Errorf("%s: %v", name, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+			t.Errorf("%s: got %v, want %q", name, err, test.wantErr)
+		}
+	}
+}
+
+func TestQueryLabs(t *testing.T) {
+	p := newClassroom(t)
+	for _, labID := range []string{"lab2", "lab3"} {
+		labID := labID
+		p.must(p.call(instructor, p.lab, "CreateLab", func(ctx contractapi.TransactionContextInterface) error {
+			return p.lab.CreateLab(ctx, labID, "class1", "Lab "+labID, "", sampleConfig, "2022-09-01T00:00:00Z", "2022-12-01T00:00:00Z")
+		}))
+		p.must(p.call(instructor, p.lab, "UpdateLabAttemptPolicy", func(ctx contractapi.TransactionContextInterface) error {
+			return p.lab.UpdateLabAttemptPolicy(ctx, labID, 3, GradeBest)
+		}))
+	}
+
+	query := Query{
+		Filters: []Filter{{Field: "classID", Operator: OpEq, Value: "class1"}, {Field: "maxAttempts", Operator: OpGte, Value: "3"}},
+		Sort:    []SortField{{Field: "name", Descending: true}},
+	}
+
+	var ids []string
+	bookmark := ""
+	for pages := 0; pages == 0 || bookmark != ""; pages++ {
+		if pages == 3 {
+			t.Fatal("bookmark never ends")
+		}
+
+		var page *PaginatedLabResult
+		err := p.call(instructor, p.lab, "QueryLabs", func(ctx contractapi.TransactionContextInterface) (err error) {
+			page, err = p.lab.QueryLabs(ctx, query, 1, bookmark)
+			return err
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, lab := range page.Labs {
+			ids = append(ids, lab.ID)
+		}
+		bookmark = page.Bookmark
+	}
+	if !reflect.DeepEqual(ids, []string{"lab3", "lab2"}) {
+		t.Errorf("got labs %v", ids)
+	}
+
+	var page *PaginatedLabResult
+	err := p.call(instructor, p.lab, "QueryAssetsWithPagination", func(ctx contractapi.TransactionContextInterface) (err error) {
+		page, err = p.lab.QueryAssetsWithPagination(ctx, query, 0, "")
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Labs) != 2 || page.FetchedRecordsCount != 2 || page.Bookmark != "" {
+		t.Errorf("got page %+v", page)
+	}
+
+	err = p.call(instructor, p.lab, "QueryLabs", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := p.lab.QueryLabs(ctx, query, maxPageSize+1, "")
+		return err
+	})
+	if err == nil || !strings.Contains(err.Error(), "page size") {
+		t.Errorf("got %v with a page size above the maximum", err)
+	}
+}
@@ -0,0 +1,346 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+
+	"labconfig"
+)
+
+// Quota limits the instances students run for a lab. Zero limits and an
+// empty list of configurations mean no limit. Allowed configs have to be
+// derived from the config of the lab, see labconfig.
+type Quota struct {
+	MaxInstancesPerStudent uint32   `json:"maxInstancesPerStudent"`
+	MaxUsageHours          uint32   `json:"maxUsageHours"`
+	AllowedConfigs         []string `json:"allowedConfigs,omitempty" metadata:",optional"`
+}
+
+// usageIndex keeps the seconds a student used the instances of a lab. It
+// outlives the instances, deleting an instance does not give back quota.
+const usageIndex = "labID~owner"
+
+// quotaDenialIndex keeps the transactions the quota of a lab refused under the
+// lab, the owner and the transaction ID. Unlike the other indexes the entries
+// hold the QuotaDenial itself.
+const quotaDenialIndex = "labID~owner~txID"
+
+// QuotaError reports a student going over the quota of a lab
+type QuotaError struct {
+	LabID      string `json:"labID"`
+	Owner      string `json:"owner"`
+	InstanceID string `json:"instanceID,omitempty"`
+	Quota      string `json:"quota"`
+	Limit      uint64 `json:"limit"`
+	Used       uint64 `json:"used"`
+}
+
+func (e *QuotaError) Error() string {
+	return fmt.Sprintf("quota exceeded for lab %s: %s is %d, limit is %d", e.LabID, e.Quota, e.Used, e.Limit)
+}
+
+// QuotaDenial is a CreateInstance or StartSession refused by the quota of a
+// lab. Fabric drops the writes and the event of failed transactions, so
+// refused transactions commit their denial and return it instead of failing.
+// It is also the payload of the QuotaExceeded event.
+type QuotaDenial struct {
+	Transaction string `json:"transaction"`
+	TxID        string `json:"txID"`
+	DeniedAt    string `json:"deniedAt"`
+	LabID       string `json:"labID"`
+	Owner       string `json:"owner"`
+	InstanceID  string `json:"instanceID,omitempty" metadata:",optional"`
+	Quota       string `json:"quota"`
+	Limit       uint64 `json:"limit"`
+	Used        uint64 `json:"used"`
+}
+
+// UpdateLabQuota replaces the quota of a lab. The InstanceContract enforces
+// it when instances are created and started.
+func (t *LabContract) UpdateLabQuota(ctx contractapi.TransactionContextInterface, labID string, quota Quota) error {
+	configs := make(map[string]bool)
+	for i, config := range quota.AllowedConfigs {
+		config, err := labconfig.Normalize(config)
+		if err != nil {
+			return err
+		}
+		if configs[config] {
+			return fmt.Errorf("quota allows config %s twice", config)
+		}
+		configs[config] = true
+		quota.AllowedConfigs[i] = config
+	}
+
+	lab, err := readLab(ctx, labID)
+	if err != nil {
+		return err
+	}
+
+	clientID, err := t.GetSubmittingClientIdentity(ctx)
+	if err != nil {
+		return err
+	}
+
+	if clientID != lab.Owner {
+		return fmt.Errorf("submitting client not authorized to update lab, does not own lab")
+	}
+
+	err = checkAllowedConfigs(lab.Config, &quota)
+	if err != nil {
+		return err
+	}
+
+	lab.Quota = &quota
+	labBytes, err := json.Marshal(lab)
+	if err != nil {
+		return err
+	}
+
+	err = putState(ctx, docLab, labID, labBytes)
+	if err != nil {
+		return err
+	}
+
+	return emit(ctx, LabUpdatedEvent, lab)
+}
+
+// checkAllowedConfigs checks that every config allowed by quota is derived
+// from the lab config labConfig.
+func checkAllowedConfigs(labConfig string, quota *Quota) error {
+	if quota == nil || len(quota.AllowedConfigs) == 0 {
+		return nil
+	}
+
+	parent, err := labconfig.Parse(labConfig)
+	if err != nil {
+		return fmt.Errorf("lab has no valid config: %v", err)
+	}
+
+	for _, allowed := range quota.AllowedConfigs {
+		config, err := labconfig.Parse(allowed)
+		if err != nil {
+			return err
+		}
+
+		err = config.DerivedFrom(parent)
+		if err != nil {
+			return fmt.Errorf("quota allows a config not derived from the lab config: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// checkCreateQuota returns a QuotaError when owner may not create another
+// instance of the lab with config. Both config and the allowed configs of the
+// quota are in canonical encoding, a config the quota does not allow is an
+// invalid request rather than a quota running out.
+func checkCreateQuota(ctx contractapi.TransactionContextInterface, lab *Lab, owner, config string) (*QuotaError, error) {
+	if lab.Quota == nil {
+		return nil, nil
+	}
+
+	if len(lab.Quota.AllowedConfigs) > 0 {
+		allowed := false
+		for _, allowedConfig := range lab.Quota.AllowedConfigs {
+			if config == allowedConfig {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return nil, fmt.Errorf("quota exceeded for lab %s: config %s is not allowed", lab.ID, config)
+		}
+	}
+
+	if lab.Quota.MaxInstancesPerStudent > 0 {
+		count, err := countInstances(ctx, lab.ID, owner)
+		if err != nil {
+			return nil, err
+		}
+		if count >= uint64(lab.Quota.MaxInstancesPerStudent) {
+			return &QuotaError{
+				LabID: lab.ID,
+				Owner: owner,
+				Quota: "instances",
+				Limit: uint64(lab.Quota.MaxInstancesPerStudent),
+				Used:  count,
+			}, nil
+		}
+	}
+
+	return checkUsageQuota(ctx, lab, owner, "")
+}
+
+// checkUsageQuota returns a QuotaError when owner has no usage time left for
+// the lab.
+func checkUsageQuota(ctx contractapi.TransactionContextInterface, lab *Lab, owner, instanceID string) (*QuotaError, error) {
+	if lab.Quota == nil || lab.Quota.MaxUsageHours == 0 {
+		return nil, nil
+	}
+
+	used, err := readUsage(ctx, lab.ID, owner)
+	if err != nil {
+		return nil, err
+	}
+
+	limit := uint64(lab.Quota.MaxUsageHours) * 3600
+	if used >= limit {
+		return &QuotaError{
+			LabID:      lab.ID,
+			Owner:      owner,
+			InstanceID: instanceID,
+			Quota:      "usage seconds",
+			Limit:      limit,
+			Used:       used,
+		}, nil
+	}
+
+	return nil, nil
+}
+
+// denyQuota commits the denial of the transaction function by exceeded and
+// emits it as QuotaExceeded event
+func denyQuota(ctx contractapi.TransactionContextInterface, function string, exceeded *QuotaError) (*QuotaDenial, error) {
+	deniedAt, err := txTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	denial := &QuotaDenial{
+		Transaction: function,
+		TxID:        ctx.GetStub().GetTxID(),
+		DeniedAt:    deniedAt.Format(time.RFC3339),
+		LabID:       exceeded.LabID,
+		Owner:       exceeded.Owner,
+		InstanceID:  exceeded.InstanceID,
+		Quota:       exceeded.Quota,
+		Limit:       exceeded.Limit,
+		Used:        exceeded.Used,
+	}
+	denialBytes, err := json.Marshal(denial)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := ctx.GetStub().CreateCompositeKey(quotaDenialIndex, []string{denial.LabID, denial.Owner, denial.TxID})
+	if err != nil {
+		return nil, err
+	}
+	err = ctx.GetStub().PutState(key, denialBytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record quota denial: %v", err)
+	}
+
+	err = emit(ctx, QuotaExceededEvent, denial)
+	if err != nil {
+		return nil, err
+	}
+
+	return denial, nil
+}
+
+// addUsage adds seconds, possibly negative, to the usage of owner for the
+// lab. It returns a QuotaError when the usage ends up over quota. The usage
+// is recorded all the same, callers report the QuotaError in their event.
+func addUsage(ctx contractapi.TransactionContextInterface, lab *Lab, owner, instanceID string, seconds int64) (*QuotaError, error) {
+	used, err := readUsage(ctx, lab.ID, owner)
+	if err != nil {
+		return nil, err
+	}
+
+	if seconds < 0 && uint64(-seconds) > used {
+		used = 0
+	} else {
+		used = uint64(int64(used) + seconds)
+	}
+
+	key, err := ctx.GetStub().CreateCompositeKey(usageIndex, []string{lab.ID, owner})
+	if err != nil {
+		return nil, err
+	}
+	err = ctx.GetStub().PutState(key, []byte(strconv.FormatUint(used, 10)))
+	if err != nil {
+		return nil, err
+	}
+
+	if lab.Quota == nil || lab.Quota.MaxUsageHours == 0 {
+		return nil, nil
+	}
+	limit := uint64(lab.Quota.MaxUsageHours) * 3600
+	if used <= limit {
+		return nil, nil
+	}
+
+	return &QuotaError{
+		LabID:      lab.ID,
+		Owner:      owner,
+		InstanceID: instanceID,
+		Quota:      "usage seconds",
+		Limit:      limit,
+		Used:       used,
+	}, nil
+}
+
+// readUsage returns the seconds owner used the instances of a lab
+func readUsage(ctx contractapi.TransactionContextInterface, labID, owner string) (uint64, error) {
+	key, err := ctx.GetStub().CreateCompositeKey(usageIndex, []string{labID, owner})
+	if err != nil {
+		return 0, err
+	}
+
+	usageBytes, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read usage of lab %s: %v", labID, err)
+	}
+	if usageBytes == nil {
+		return 0, nil
+	}
+
+	return strconv.ParseUint(string(usageBytes), 10, 64)
+}
+
+// countInstances returns how many instances of a lab owner has
+func countInstances(ctx contractapi.TransactionContextInterface, labID, owner string) (uint64, error) {
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(instanceOwnerIndex, []string{owner})
+	if err != nil {
+		return 0, err
+	}
+	defer resultsIterator.Close()
+
+	var count uint64
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return 0, err
+		}
+
+		_, attributes, err := ctx.GetStub().SplitCompositeKey(queryResponse.Key)
+		if err != nil {
+			return 0, err
+		}
+
+		instanceBytes, err := getState(ctx, docInstance, attributes[1])
+		if err != nil {
+			return 0, err
+		}
+		if instanceBytes == nil {
+			continue
+		}
+
+		var instance Instance
+		err = json.Unmarshal(instanceBytes, &instance)
+		if err != nil {
+			return 0, err
+		}
+		if instance.LabID == labID {
+			count++
+		}
+	}
+
+	return count, nil
+}
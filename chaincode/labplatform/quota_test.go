@@ -0,0 +1,132 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// setQuota replaces the quota of lab1
+func setQuota(p *platform, quota Quota) {
+	p.t.Helper()
+
+	p.must(p.call(instructor, p.lab, "UpdateLabQuota", func(ctx contractapi.TransactionContextInterface) error {
+		return p.lab.UpdateLabQuota(ctx, "lab1", quota)
+	}))
+}
+
+// checkDenial checks that a transaction returned and committed a denial
+func checkDenial(p *platform, denial *QuotaDenial, transaction, quota string) {
+	p.t.Helper()
+
+	if denial == nil {
+		p.t.Fatalf("%s was not denied", transaction)
+	}
+	if denial.Transaction != transaction || denial.Quota != quota || denial.Owner != student.String() || denial.LabID != "lab1" {
+		p.t.Errorf("got denial %+v", denial)
+	}
+
+	keys := p.ledger.CompositeKeys(quotaDenialIndex)
+	if len(keys) != 1 || keys[0][0] != "lab1" || keys[0][1] != student.String() || keys[0][2] != denial.TxID {
+		p.t.Errorf("got %s entries %v", quotaDenialIndex, keys)
+	}
+	if p.lastEvent() != QuotaExceededEvent {
+		p.t.Errorf("got event %s", p.lastEvent())
+	}
+}
+
+func TestCreateInstanceOverQuotaIsDenied(t *testing.T) {
+	p := newClassroom(t)
+	setQuota(p, Quota{MaxInstancesPerStudent: 1})
+	createInstance(p)
+
+	var denial *QuotaDenial
+	err := p.call(student, p.instance, "CreateInstance", func(ctx contractapi.TransactionContextInterface) (err error) {
+		denial, err = p.instance.CreateInstance(ctx, "instance2", "lab1", "class1", sampleConfig, "")
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	checkDenial(p, denial, "CreateInstance", "instances")
+	if p.document(docInstance, "instance2", nil) {
+		t.Error("instance over quota was created")
+	}
+}
+
+func TestStartSessionOverQuotaIsDenied(t *testing.T) {
+	p := newClassroom(t)
+	setQuota(p, Quota{MaxUsageHours: 1})
+	createInstance(p)
+
+	start := func() *QuotaDenial {
+		var denial *QuotaDenial
+		p.must(p.call(student, p.instance, "StartSession", func(ctx contractapi.TransactionContextInterface) (err error) {
+			denial, err = p.instance.StartSession(ctx, "instance1")
+			return err
+		}))
+		return denial
+	}
+
+	if denial := start(); denial != nil {
+		t.Fatalf("got denial %+v within quota", denial)
+	}
+	p.ledger.Advance(2 * time.Hour)
+	p.must(p.call(student, p.instance, "StopSession", func(ctx contractapi.TransactionContextInterface) error {
+		return p.instance.StopSession(ctx, "instance1")
+	}))
+
+	checkDenial(p, start(), "StartSession", "usage seconds")
+
+	var instance Instance
+	p.document(docInstance, "instance1", &instance)
+	if instance.ActiveSession != 0 {
+		t.Errorf("session %d was opened over quota", instance.ActiveSession)
+	}
+}
+
+func TestUsageOverQuotaKeepsEvents(t *testing.T) {
+	p := newClassroom(t)
+	setQuota(p, Quota{MaxUsageHours: 1})
+	createInstance(p)
+
+	p.must(p.call(student, p.instance, "StartSession", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := p.instance.StartSession(ctx, "instance1")
+		return err
+	}))
+	p.ledger.Advance(2 * time.Hour)
+	p.must(p.call(student, p.instance, "StopSession", func(ctx contractapi.TransactionContextInterface) error {
+		return p.instance.StopSession(ctx, "instance1")
+	}))
+
+	var stopped struct {
+		Session
+		QuotaExceeded *QuotaError `json:"quotaExceeded"`
+	}
+	event := p.ledger.LastEvent()
+	if event.Name != SessionStoppedEvent || json.Unmarshal(event.Payload, &stopped) != nil {
+		t.Fatalf("got event %s %s", event.Name, event.Payload)
+	}
+	if stopped.Seconds != 7200 || stopped.QuotaExceeded == nil || stopped.QuotaExceeded.Used != 7200 {
+		t.Errorf("got SessionStopped payload %s", event.Payload)
+	}
+
+	p.must(p.call(admin, p.instance, "UpdateInstanceUsedTime", func(ctx contractapi.TransactionContextInterface) error {
+		return p.instance.UpdateInstanceUsedTime(ctx, "instance1", 1800)
+	}))
+
+	var updated struct {
+		Instance
+		QuotaExceeded *QuotaError `json:"quotaExceeded"`
+	}
+	event = p.ledger.LastEvent()
+	if event.Name != InstanceUpdatedEvent || json.Unmarshal(event.Payload, &updated) != nil {
+		t.Fatalf("got event %s %s", event.Name, event.Payload)
+	}
+	if updated.UsedTime != 1800 || updated.QuotaExceeded != nil {
+		t.Errorf("got InstanceUpdated payload %s", event.Payload)
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// Criterion is one line of the rubric submissions of a lab are graded by
+type Criterion struct {
+	Name      string `json:"name"`
+	MaxPoints uint32 `json:"maxPoints"`
+}
+
+// SetLabRubric replaces the rubric of a lab. Criteria need a unique name and
+// are worth at least one point.
+func (t *LabContract) SetLabRubric(ctx contractapi.TransactionContextInterface, labID string, rubric []Criterion) error {
+	if len(rubric) == 0 {
+		return fmt.Errorf("rubric has no criteria")
+	}
+	names := make(map[string]bool)
+	for _, criterion := range rubric {
+		if criterion.Name == "" {
+			return fmt.Errorf("rubric criterion has no name")
+		}
+		if names[criterion.Name] {
+			return fmt.Errorf("rubric criterion %s is listed twice", criterion.Name)
+		}
+		if criterion.MaxPoints == 0 {
+			return fmt.Errorf("rubric criterion %s is worth no points", criterion.Name)
+		}
+		names[criterion.Name] = true
+	}
+
+	lab, err := readLab(ctx, labID)
+	if err != nil {
+		return err
+	}
+
+	clientID, err := t.GetSubmittingClientIdentity(ctx)
+	if err != nil {
+		return err
+	}
+
+	if clientID != lab.Owner {
+		return fmt.Errorf("submitting client not authorized to update lab, does not own lab")
+	}
+
+	lab.Rubric = rubric
+	labBytes, err := json.Marshal(lab)
+	if err != nil {
+		return err
+	}
+
+	err = putState(ctx, docLab, labID, labBytes)
+	if err != nil {
+		return err
+	}
+
+	return emit(ctx, LabUpdatedEvent, lab)
+}
@@ -0,0 +1,407 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+
+	"auth"
+)
+
+// sessionIndex stores the sessions of an instance under its ID and the zero
+// padded session number. Unlike the other indexes the entries hold the
+// session itself. Sessions are deleted along with their instance, their
+// seconds stay in the usage index.
+const sessionIndex = "instanceID~session"
+
+// Session is one interval an instance was running. Both times are RFC 3339
+// transaction timestamps, StoppedAt is empty while the session is open.
+type Session struct {
+	InstanceID string `json:"instanceID"`
+	Number     uint32 `json:"number"`
+	StartedAt  string `json:"startedAt"`
+	StoppedAt  string `json:"stoppedAt,omitempty" metadata:",optional"`
+	Seconds    uint64 `json:"seconds"`
+}
+
+// UsageEntry is the usage of the instances of one student for one lab,
+// deleted instances included
+type UsageEntry struct {
+	ClassID string `json:"classID"`
+	LabID   string `json:"labID"`
+	Owner   string `json:"owner"`
+	Seconds uint64 `json:"seconds"`
+}
+
+// UsageReport is the usage of a class, read from the usage the quota counts.
+// Open sessions are left out until they are stopped.
+type UsageReport struct {
+	ClassID      string        `json:"classID"`
+	GeneratedAt  string        `json:"generatedAt"`
+	Entries      []*UsageEntry `json:"entries"`
+	TotalSeconds uint64        `json:"totalSeconds"`
+}
+
+// StartSession opens a session on an instance owned by the submitting client.
+// Once the owner has used up the usage quota of the lab, it returns the
+// committed QuotaDenial instead.
+func (t *InstanceContract) StartSession(ctx contractapi.TransactionContextInterface, instanceID string) (*QuotaDenial, error) {
+	instance, err := t.readOwnedInstance(ctx, instanceID)
+	if err != nil {
+		return nil, err
+	}
+
+	if instance.ActiveSession != 0 {
+		return nil, fmt.Errorf("instance %s already has open session %d", instanceID, instance.ActiveSession)
+	}
+
+	lab, err := readLab(ctx, instance.LabID)
+	if err != nil {
+		return nil, err
+	}
+
+	exceeded, err := checkUsageQuota(ctx, lab, instance.Owner, instanceID)
+	if err != nil {
+		return nil, err
+	}
+	if exceeded != nil {
+		return denyQuota(ctx, "StartSession", exceeded)
+	}
+
+	sessions, err := listSessions(ctx, instanceID)
+	if err != nil {
+		return nil, err
+	}
+
+	startedAt, err := txTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	session := &Session{
+		InstanceID: instanceID,
+		Number:     uint32(len(sessions)) + 1,
+		StartedAt:  startedAt.Format(time.RFC3339),
+	}
+	err = putSession(ctx, session)
+	if err != nil {
+		return nil, err
+	}
+
+	instance.ActiveSession = session.Number
+	instanceBytes, err := json.Marshal(instance)
+	if err != nil {
+		return nil, err
+	}
+
+	err = putState(ctx, docInstance, instanceID, instanceBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	return nil, emit(ctx, SessionStartedEvent, session)
+}
+
+// StopSession closes the open session of an instance owned by the submitting
+// client and adds its length to the used time of the instance. A session
+// running over the usage quota of the lab is still recorded, see addUsage.
+func (t *InstanceContract) StopSession(ctx contractapi.TransactionContextInterface, instanceID string) error {
+	instance, err := t.readOwnedInstance(ctx, instanceID)
+	if err != nil {
+		return err
+	}
+
+	if instance.ActiveSession == 0 {
+		return fmt.Errorf("instance %s has no open session", instanceID)
+	}
+
+	session, err := readSession(ctx, instanceID, instance.ActiveSession)
+	if err != nil {
+		return err
+	}
+
+	startedAt, err := time.Parse(time.RFC3339, session.StartedAt)
+	if err != nil {
+		return err
+	}
+	stoppedAt, err := txTime(ctx)
+	if err != nil {
+		return err
+	}
+
+	session.StoppedAt = stoppedAt.Format(time.RFC3339)
+	session.Seconds = seconds(startedAt, stoppedAt)
+	err = putSession(ctx, session)
+	if err != nil {
+		return err
+	}
+
+	lab, err := readLab(ctx, instance.LabID)
+	if err != nil {
+		return err
+	}
+
+	exceeded, err := addUsage(ctx, lab, instance.Owner, instanceID, int64(session.Seconds))
+	if err != nil {
+		return err
+	}
+
+	instance.ActiveSession = 0
+	instance.UsedTime += session.Seconds
+	instanceBytes, err := json.Marshal(instance)
+	if err != nil {
+		return err
+	}
+
+	err = putState(ctx, docInstance, instanceID, instanceBytes)
+	if err != nil {
+		return err
+	}
+
+	return emit(ctx, SessionStoppedEvent, &SessionUsageEvent{Session: session, QuotaExceeded: exceeded})
+}
+
+// ListSessions returns the sessions of an instance, first session first
+func (t *InstanceContract) ListSessions(ctx contractapi.TransactionContextInterface, instanceID string) ([]*Session, error) {
+	return listSessions(ctx, instanceID)
+}
+
+// GetUsageReport returns the usage of the labs of a class per student, as
+// counted against the usage quota. labID and owner narrow the report down
+// when not empty. Only admins and the owner of the class read the usage of
+// every student, other staff are refused and students only get a report of
+// their own usage.
+func (t *InstanceContract) GetUsageReport(ctx contractapi.TransactionContextInterface, classID, labID, owner string) (*UsageReport, error) {
+	clientID, err := t.GetSubmittingClientIdentity(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	admin, err := auth.HasRole(ctx, auth.Admin)
+	if err != nil {
+		return nil, err
+	}
+	staff, err := auth.HasRole(ctx, auth.Staff...)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case admin:
+	case staff:
+		err = assertClassOwner(ctx, clientID, classID, "read usage report")
+		if err != nil {
+			return nil, err
+		}
+	default:
+		owner = clientID
+	}
+
+	labIDs := []string{labID}
+	if labID == "" {
+		labIDs, err = listIndex(ctx, labClassIndex, classID)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		lab, err := readLab(ctx, labID)
+		if err != nil {
+			return nil, err
+		}
+		if lab.ClassID != classID {
+			return nil, fmt.Errorf("lab %s does not belong to class %s", labID, classID)
+		}
+	}
+
+	now, err := txTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &UsageReport{
+		ClassID:     classID,
+		GeneratedAt: now.Format(time.RFC3339),
+		Entries:     []*UsageEntry{},
+	}
+	for _, labID := range labIDs {
+		entries, err := listUsage(ctx, classID, labID, owner)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, entry := range entries {
+			report.Entries = append(report.Entries, entry)
+			report.TotalSeconds += entry.Seconds
+		}
+	}
+
+	sort.Slice(report.Entries, func(i, j int) bool {
+		if report.Entries[i].Owner != report.Entries[j].Owner {
+			return report.Entries[i].Owner < report.Entries[j].Owner
+		}
+		return report.Entries[i].LabID < report.Entries[j].LabID
+	})
+
+	return report, nil
+}
+
+// listUsage reads the usage index entries of a lab, only the one of owner
+// when it is not empty.
+func listUsage(ctx contractapi.TransactionContextInterface, classID, labID, owner string) ([]*UsageEntry, error) {
+	attributes := []string{labID}
+	if owner != "" {
+		attributes = append(attributes, owner)
+	}
+
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(usageIndex, attributes)
+	if err != nil {
+		return nil, err
+	}
+	defer resultsIterator.Close()
+
+	var entries []*UsageEntry
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		_, attributes, err := ctx.GetStub().SplitCompositeKey(queryResponse.Key)
+		if err != nil {
+			return nil, err
+		}
+		used, err := strconv.ParseUint(string(queryResponse.Value), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid usage of %s for lab %s: %v", attributes[1], labID, err)
+		}
+
+		entries = append(entries, &UsageEntry{ClassID: classID, LabID: labID, Owner: attributes[1], Seconds: used})
+	}
+
+	return entries, nil
+}
+
+// readOwnedInstance reads an instance and checks the submitting client owns it
+func (t *InstanceContract) readOwnedInstance(ctx contractapi.TransactionContextInterface, instanceID string) (*Instance, error) {
+	instance, err := t.ReadInstance(ctx, instanceID)
+	if err != nil {
+		return nil, err
+	}
+
+	clientID, err := t.GetSubmittingClientIdentity(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if clientID != instance.Owner {
+		return nil, fmt.Errorf("submitting client not authorized to use instance, does not own instance")
+	}
+
+	return instance, nil
+}
+
+// listSessions reads the sessions of an instance, first session first
+func listSessions(ctx contractapi.TransactionContextInterface, instanceID string) ([]*Session, error) {
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(sessionIndex, []string{instanceID})
+	if err != nil {
+		return nil, err
+	}
+	defer resultsIterator.Close()
+
+	sessions := []*Session{}
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		var session Session
+		err = json.Unmarshal(queryResponse.Value, &session)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, &session)
+	}
+
+	return sessions, nil
+}
+
+// deleteSessions removes the sessions of an instance, so that an instance
+// created again under its ID starts without sessions
+func deleteSessions(ctx contractapi.TransactionContextInterface, instanceID string) error {
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(sessionIndex, []string{instanceID})
+	if err != nil {
+		return err
+	}
+	defer resultsIterator.Close()
+
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return err
+		}
+
+		err = ctx.GetStub().DelState(queryResponse.Key)
+		if err != nil {
+			return fmt.Errorf("failed to delete session of instance %s: %v", instanceID, err)
+		}
+	}
+
+	return nil
+}
+
+// readSession reads one session of an instance
+func readSession(ctx contractapi.TransactionContextInterface, instanceID string, number uint32) (*Session, error) {
+	sessionKey, err := sessionKey(ctx, instanceID, number)
+	if err != nil {
+		return nil, err
+	}
+
+	sessionBytes, err := ctx.GetStub().GetState(sessionKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read session %d of instance %s: %v", number, instanceID, err)
+	}
+	if sessionBytes == nil {
+		return nil, fmt.Errorf("session %d of instance %s does not exist", number, instanceID)
+	}
+
+	var session Session
+	err = json.Unmarshal(sessionBytes, &session)
+	if err != nil {
+		return nil, err
+	}
+
+	return &session, nil
+}
+
+// putSession writes a session to the session index
+func putSession(ctx contractapi.TransactionContextInterface, session *Session) error {
+	sessionKey, err := sessionKey(ctx, session.InstanceID, session.Number)
+	if err != nil {
+		return err
+	}
+
+	sessionBytes, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+
+	return ctx.GetStub().PutState(sessionKey, sessionBytes)
+}
+
+// sessionKey returns the session index key of one session
+func sessionKey(ctx contractapi.TransactionContextInterface, instanceID string, number uint32) (string, error) {
+	return ctx.GetStub().CreateCompositeKey(sessionIndex, []string{instanceID, fmt.Sprintf("%06d", number)})
+}
+
+// seconds returns the whole seconds from start to end, 0 if end is earlier
+func seconds(start, end time.Time) uint64 {
+	if !end.After(start) {
+		return 0
+	}
+	return uint64(end.Sub(start) / time.Second)
+}
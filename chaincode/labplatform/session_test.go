@@ -0,0 +1,117 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// runSession runs a session of d on instance1
+func runSession(p *platform, d time.Duration) {
+	p.t.Helper()
+
+	p.must(p.call(student, p.instance, "StartSession", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := p.instance.StartSession(ctx, "instance1")
+		return err
+	}))
+	p.ledger.Advance(d)
+	p.must(p.call(student, p.instance, "StopSession", func(ctx contractapi.TransactionContextInterface) error {
+		return p.instance.StopSession(ctx, "instance1")
+	}))
+}
+
+func TestDeleteInstanceRemovesSessions(t *testing.T) {
+	p := newClassroom(t)
+	createInstance(p)
+	runSession(p, time.Hour)
+
+	p.must(p.call(student, p.instance, "DeleteInstance", func(ctx contractapi.TransactionContextInterface) error {
+		return p.instance.DeleteInstance(ctx, "instance1")
+	}))
+	if keys := p.ledger.CompositeKeys(sessionIndex); len(keys) != 0 {
+		t.Fatalf("%s entries left: %v", sessionIndex, keys)
+	}
+
+	createInstance(p)
+	runSession(p, time.Minute)
+
+	var sessions []*Session
+	p.must(p.call(instructor, p.instance, "ListSessions", func(ctx contractapi.TransactionContextInterface) (err error) {
+		sessions, err = p.instance.ListSessions(ctx, "instance1")
+		return err
+	}))
+	if len(sessions) != 1 || sessions[0].Number != 1 || sessions[0].Seconds != 60 {
+		t.Errorf("recreated instance has sessions %+v", sessions)
+	}
+}
+
+func TestDeleteLabRemovesOpenSessions(t *testing.T) {
+	p := newClassroom(t)
+	createInstance(p)
+	p.must(p.call(student, p.instance, "StartSession", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := p.instance.StartSession(ctx, "instance1")
+		return err
+	}))
+	p.ledger.Advance(time.Hour)
+
+	p.must(p.call(instructor, p.lab, "DeleteLab", func(ctx contractapi.TransactionContextInterface) error {
+		return p.lab.DeleteLab(ctx, "lab1", DeleteCascade)
+	}))
+	if keys := p.ledger.CompositeKeys(sessionIndex); len(keys) != 0 {
+		t.Errorf("%s entries left: %v", sessionIndex, keys)
+	}
+	if p.document(docInstance, "instance1", nil) {
+		t.Error("instance of the deleted lab is left")
+	}
+}
+
+func TestUsageReportOutlivesInstances(t *testing.T) {
+	p := newClassroom(t)
+	createInstance(p)
+	runSession(p, time.Hour)
+	p.must(p.call(student, p.instance, "DeleteInstance", func(ctx contractapi.TransactionContextInterface) error {
+		return p.instance.DeleteInstance(ctx, "instance1")
+	}))
+	createInstance(p)
+	runSession(p, time.Minute)
+
+	want := []*UsageEntry{{ClassID: "class1", LabID: "lab1", Owner: student.String(), Seconds: 3660}}
+	for _, filter := range [][2]string{{"", ""}, {"lab1", ""}, {"", student.String()}, {"lab1", student.String()}} {
+		var report *UsageReport
+		p.must(p.call(instructor, p.instance, "GetUsageReport", func(ctx contractapi.TransactionContextInterface) (err error) {
+			report, err = p.instance.GetUsageReport(ctx, "class1", filter[0], filter[1])
+			return err
+		}))
+		if !reflect.DeepEqual(report.Entries, want) || report.TotalSeconds != 3660 {
+			t.Errorf("report filtered by %v: got %+v", filter, report)
+		}
+	}
+
+	var report *UsageReport
+	p.must(p.call(otherStudent, p.instance, "GetUsageReport", func(ctx contractapi.TransactionContextInterface) (err error) {
+		report, err = p.instance.GetUsageReport(ctx, "class1", "", student.String())
+		return err
+	}))
+	if len(report.Entries) != 0 {
+		t.Errorf("another student got the usage %+v", report.Entries)
+	}
+
+	err := p.call(otherInstructor, p.instance, "GetUsageReport", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := p.instance.GetUsageReport(ctx, "class1", "", "")
+		return err
+	})
+	if err == nil || !strings.Contains(err.Error(), "does not own class") {
+		t.Errorf("got %v reading the usage report of another class", err)
+	}
+
+	p.must(p.call(admin, p.instance, "GetUsageReport", func(ctx contractapi.TransactionContextInterface) (err error) {
+		report, err = p.instance.GetUsageReport(ctx, "class1", "", "")
+		return err
+	}))
+	if !reflect.DeepEqual(report.Entries, want) {
+		t.Errorf("admin got the usage %+v", report.Entries)
+	}
+}
@@ -0,0 +1,535 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// SubmissionContract manages the work students hand in for labs, its
+// attempts and its grades
+type SubmissionContract struct {
+	contractapi.Contract
+}
+
+// Submission is the work a student handed in for a lab. The work itself is
+// stored off-chain, the reference to it is private data of the class org,
+// see SubmissionContent. Attempt counts the submissions of the owner for the
+// lab, starting at 1. SubmittedAt is the RFC 3339 transaction timestamp,
+// Late is set for submissions accepted after the lab end time. Collection is
+// the private data collection keeping the content and grade.
+type Submission struct {
+	DocType     string `json:"docType"` //docType is used to distinguish the various types of objects in state database
+	ID          string `json:"ID"`
+	ClassID     string `json:"classID"`
+	LabID       string `json:"labID"`
+	Owner       string `json:"owner"`
+	Attempt     uint32 `json:"attempt"`
+	SubmittedAt string `json:"submittedAt"`
+	Late        bool   `json:"late"`
+	Collection  string `json:"collection"`
+	Grade       *Grade `json:"grade,omitempty" metadata:",optional"`
+}
+
+// SubmissionContent is the private part of a submission
+type SubmissionContent struct {
+	Artifact *Artifact `json:"artifact"`
+}
+
+// Indexes of submissions by lab, class and owner
+const (
+	submissionLabIndex   = "labID~submissionID"
+	submissionClassIndex = "classID~submissionID"
+	submissionOwnerIndex = "owner~submissionID"
+)
+
+// PaginatedSubmissionResult is a page of submissions and the bookmark of the next
+// page, empty after the last page
+type PaginatedSubmissionResult struct {
+	Submissions         []*Submission `json:"records"`
+	FetchedRecordsCount int32         `json:"fetchedRecordsCount"`
+	Bookmark            string        `json:"bookmark"`
+}
+
+// CreateAsset initializes a new asset in the ledger. The submission becomes
+// the next attempt of owner for the lab. An empty owner is the submitting
+// client, staff owning the class may hand in work for the students of the
+// class instead. The Artifact is passed as "artifact" in the transient map.
+func (t *SubmissionContract) CreateSubmission(ctx contractapi.TransactionContextInterface, submissionID, labID, classID, owner string) error {
+	clientID, err := t.GetSubmittingClientIdentity(ctx)
+	if err != nil {
+		return err
+	}
+
+	owner, err = ownerFor(ctx, clientID, classID, owner)
+	if err != nil {
+		return err
+	}
+
+	var artifact Artifact
+	err = readTransient(ctx, "artifact", &artifact)
+	if err != nil {
+		return err
+	}
+
+	submission, err := t.createSubmission(ctx, submissionID, labID, classID, &artifact, owner)
+	if err != nil {
+		return err
+	}
+
+	return emit(ctx, SubmissionCreatedEvent, submission)
+}
+
+func (t *SubmissionContract) createSubmission(ctx contractapi.TransactionContextInterface, submissionID, labID, classID string, artifact *Artifact, owner string) (*Submission, error) {
+	err := artifact.validate()
+	if err != nil {
+		return nil, err
+	}
+
+	exists, err := t.SubmissionExists(ctx, submissionID)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to get submission: %v", err)
+	}
+	if exists {
+		return nil, fmt.Errorf("submission already exists: %s", submissionID)
+	}
+
+	err = assertEnrolled(ctx, classID, owner)
+	if err != nil {
+		return nil, err
+	}
+
+	lab, err := readLab(ctx, labID)
+	if err != nil {
+		return nil, err
+	}
+	if lab.ClassID != classID {
+		return nil, fmt.Errorf("lab %s does not belong to class %s", labID, classID)
+	}
+
+	collection, err := classCollection(ctx, classID)
+	if err != nil {
+		return nil, err
+	}
+
+	submittedAt, err := txTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	late, err := checkWindow(lab, submittedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	count, err := attemptCount(ctx, labID, owner)
+	if err != nil {
+		return nil, err
+	}
+	if lab.MaxAttempts > 0 && count >= lab.MaxAttempts {
+		return nil, fmt.Errorf("%s has used all %d attempts for lab %s", owner, lab.MaxAttempts, labID)
+	}
+
+	submission := &Submission{
+		DocType:     docSubmission,
+		ID:          submissionID,
+		ClassID:     classID,
+		LabID:       labID,
+		Owner:       owner,
+		Attempt:     count + 1,
+		SubmittedAt: submittedAt.Format(time.RFC3339),
+		Late:        late,
+		Collection:  collection,
+	}
+	SubmissionBytes, err := json.Marshal(submission)
+	if err != nil {
+		return nil, err
+	}
+
+	err = putState(ctx, docSubmission, submissionID, SubmissionBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	contentBytes, err := json.Marshal(&SubmissionContent{Artifact: artifact})
+	if err != nil {
+		return nil, err
+	}
+	err = putPrivate(ctx, collection, docContent, submissionID, contentBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	// Index the submission by lab, class and owner. Only the key is needed, the
+	// value is a null character since a nil value would delete the key.
+	labIndexKey, err := ctx.GetStub().CreateCompositeKey(submissionLabIndex, []string{submission.LabID, submission.ID})
+	if err != nil {
+		return nil, err
+	}
+	value := []byte{0x00}
+	err = ctx.GetStub().PutState(labIndexKey, value)
+	if err != nil {
+		return nil, err
+	}
+
+	classIndexKey, err := ctx.GetStub().CreateCompositeKey(submissionClassIndex, []string{submission.ClassID, submission.ID})
+	if err != nil {
+		return nil, err
+	}
+	value = []byte{0x00}
+	err = ctx.GetStub().PutState(classIndexKey, value)
+	if err != nil {
+		return nil, err
+	}
+
+	ownerIndexKey, err := ctx.GetStub().CreateCompositeKey(submissionOwnerIndex, []string{submission.Owner, submission.ID})
+	if err != nil {
+		return nil, err
+	}
+	value = []byte{0x00}
+	err = ctx.GetStub().PutState(ownerIndexKey, value)
+	if err != nil {
+		return nil, err
+	}
+
+	// The attempt index points at the submission ID, so it is the only index
+	// entry with a value
+	attemptIndexKey, err := attemptKey(ctx, submission.LabID, submission.Owner, submission.Attempt)
+	if err != nil {
+		return nil, err
+	}
+	err = ctx.GetStub().PutState(attemptIndexKey, []byte(submission.ID))
+	if err != nil {
+		return nil, err
+	}
+
+	err = putAttemptCount(ctx, submission.LabID, submission.Owner, submission.Attempt)
+	if err != nil {
+		return nil, err
+	}
+
+	return submission, nil
+}
+
+// AssetExists returns true when asset with given ID exists in the ledger.
+func (t *SubmissionContract) SubmissionExists(ctx contractapi.TransactionContextInterface, submissionID string) (bool, error) {
+	submissionBytes, err := getState(ctx, docSubmission, submissionID)
+	if err != nil {
+		return false, fmt.Errorf("failed to read lab %s from world state. %v", submissionID, err)
+	}
+
+	return submissionBytes != nil, nil
+}
+
+// ReadSubmission retrieves a submission from the ledger. Students can only read
+// their own submissions and don't see the grade before it is released.
+func (t *SubmissionContract) ReadSubmission(ctx contractapi.TransactionContextInterface, submissionID string) (*Submission, error) {
+	submission, err := readSubmission(ctx, submissionID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = t.hideUnreleasedGrade(ctx, submission)
+	if err != nil {
+		return nil, err
+	}
+
+	return submission, nil
+}
+
+// GetSubmissionContent returns the private content of a submission. It is
+// only readable on peers of the org of the class, by staff and the owner.
+func (t *SubmissionContract) GetSubmissionContent(ctx contractapi.TransactionContextInterface, submissionID string) (*SubmissionContent, error) {
+	submission, err := t.ReadSubmission(ctx, submissionID)
+	if err != nil {
+		return nil, err
+	}
+
+	contentBytes, err := getPrivate(ctx, submission.Collection, docContent, submissionID)
+	if err != nil {
+		return nil, err
+	}
+	if contentBytes == nil {
+		return nil, fmt.Errorf("submission %s has no private content in collection %s", submissionID, submission.Collection)
+	}
+
+	var content SubmissionContent
+	err = json.Unmarshal(contentBytes, &content)
+	if err != nil {
+		return nil, err
+	}
+
+	return &content, nil
+}
+
+// readSubmission retrieves a submission from the ledger without checking the caller
+func readSubmission(ctx contractapi.TransactionContextInterface, submissionID string) (*Submission, error) {
+	submissionBytes, err := getState(ctx, docSubmission, submissionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get asset %s: %v", submissionID, err)
+	}
+	if submissionBytes == nil {
+		return nil, fmt.Errorf("submission %s does not exist", submissionID)
+	}
+
+	var submission Submission
+	err = json.Unmarshal(submissionBytes, &submission)
+	if err != nil {
+		return nil, err
+	}
+
+	return &submission, nil
+}
+
+// DeleteSubmission removes a submission from the ledger. Only the owner of
+// the class of the submission may delete it.
+func (t *SubmissionContract) DeleteSubmission(ctx contractapi.TransactionContextInterface, submissionID string) error {
+	submission, err := readSubmission(ctx, submissionID)
+	if err != nil {
+		return err
+	}
+
+	clientID, err := t.GetSubmittingClientIdentity(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = assertClassOwner(ctx, clientID, submission.ClassID, "delete submission")
+	if err != nil {
+		return err
+	}
+
+	err = deleteSubmission(ctx, submission)
+	if err != nil {
+		return err
+	}
+
+	return emit(ctx, SubmissionDeletedEvent, &DeleteEvent{ID: submissionID})
+}
+
+// deleteSubmission removes a submission and its index entries from the ledger
+func deleteSubmission(ctx contractapi.TransactionContextInterface, submission *Submission) error {
+	err := delState(ctx, docSubmission, submission.ID)
+	if err != nil {
+		return fmt.Errorf("failed to delete asset %s: %v", submission.ID, err)
+	}
+
+	err = delPrivate(ctx, submission.Collection, docContent, submission.ID)
+	if err != nil {
+		return err
+	}
+	err = delPrivate(ctx, submission.Collection, docGrade, submission.ID)
+	if err != nil {
+		return err
+	}
+
+	labIndexKey, err := ctx.GetStub().CreateCompositeKey(submissionLabIndex, []string{submission.LabID, submission.ID})
+	if err != nil {
+		return err
+	}
+
+	err = ctx.GetStub().DelState(labIndexKey)
+
+	if err != nil {
+		return err
+	}
+
+	classIndexKey, err := ctx.GetStub().CreateCompositeKey(submissionClassIndex, []string{submission.ClassID, submission.ID})
+	if err != nil {
+		return err
+	}
+
+	// Delete index entry
+	err = ctx.GetStub().DelState(classIndexKey)
+	if err != nil {
+		return err
+	}
+
+	ownerIndexKey, err := ctx.GetStub().CreateCompositeKey(submissionOwnerIndex, []string{submission.Owner, submission.ID})
+	if err != nil {
+		return err
+	}
+
+	// Delete index entry
+	err = ctx.GetStub().DelState(ownerIndexKey)
+	if err != nil {
+		return err
+	}
+
+	attemptIndexKey, err := attemptKey(ctx, submission.LabID, submission.Owner, submission.Attempt)
+	if err != nil {
+		return err
+	}
+
+	return ctx.GetStub().DelState(attemptIndexKey)
+}
+
+// UpdateSubmissionScore grades a submission with a bare score instead of the
+// rubric of its lab. The GradeDetails are passed as "grade" in the transient
+// map without scores, the grader and time are recorded like by
+// GradeSubmission.
+func (t *SubmissionContract) UpdateSubmissionScore(ctx contractapi.TransactionContextInterface, submissionID string) error {
+	var details GradeDetails
+	err := readTransient(ctx, "grade", &details)
+	if err != nil {
+		return err
+	}
+	if len(details.Scores) > 0 {
+		return fmt.Errorf("a bare score has no criterion scores, use GradeSubmission to grade by rubric")
+	}
+
+	submission, err := readSubmission(ctx, submissionID)
+	if err != nil {
+		return err
+	}
+
+	err = t.putGrade(ctx, submission, &details)
+	if err != nil {
+		return err
+	}
+
+	return emit(ctx, SubmissionGradedEvent, newGradeEvent(submission))
+}
+
+// GetSubmissionByRange returns a page of the submissions with an ID in [startKey,
+// endKey). Paginated queries are only valid for read only transactions.
+func (t *SubmissionContract) GetSubmissionByRange(ctx contractapi.TransactionContextInterface, startKey, endKey string, pageSize int, bookmark string) (*PaginatedSubmissionResult, error) {
+	values, nextBookmark, err := listStatePage(ctx, docSubmission, startKey, endKey, pageSize, bookmark)
+	if err != nil {
+		return nil, err
+	}
+
+	return submissionPage(values, nextBookmark)
+}
+
+func (t *SubmissionContract) QuerySubmissionByClass(ctx contractapi.TransactionContextInterface, class string, pageSize int, bookmark string) (*PaginatedSubmissionResult, error) {
+	return querySubmissionPage(ctx, newSelector(docSubmission).eq("classID", class).String(), pageSize, bookmark)
+}
+
+func (t *SubmissionContract) QuerySubmissionByLab(ctx contractapi.TransactionContextInterface, lab string, pageSize int, bookmark string) (*PaginatedSubmissionResult, error) {
+	return querySubmissionPage(ctx, newSelector(docSubmission).eq("labID", lab).String(), pageSize, bookmark)
+}
+
+func (t *SubmissionContract) QuerySubmissionByOwner(ctx contractapi.TransactionContextInterface, owner string, pageSize int, bookmark string) (*PaginatedSubmissionResult, error) {
+	return querySubmissionPage(ctx, newSelector(docSubmission).eq("owner", owner).String(), pageSize, bookmark)
+}
+
+// querySubmissionPage returns a page of the submissions matching a CouchDB query
+func querySubmissionPage(ctx contractapi.TransactionContextInterface, queryString string, pageSize int, bookmark string) (*PaginatedSubmissionResult, error) {
+	values, nextBookmark, err := queryPage(ctx, queryString, pageSize, bookmark)
+	if err != nil {
+		return nil, err
+	}
+
+	return submissionPage(values, nextBookmark)
+}
+
+// submissionPage decodes a page of submissions
+func submissionPage(values [][]byte, bookmark string) (*PaginatedSubmissionResult, error) {
+	submissions := []*Submission{}
+	for _, value := range values {
+		var submission Submission
+		err := json.Unmarshal(value, &submission)
+		if err != nil {
+			return nil, err
+		}
+		submissions = append(submissions, &submission)
+	}
+
+	return &PaginatedSubmissionResult{
+		Submissions:         submissions,
+		FetchedRecordsCount: int32(len(submissions)),
+		Bookmark:            bookmark,
+	}, nil
+}
+
+// GetSubmissionsByClass returns a page of the submissions of a class, read
+// through the classID~submissionID index. Unlike QuerySubmissionByClass it
+// works on LevelDB too.
+func (t *SubmissionContract) GetSubmissionsByClass(ctx contractapi.TransactionContextInterface, classID string, pageSize int, bookmark string) (*PaginatedSubmissionResult, error) {
+	return getSubmissionsByIndex(ctx, submissionClassIndex, classID, pageSize, bookmark)
+}
+
+// GetSubmissionsByLab returns a page of the submissions of a lab, read
+// through the labID~submissionID index. Unlike QuerySubmissionByLab it works
+// on LevelDB too.
+func (t *SubmissionContract) GetSubmissionsByLab(ctx contractapi.TransactionContextInterface, labID string, pageSize int, bookmark string) (*PaginatedSubmissionResult, error) {
+	return getSubmissionsByIndex(ctx, submissionLabIndex, labID, pageSize, bookmark)
+}
+
+// GetSubmissionsByOwner returns a page of the submissions of an owner, read
+// through the owner~submissionID index. Unlike QuerySubmissionByOwner it works
+// on LevelDB too.
+func (t *SubmissionContract) GetSubmissionsByOwner(ctx contractapi.TransactionContextInterface, owner string, pageSize int, bookmark string) (*PaginatedSubmissionResult, error) {
+	return getSubmissionsByIndex(ctx, submissionOwnerIndex, owner, pageSize, bookmark)
+}
+
+// getSubmissionsByIndex returns a page of the submissions whose index
+// entries start with key
+func getSubmissionsByIndex(ctx contractapi.TransactionContextInterface, index, key string, pageSize int, bookmark string) (*PaginatedSubmissionResult, error) {
+	ids, nextBookmark, err := listIndexPage(ctx, index, key, pageSize, bookmark)
+	if err != nil {
+		return nil, err
+	}
+
+	submissions := []*Submission{}
+	for _, id := range ids {
+		submission, err := readSubmission(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		submissions = append(submissions, submission)
+	}
+
+	return &PaginatedSubmissionResult{
+		Submissions:         submissions,
+		FetchedRecordsCount: int32(len(submissions)),
+		Bookmark:            nextBookmark,
+	}, nil
+}
+
+// GetSubmittingClientIdentity returns the name and issuer of the identity that
+// invokes the smart contract. This function base64 decodes the identity string
+// before returning the value to the client or smart contract.
+func (t *SubmissionContract) GetSubmittingClientIdentity(ctx contractapi.TransactionContextInterface) (string, error) {
+
+	b64ID, err := ctx.GetClientIdentity().GetID()
+	if err != nil {
+		return "", fmt.Errorf("Failed to read clientID: %v", err)
+	}
+	decodeID, err := base64.StdEncoding.DecodeString(b64ID)
+	if err != nil {
+		return "", fmt.Errorf("failed to base64 decode clientID: %v", err)
+	}
+	return string(decodeID), nil
+}
+
+// InitLedger creates the initial set of assets in the ledger.
+func (t *SubmissionContract) InitLedger(ctx contractapi.TransactionContextInterface) error {
+	// Attempts are counted from committed state, so every submission of this
+	// transaction is for a different lab and owner.
+	artifact := &Artifact{
+		SHA256:    "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08",
+		Size:      4,
+		MediaType: "text/plain",
+		URI:       "file:///var/labplatform/artifacts/sha256/9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08",
+	}
+	submissions := []Submission{
+		{ID: "submission1", ClassID: "class1", LabID: "lab1", Owner: "Tom"},
+		{ID: "submission2", ClassID: "class1", LabID: "lab2", Owner: "Tom"},
+		{ID: "submission3", ClassID: "class1", LabID: "lab2", Owner: "Sam"},
+	}
+
+	for _, submission := range submissions {
+		_, err := t.createSubmission(ctx, submission.ID, submission.LabID, submission.ClassID, artifact, submission.Owner)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
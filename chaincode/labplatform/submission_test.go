@@ -0,0 +1,422 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+
+	"ledgertest"
+)
+
+var testArtifact = &Artifact{
+	SHA256:    "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08",
+	Size:      4,
+	MediaType: "text/plain",
+	URI:       "https://artifacts.example.com/9f86d081",
+}
+
+// submitAttempt hands in an attempt of student for lab1 and returns it
+func submitAttempt(p *platform) *Submission {
+	p.t.Helper()
+
+	var submission *Submission
+	p.must(p.call(student, p.submission, "SubmitAttempt", func(ctx contractapi.TransactionContextInterface) (err error) {
+		submission, err = p.submission.SubmitAttempt(ctx, "lab1", "class1")
+		return err
+	}, transient("artifact", testArtifact)))
+
+	return submission
+}
+
+func TestSubmitAttempt(t *testing.T) {
+	p := newClassroom(t)
+
+	first := submitAttempt(p)
+	p.ledger.Advance(time.Hour)
+	second := submitAttempt(p)
+
+	if first.Attempt != 1 || second.Attempt != 2 || first.ID == second.ID {
+		t.Errorf("got attempts %d and %d", first.Attempt, second.Attempt)
+	}
+	if first.Owner != student.String() || first.Collection != "Org1MSPPrivateCollection" || first.Late {
+		t.Errorf("got submission %+v", first)
+	}
+	if !p.document(docSubmission, first.ID, nil) {
+		t.Error("submission was not stored")
+	}
+
+	attemptKey := "\x00" + attemptIndex + "\x00lab1\x00" + student.String() + "\x00" + "000002" + "\x00"
+	if string(p.ledger.GetState(attemptKey)) != second.ID {
+		t.Errorf("attempt index points at %q, want %s", p.ledger.GetState(attemptKey), second.ID)
+	}
+	for _, index := range []string{submissionLabIndex, submissionClassIndex, submissionOwnerIndex} {
+		if keys := p.ledger.CompositeKeys(index); len(keys) != 2 {
+			t.Errorf("%s: got %v, want both attempts", index, keys)
+		}
+	}
+
+	var content *SubmissionContent
+	err := p.call(student, p.submission, "GetSubmissionContent", func(ctx contractapi.TransactionContextInterface) (err error) {
+		content, err = p.submission.GetSubmissionContent(ctx, first.ID)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *content.Artifact != *testArtifact {
+		t.Errorf("got artifact %+v", content.Artifact)
+	}
+	if strings.Contains(string(p.ledger.GetState("\x00"+docSubmission+"\x00"+first.ID+"\x00")), testArtifact.URI) {
+		t.Error("artifact leaked into the world state")
+	}
+}
+
+func TestDeletedAttemptsStayCounted(t *testing.T) {
+	p := newClassroom(t)
+	p.must(p.call(instructor, p.lab, "UpdateLabAttemptPolicy", func(ctx contractapi.TransactionContextInterface) error {
+		return p.lab.UpdateLabAttemptPolicy(ctx, "lab1", 2, GradeLatest)
+	}))
+
+	first := submitAttempt(p)
+	p.must(p.call(instructor, p.submission, "DeleteSubmission", func(ctx contractapi.TransactionContextInterface) error {
+		return p.submission.DeleteSubmission(ctx, first.ID)
+	}))
+
+	p.ledger.Advance(time.Hour)
+	second := submitAttempt(p)
+	if second.Attempt != 2 {
+		t.Errorf("attempt after a deleted one is number %d", second.Attempt)
+	}
+
+	p.ledger.Advance(time.Hour)
+	err := p.call(student, p.submission, "SubmitAttempt", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := p.submission.SubmitAttempt(ctx, "lab1", "class1")
+		return err
+	}, transient("artifact", testArtifact))
+	if err == nil || !strings.Contains(err.Error(), "used all 2 attempts") {
+		t.Errorf("got %v submitting a third attempt after deleting one", err)
+	}
+}
+
+func TestDeleteSubmissionChecksClassOwner(t *testing.T) {
+	p := newClassroom(t)
+	submission := submitAttempt(p)
+
+	del := func(identity *ledgertest.Identity) error {
+		return p.call(identity, p.submission, "DeleteSubmission", func(ctx contractapi.TransactionContextInterface) error {
+			return p.submission.DeleteSubmission(ctx, submission.ID)
+		})
+	}
+
+	if err := del(otherInstructor); err == nil || !strings.Contains(err.Error(), "does not own class") {
+		t.Errorf("got %v deleting as the instructor of another class", err)
+	}
+	if !p.document(docSubmission, submission.ID, nil) {
+		t.Fatal("submission was deleted by the instructor of another class")
+	}
+
+	p.must(del(instructor))
+	if p.document(docSubmission, submission.ID, nil) {
+		t.Error("submission was not deleted")
+	}
+}
+
+func TestGetGradedAttemptPolicies(t *testing.T) {
+	p := newClassroom(t)
+
+	graded := func(policy string) (*Submission, error) {
+		p.must(p.call(instructor, p.lab, "UpdateLabAttemptPolicy", func(ctx contractapi.TransactionContextInterface) error {
+			return p.lab.UpdateLabAttemptPolicy(ctx, "lab1", 0, policy)
+		}))
+
+		var submission *Submission
+		err := p.call(instructor, p.submission, "GetGradedAttempt", func(ctx contractapi.TransactionContextInterface) (err error) {
+			submission, err = p.submission.GetGradedAttempt(ctx, "lab1", student.String())
+			return err
+		})
+		return submission, err
+	}
+
+	var attempts []*Submission
+	for i := 0; i < 4; i++ {
+		p.ledger.Advance(time.Hour)
+		attempts = append(attempts, submitAttempt(p))
+	}
+
+	if _, err := graded(GradeBest); err == nil || !strings.Contains(err.Error(), "no graded attempts") {
+		t.Errorf("got %v for the best attempt before grading", err)
+	}
+
+	// The second attempt is the best, the last one is not graded
+	for i, total := range []uint32{6, 9, 4} {
+		details := &GradeDetails{Salt: "0123456789abcdef", Total: total}
+		p.must(p.call(instructor, p.submission, "UpdateSubmissionScore", func(ctx contractapi.TransactionContextInterface) error {
+			return p.submission.UpdateSubmissionScore(ctx, attempts[i].ID)
+		}, transient("grade", details)))
+	}
+
+	tests := []struct {
+		policy string
+		want   *Submission
+	}{
+		{GradeFirst, attempts[0]},
+		{GradeBest, attempts[1]},
+		{GradeLatest, attempts[3]},
+	}
+	for _, test := range tests {
+		submission, err := graded(test.policy)
+		if err != nil {
+			t.Errorf("%s: got %v", test.policy, err)
+			continue
+		}
+		if submission.ID != test.want.ID {
+			t.Errorf("%s: got attempt %d, want %d", test.policy, submission.Attempt, test.want.Attempt)
+		}
+	}
+}
+
+func TestSubmitAttemptChecks(t *testing.T) {
+	p := newClassroom(t)
+
+	err := p.call(otherStudent, p.submission, "SubmitAttempt", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := p.submission.SubmitAttempt(ctx, "lab1", "class1")
+		return err
+	}, transient("artifact", testArtifact))
+	if err == nil || !strings.Contains(err.Error(), "is not enrolled") {
+		t.Errorf("got %v submitting without enrollment", err)
+	}
+
+	err = p.call(student, p.submission, "SubmitAttempt", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := p.submission.SubmitAttempt(ctx, "lab1", "class1")
+		return err
+	})
+	if err == nil || !strings.Contains(err.Error(), "transient map") {
+		t.Errorf("got %v submitting without an artifact", err)
+	}
+
+	p.ledger.SetTime(time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC))
+	err = p.call(student, p.submission, "SubmitAttempt", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := p.submission.SubmitAttempt(ctx, "lab1", "class1")
+		return err
+	}, transient("artifact", testArtifact))
+	if err == nil {
+		t.Error("accepted a submission after the end time")
+	}
+}
+
+func TestCreateSubmissionOwner(t *testing.T) {
+	p := newClassroom(t)
+
+	err := p.call(student, p.submission, "CreateSubmission", func(ctx contractapi.TransactionContextInterface) error {
+		return p.submission.CreateSubmission(ctx, "submission1", "lab1", "class1", otherStudent.String())
+	}, transient("artifact", testArtifact))
+	if err == nil || !strings.Contains(err.Error(), "not authorized") {
+		t.Errorf("got %v handing in work for another student", err)
+	}
+
+	p.must(p.call(instructor, p.submission, "CreateSubmission", func(ctx contractapi.TransactionContextInterface) error {
+		return p.submission.CreateSubmission(ctx, "submission1", "lab1", "class1", student.String())
+	}, transient("artifact", testArtifact)))
+
+	var submission Submission
+	if !p.document(docSubmission, "submission1", &submission) {
+		t.Fatal("submission was not stored")
+	}
+	if submission.Owner != student.String() || submission.Attempt != 1 {
+		t.Errorf("got submission %+v", submission)
+	}
+}
+
+func TestGradeIsHiddenUntilReleased(t *testing.T) {
+	p := newClassroom(t)
+	p.must(p.call(instructor, p.lab, "SetLabRubric", func(ctx contractapi.TransactionContextInterface) error {
+		return p.lab.SetLabRubric(ctx, "lab1", []Criterion{{Name: "routes", MaxPoints: 10}})
+	}))
+	submission := submitAttempt(p)
+
+	details := &GradeDetails{
+		Salt:     "0123456789abcdef",
+		Scores:   []CriterionScore{{Criterion: "routes", Points: 8}},
+		Feedback: "Missing the default route",
+	}
+	p.must(p.call(instructor, p.submission, "GradeSubmission", func(ctx contractapi.TransactionContextInterface) error {
+		return p.submission.GradeSubmission(ctx, submission.ID)
+	}, transient("grade", details)))
+
+	read := func() (*Submission, error) {
+		var read *Submission
+		err := p.call(student, p.submission, "ReadSubmission", func(ctx contractapi.TransactionContextInterface) (err error) {
+			read, err = p.submission.ReadSubmission(ctx, submission.ID)
+			return err
+		})
+		return read, err
+	}
+	getGrade := func() (*GradeDetails, error) {
+		var grade *GradeDetails
+		err := p.call(student, p.submission, "GetGrade", func(ctx contractapi.TransactionContextInterface) (err error) {
+			grade, err = p.submission.GetGrade(ctx, submission.ID)
+			return err
+		})
+		return grade, err
+	}
+
+	draft, err := read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if draft.Grade != nil {
+		t.Errorf("student sees draft grade %+v", draft.Grade)
+	}
+	if _, err = getGrade(); err == nil {
+		t.Error("student read the draft grade")
+	}
+
+	p.must(p.call(instructor, p.submission, "ReleaseGrade", func(ctx contractapi.TransactionContextInterface) error {
+		return p.submission.ReleaseGrade(ctx, submission.ID)
+	}))
+
+	released, err := read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if released.Grade == nil || released.Grade.State != GradeReleased || released.Grade.Grader != instructor.String() {
+		t.Errorf("got grade %+v after release", released.Grade)
+	}
+	grade, err := getGrade()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if grade.Total != 8 || grade.Salt != details.Salt {
+		t.Errorf("got grade %+v", grade)
+	}
+
+	verify := func(disclosed *GradeDetails) bool {
+		var valid bool
+		p.must(p.call(otherStudent, p.submission, "VerifyGrade", func(ctx contractapi.TransactionContextInterface) (err error) {
+			valid, err = p.submission.VerifyGrade(ctx, submission.ID)
+			return err
+		}, transient("grade", disclosed)))
+		return valid
+	}
+	if !verify(grade) {
+		t.Error("disclosed grade did not verify")
+	}
+	forged := *grade
+	forged.Total = 10
+	if verify(&forged) {
+		t.Error("forged grade verified")
+	}
+}
+
+func TestRegradeStaysDraftUntilReleased(t *testing.T) {
+	p := newClassroom(t)
+	submission := submitAttempt(p)
+
+	score := func(identity *ledgertest.Identity, total uint32) error {
+		details := &GradeDetails{Salt: "0123456789abcdef", Total: total}
+		return p.call(identity, p.submission, "UpdateSubmissionScore", func(ctx contractapi.TransactionContextInterface) error {
+			return p.submission.UpdateSubmissionScore(ctx, submission.ID)
+		}, transient("grade", details))
+	}
+	release := func(identity *ledgertest.Identity) error {
+		return p.call(identity, p.submission, "ReleaseGrade", func(ctx contractapi.TransactionContextInterface) error {
+			return p.submission.ReleaseGrade(ctx, submission.ID)
+		})
+	}
+	state := func() string {
+		var stored Submission
+		p.document(docSubmission, submission.ID, &stored)
+		return stored.Grade.State
+	}
+
+	if err := score(otherInstructor, 5); err == nil || !strings.Contains(err.Error(), "does not own class") {
+		t.Errorf("got %v grading as the instructor of another class", err)
+	}
+
+	p.must(score(instructor, 5))
+	p.must(release(instructor))
+	if state() != GradeReleased {
+		t.Fatalf("grade is %s after release", state())
+	}
+
+	p.must(score(instructor, 7))
+	if state() != GradeDraft {
+		t.Errorf("regrade is %s before release", state())
+	}
+	if err := release(otherInstructor); err == nil || !strings.Contains(err.Error(), "does not own class") {
+		t.Errorf("got %v releasing as the instructor of another class", err)
+	}
+	p.must(release(instructor))
+	if state() != GradeRegraded {
+		t.Errorf("regrade is %s after release", state())
+	}
+}
+
+func TestStudentCannotReadOtherSubmissions(t *testing.T) {
+	p := newClassroom(t)
+	p.must(p.call(instructor, p.class, "EnrollStudent", func(ctx contractapi.TransactionContextInterface) error {
+		return p.class.EnrollStudent(ctx, "class1", otherStudent.String())
+	}))
+	submission := submitAttempt(p)
+
+	err := p.call(otherStudent, p.submission, "ReadSubmission", func(ctx contractapi.TransactionContextInterface) error {
+		_, err := p.submission.ReadSubmission(ctx, submission.ID)
+		return err
+	})
+	if err == nil || !strings.Contains(err.Error(), "not authorized") {
+		t.Errorf("got %v reading the submission of another student", err)
+	}
+}
+
+func TestGetSubmissionsByIndex(t *testing.T) {
+	p := newClassroom(t)
+	first := submitAttempt(p)
+	p.ledger.Advance(time.Hour)
+	second := submitAttempt(p)
+
+	queries := map[string]func(ctx contractapi.TransactionContextInterface) (*PaginatedSubmissionResult, error){
+		"GetSubmissionsByClass": func(ctx contractapi.TransactionContextInterface) (*PaginatedSubmissionResult, error) {
+			return p.submission.GetSubmissionsByClass(ctx, "class1", 0, "")
+		},
+		"GetSubmissionsByLab": func(ctx contractapi.TransactionContextInterface) (*PaginatedSubmissionResult, error) {
+			return p.submission.GetSubmissionsByLab(ctx, "lab1", 0, "")
+		},
+		"GetSubmissionsByOwner": func(ctx contractapi.TransactionContextInterface) (*PaginatedSubmissionResult, error) {
+			return p.submission.GetSubmissionsByOwner(ctx, student.String(), 0, "")
+		},
+	}
+
+	for function, query := range queries {
+		var page *PaginatedSubmissionResult
+		err := p.call(instructor, p.submission, function, func(ctx contractapi.TransactionContextInterface) (err error) {
+			page, err = query(ctx)
+			return err
+		})
+		if err != nil {
+			t.Errorf("%s: %v", function, err)
+			continue
+		}
+
+		ids := make(map[string]bool)
+		for _, submission := range page.Submissions {
+			ids[submission.ID] = true
+		}
+		if len(ids) != 2 || !ids[first.ID] || !ids[second.ID] || page.Bookmark != "" {
+			t.Errorf("%s: got page %+v", function, page)
+		}
+	}
+
+	var page *PaginatedSubmissionResult
+	err := p.call(instructor, p.submission, "GetSubmissionsByOwner", func(ctx contractapi.TransactionContextInterface) (err error) {
+		page, err = p.submission.GetSubmissionsByOwner(ctx, otherStudent.String(), 0, "")
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.Submissions == nil || len(page.Submissions) != 0 {
+		t.Errorf("got submissions %+v of %s", page.Submissions, otherStudent)
+	}
+}
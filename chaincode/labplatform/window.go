@@ -0,0 +1,107 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// Late policies decide what happens to submissions after the lab EndTime.
+// Submissions before StartTime are always rejected.
+const (
+	// LateReject refuses submissions after EndTime
+	LateReject = "reject"
+	// LateAccept accepts submissions after EndTime and flags them late
+	LateAccept = "accept"
+)
+
+// parseWindow parses the RFC 3339 start and end time of a lab and checks that
+// the lab opens before it closes.
+func parseWindow(startTime, endTime string) (time.Time, time.Time, error) {
+	start, err := time.Parse(time.RFC3339, startTime)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("start time %q is not an RFC 3339 timestamp", startTime)
+	}
+	end, err := time.Parse(time.RFC3339, endTime)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("end time %q is not an RFC 3339 timestamp", endTime)
+	}
+	if !start.Before(end) {
+		return time.Time{}, time.Time{}, fmt.Errorf("start time %s is not before end time %s", startTime, endTime)
+	}
+
+	return start, end, nil
+}
+
+// UpdateLabLatePolicy sets what happens to submissions after the end time
+// of the lab, either reject or accept.
+func (t *LabContract) UpdateLabLatePolicy(ctx contractapi.TransactionContextInterface, labID, newPolicy string) error {
+	if newPolicy != LateReject && newPolicy != LateAccept {
+		return fmt.Errorf("unknown late policy %q, expected %s or %s", newPolicy, LateReject, LateAccept)
+	}
+
+	lab, err := readLab(ctx, labID)
+	if err != nil {
+		return err
+	}
+
+	clientID, err := t.GetSubmittingClientIdentity(ctx)
+	if err != nil {
+		return err
+	}
+
+	if clientID != lab.Owner {
+		return fmt.Errorf("submitting client not authorized to update lab, does not own lab")
+	}
+
+	lab.LatePolicy = newPolicy
+	labBytes, err := json.Marshal(lab)
+	if err != nil {
+		return err
+	}
+
+	err = putState(ctx, docLab, labID, labBytes)
+	if err != nil {
+		return err
+	}
+
+	return emit(ctx, LabUpdatedEvent, lab)
+}
+
+// txTime returns the timestamp the client put in the transaction proposal.
+// Every endorser sees the same value, unlike its own clock.
+func txTime(ctx contractapi.TransactionContextInterface) (time.Time, error) {
+	timestamp, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to read transaction timestamp: %v", err)
+	}
+
+	return time.Unix(timestamp.Seconds, int64(timestamp.Nanos)).UTC(), nil
+}
+
+// checkWindow decides whether a submission made at submittedAt is accepted by
+// the lab and whether it is late.
+func checkWindow(lab *Lab, submittedAt time.Time) (bool, error) {
+	start, err := time.Parse(time.RFC3339, lab.StartTime)
+	if err != nil {
+		return false, fmt.Errorf("lab %s has no valid start time: %q is not an RFC 3339 timestamp", lab.ID, lab.StartTime)
+	}
+	end, err := time.Parse(time.RFC3339, lab.EndTime)
+	if err != nil {
+		return false, fmt.Errorf("lab %s has no valid end time: %q is not an RFC 3339 timestamp", lab.ID, lab.EndTime)
+	}
+
+	if submittedAt.Before(start) {
+		return false, fmt.Errorf("lab %s does not accept submissions before %s", lab.ID, lab.StartTime)
+	}
+	if !submittedAt.After(end) {
+		return false, nil
+	}
+
+	if lab.LatePolicy == LateAccept {
+		return true, nil
+	}
+	return false, fmt.Errorf("lab %s closed for submissions at %s", lab.ID, lab.EndTime)
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// submitAt hands in an attempt of student for lab1 at a time
+func submitAt(p *platform, at time.Time) (*Submission, error) {
+	p.ledger.SetTime(at)
+
+	var submission *Submission
+	err := p.call(student, p.submission, "SubmitAttempt", func(ctx contractapi.TransactionContextInterface) (err error) {
+		submission, err = p.submission.SubmitAttempt(ctx, "lab1", "class1")
+		return err
+	}, transient("artifact", testArtifact))
+
+	return submission, err
+}
+
+func TestSubmissionWindow(t *testing.T) {
+	// lab1 is open from 2022-09-01 to 2022-12-01
+	tests := map[string]struct {
+		latePolicy string
+		at         time.Time
+		wantLate   bool
+		wantErr    string
+	}{
+		"before start":         {LateReject, time.Date(2022, 8, 31, 23, 59, 59, 0, time.UTC), false, "does not accept submissions before 2022-09-01T00:00:00Z"},
+		"before start, accept": {LateAccept, time.Date(2022, 8, 31, 23, 59, 59, 0, time.UTC), false, "does not accept submissions before 2022-09-01T00:00:00Z"},
+		"at start":             {LateReject, time.Date(2022, 9, 1, 0, 0, 0, 0, time.UTC), false, ""},
+		"at end":               {LateReject, time.Date(2022, 12, 1, 0, 0, 0, 0, time.UTC), false, ""},
+		"after end, reject":    {LateReject, time.Date(2022, 12, 1, 0, 0, 1, 0, time.UTC), false, "closed for submissions at 2022-12-01T00:00:00Z"},
+		"after end, accept":    {LateAccept, time.Date(2022, 12, 1, 0, 0, 1, 0, time.UTC), true, ""},
+		"on time, accept":      {LateAccept, time.Date(2022, 10, 1, 0, 0, 0, 0, time.UTC), false, ""},
+	}
+
+	for name, test := range tests {
+		p := newClassroom(t)
+		p.must(p.call(instructor, p.lab, "UpdateLabLatePolicy", func(ctx contractapi.TransactionContextInterface) error {
+			return p.lab.UpdateLabLatePolicy(ctx, "lab1", test.latePolicy)
+		}))
+
+		submission, err := submitAt(p, test.at)
+		if test.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+				t.Errorf("%s: got %v, want %q", name, err, test.wantErr)
+			}
+			if keys := p.ledger.CompositeKeys(submissionLabIndex); len(keys) != 0 {
+				t.Errorf("%s: refused submission was stored", name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: got %v", name, err)
+			continue
+		}
+
+		var stored Submission
+		if !p.document(docSubmission, submission.ID, &stored) {
+			t.Fatalf("%s: submission was not stored", name)
+		}
+		if stored.Late != test.wantLate || submission.Late != test.wantLate {
+			t.Errorf("%s: got late %v, want %v", name, stored.Late, test.wantLate)
+		}
+	}
+}
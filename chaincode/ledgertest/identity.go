@@ -0,0 +1,78 @@
+package ledgertest
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"fmt"
+)
+
+// Identity is a client identity with the attributes of its enrollment
+// certificate. It implements cid.ClientIdentity.
+type Identity struct {
+	MSPID      string
+	Subject    string
+	Issuer     string
+	Name       string
+	Attributes map[string]string
+}
+
+// NewIdentity returns an identity of an MSP named name, with subject and
+// issuer shaped like the ones of the Fabric CA of the test network.
+func NewIdentity(mspID, name string, attributes map[string]string) *Identity {
+	if attributes == nil {
+		attributes = map[string]string{}
+	}
+
+	return &Identity{
+		MSPID:      mspID,
+		Subject:    fmt.Sprintf("CN=%s,OU=client", name),
+		Issuer:     fmt.Sprintf("CN=ca.%s", mspID),
+		Name:       name,
+		Attributes: attributes,
+	}
+}
+
+// String returns the identity as chaincode sees it after base64 decoding
+// GetID, x509::<subject>::<issuer>
+func (i *Identity) String() string {
+	return "x509::" + i.Subject + "::" + i.Issuer
+}
+
+// GetID returns the base64 encoded ID of the identity, like cid does
+func (i *Identity) GetID() (string, error) {
+	return base64.StdEncoding.EncodeToString([]byte(i.String())), nil
+}
+
+// GetMSPID returns the MSP ID of the identity
+func (i *Identity) GetMSPID() (string, error) {
+	return i.MSPID, nil
+}
+
+// GetAttributeValue returns the value of a certificate attribute
+func (i *Identity) GetAttributeValue(attrName string) (string, bool, error) {
+	value, found := i.Attributes[attrName]
+	return value, found, nil
+}
+
+// AssertAttributeValue checks that a certificate attribute has a value
+func (i *Identity) AssertAttributeValue(attrName, attrValue string) error {
+	value, found := i.Attributes[attrName]
+	if !found {
+		return fmt.Errorf("attribute '%s' was not found", attrName)
+	}
+	if value != attrValue {
+		return fmt.Errorf("attribute '%s' equals '%s' instead of '%s'", attrName, value, attrValue)
+	}
+
+	return nil
+}
+
+// GetX509Certificate returns a certificate carrying the name of the
+// identity. It is not signed.
+func (i *Identity) GetX509Certificate() (*x509.Certificate, error) {
+	return &x509.Certificate{
+		Subject: pkix.Name{CommonName: i.Name, OrganizationalUnit: []string{"client"}},
+		Issuer:  pkix.Name{CommonName: "ca." + i.MSPID},
+	}, nil
+}
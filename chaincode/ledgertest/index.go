@@ -0,0 +1,299 @@
+package ledgertest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Index is a CouchDB index of a chaincode, as declared in
+// META-INF/statedb/couchdb/indexes
+type Index struct {
+	Name   string
+	DDoc   string
+	Fields []string
+}
+
+// indexDefinition is the JSON format of an index file
+type indexDefinition struct {
+	Index struct {
+		Fields []interface{} `json:"fields"`
+	} `json:"index"`
+	DDoc string `json:"ddoc"`
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// LoadIndexes reads the index definitions of a chaincode from the *.json
+// files of dir, in file name order
+func LoadIndexes(dir string) ([]Index, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no index definitions in %s", dir)
+	}
+	sort.Strings(files)
+
+	var indexes []Index
+	names := make(map[string]string)
+	for _, file := range files {
+		definitionBytes, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+
+		index, err := parseIndex(definitionBytes)
+		if err != nil {
+			return nil, fmt.Errorf("invalid index definition %s: %v", file, err)
+		}
+		if other, ok := names[index.Name]; ok {
+			return nil, fmt.Errorf("index %s is declared in %s and %s", index.Name, other, file)
+		}
+		names[index.Name] = file
+
+		indexes = append(indexes, index)
+	}
+
+	return indexes, nil
+}
+
+// parseIndex parses an index definition. Fields are either names or
+// {name: direction} objects, the direction does not matter to CouchDB.
+func parseIndex(definitionBytes []byte) (Index, error) {
+	var definition indexDefinition
+	decoder := json.NewDecoder(strings.NewReader(string(definitionBytes)))
+	decoder.DisallowUnknownFields()
+	err := decoder.Decode(&definition)
+	if err != nil {
+		return Index{}, err
+	}
+
+	if definition.Name == "" || definition.DDoc == "" {
+		return Index{}, fmt.Errorf("name and ddoc are required")
+	}
+	if definition.Type != "" && definition.Type != "json" {
+		return Index{}, fmt.Errorf("unsupported index type %q", definition.Type)
+	}
+	if len(definition.Index.Fields) == 0 {
+		return Index{}, fmt.Errorf("index %s has no fields", definition.Name)
+	}
+
+	index := Index{Name: definition.Name, DDoc: definition.DDoc}
+	for _, field := range definition.Index.Fields {
+		switch field := field.(type) {
+		case string:
+			index.Fields = append(index.Fields, field)
+		case map[string]interface{}:
+			if len(field) != 1 {
+				return Index{}, fmt.Errorf("index field object must have exactly one field: %v", field)
+			}
+			for name := range field {
+				index.Fields = append(index.Fields, name)
+			}
+		default:
+			return Index{}, fmt.Errorf("invalid index field %v", field)
+		}
+	}
+
+	return index, nil
+}
+
+// RequireIndexes makes rich queries fail unless one of indexes covers them,
+// so that tests catch queries that would scan the whole state database.
+func (l *Ledger) RequireIndexes(indexes ...Index) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.indexes = append(l.indexes, indexes...)
+}
+
+// UnusedIndexes returns the names of the required indexes no rich query was
+// answered with so far, so that tests catch indexes nothing needs
+func (l *Ledger) UnusedIndexes() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var names []string
+	for _, index := range l.indexes {
+		if !l.usedIndexes[index.Name] {
+			names = append(names, index.Name)
+		}
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// CoveringIndex returns the index CouchDB can answer a query with, nil when
+// there is none. Like CouchDB, an index is usable when the selector has a
+// condition on every field of the index and the sort fields are a prefix of
+// the fields of the index; use_index has to name a usable index. On top of
+// that, a query with more conditions than on docType is only covered by an
+// index narrowing on another field, not by a scan of its docType.
+func CoveringIndex(indexes []Index, queryString string) (*Index, error) {
+	var q query
+	err := json.Unmarshal([]byte(queryString), &q)
+	if err != nil {
+		return nil, fmt.Errorf("invalid query %s: %v", queryString, err)
+	}
+
+	sortFields, err := parseSort(q.Sort)
+	if err != nil {
+		return nil, fmt.Errorf("invalid query %s: %v", queryString, err)
+	}
+
+	useIndex, err := parseUseIndex(q.UseIndex)
+	if err != nil {
+		return nil, fmt.Errorf("invalid query %s: %v", queryString, err)
+	}
+
+	fields := selectorFields(q.Selector)
+	narrowed := false
+	for field := range fields {
+		if field != "docType" {
+			narrowed = true
+		}
+	}
+	for name := range q.Selector {
+		if strings.HasPrefix(name, "$") && name != "$and" {
+			narrowed = true
+		}
+	}
+
+	for i := range indexes {
+		index := &indexes[i]
+		if len(useIndex) > 0 && !useIndexNames(useIndex, index) {
+			continue
+		}
+		if usable(index, fields, sortFields, narrowed) {
+			return index, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// usable tells whether an index can answer a selector on fields with a sort
+func usable(index *Index, fields map[string]bool, sortFields []sortField, narrowed bool) bool {
+	indexNarrows := false
+	for _, field := range index.Fields {
+		if !fields[field] {
+			return false
+		}
+		if field != "docType" {
+			indexNarrows = true
+		}
+	}
+	if narrowed && !indexNarrows {
+		return false
+	}
+
+	if len(sortFields) > len(index.Fields) {
+		return false
+	}
+	for i, field := range sortFields {
+		if strings.Join(field.path, ".") != index.Fields[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// selectorFields returns the fields a selector has conditions on. Fields of
+// $and are included, the ones of $or, $nor and $not are not since they don't
+// have to match.
+func selectorFields(selector map[string]interface{}) map[string]bool {
+	fields := make(map[string]bool)
+	for name, value := range selector {
+		if name == "$and" {
+			selectors, _ := value.([]interface{})
+			for _, nested := range selectors {
+				object, _ := nested.(map[string]interface{})
+				for field := range selectorFields(object) {
+					fields[field] = true
+				}
+			}
+			continue
+		}
+		if strings.HasPrefix(name, "$") {
+			continue
+		}
+
+		// nested objects without operators select nested fields
+		if object, ok := value.(map[string]interface{}); ok && !isOperatorObject(object) {
+			for field := range selectorFields(object) {
+				fields[name+"."+field] = true
+			}
+			continue
+		}
+		fields[name] = true
+	}
+
+	return fields
+}
+
+// parseUseIndex parses use_index, either "<ddoc>", ["<ddoc>"] or
+// ["<ddoc>", "<name>"]. The design document may have the _design/ prefix.
+func parseUseIndex(value interface{}) ([]string, error) {
+	switch value := value.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return []string{value}, nil
+	case []interface{}:
+		var names []string
+		for _, item := range value {
+			name, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("invalid use_index %v", value)
+			}
+			names = append(names, name)
+		}
+		if len(names) == 0 || len(names) > 2 {
+			return nil, fmt.Errorf("invalid use_index %v", value)
+		}
+		return names, nil
+	default:
+		return nil, fmt.Errorf("invalid use_index %v", value)
+	}
+}
+
+// useIndexNames tells whether use_index names index
+func useIndexNames(useIndex []string, index *Index) bool {
+	if strings.TrimPrefix(useIndex[0], "_design/") != index.DDoc {
+		return false
+	}
+
+	return len(useIndex) == 1 || useIndex[1] == index.Name
+}
+
+// checkIndexes fails for queries none of the required indexes covers
+func (l *Ledger) checkIndexes(queryString string) error {
+	l.mu.Lock()
+	indexes := append([]Index(nil), l.indexes...)
+	l.mu.Unlock()
+
+	if len(indexes) == 0 {
+		return nil
+	}
+
+	index, err := CoveringIndex(indexes, queryString)
+	if err != nil {
+		return err
+	}
+	if index == nil {
+		return fmt.Errorf("no index covers query %s", queryString)
+	}
+
+	l.mu.Lock()
+	l.usedIndexes[index.Name] = true
+	l.mu.Unlock()
+
+	return nil
+}
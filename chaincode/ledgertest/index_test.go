@@ -0,0 +1,136 @@
+package ledgertest
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func writeIndexes(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	for name, content := range files {
+		err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return dir
+}
+
+func TestLoadIndexes(t *testing.T) {
+	dir := writeIndexes(t, map[string]string{
+		"indexClassID.json": `{"index":{"fields":["docType","classID"]},"ddoc":"indexClassIDDoc","name":"indexClassID","type":"json"}`,
+		"indexName.json":    `{"index":{"fields":[{"docType":"asc"},{"name":"asc"}]},"ddoc":"indexNameDoc","name":"indexName","type":"json"}`,
+		"README.md":         `not an index`,
+	})
+
+	indexes, err := LoadIndexes(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Index{
+		{Name: "indexClassID", DDoc: "indexClassIDDoc", Fields: []string{"docType", "classID"}},
+		{Name: "indexName", DDoc: "indexNameDoc", Fields: []string{"docType", "name"}},
+	}
+	if !reflect.DeepEqual(indexes, want) {
+		t.Errorf("got %+v", indexes)
+	}
+}
+
+func TestLoadIndexesErrors(t *testing.T) {
+	tests := map[string]map[string]string{
+		"duplicate name": {
+			"a.json": `{"index":{"fields":["docType"]},"ddoc":"aDoc","name":"index","type":"json"}`,
+			"b.json": `{"index":{"fields":["docType"]},"ddoc":"bDoc","name":"index","type":"json"}`,
+		},
+		"no fields":    {"a.json": `{"index":{"fields":[]},"ddoc":"aDoc","name":"a","type":"json"}`},
+		"text index":   {"a.json": `{"index":{"fields":["name"]},"ddoc":"aDoc","name":"a","type":"text"}`},
+		"unknown key":  {"a.json": `{"index":{"fields":["name"]},"ddoc":"aDoc","name":"a","partial":true}`},
+		"missing ddoc": {"a.json": `{"index":{"fields":["name"]},"name":"a"}`},
+		"no files":     {},
+	}
+
+	for name, files := range tests {
+		_, err := LoadIndexes(writeIndexes(t, files))
+		if err == nil {
+			t.Errorf("%s: no error", name)
+		}
+	}
+}
+
+func TestCoveringIndex(t *testing.T) {
+	indexes := []Index{
+		{Name: "indexDocType", DDoc: "indexDocTypeDoc", Fields: []string{"docType"}},
+		{Name: "indexClassID", DDoc: "indexClassIDDoc", Fields: []string{"docType", "classID"}},
+		{Name: "indexName", DDoc: "indexNameDoc", Fields: []string{"docType", "name"}},
+	}
+
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{`{"selector":{"docType":"lab"}}`, "indexDocType"},
+		{`{"selector":{"docType":"lab","classID":"c1"}}`, "indexClassID"},
+		{`{"selector":{"docType":"lab","classID":{"$eq":"c1"},"owner":"o"}}`, "indexClassID"},
+		{`{"selector":{"$and":[{"docType":"lab"},{"classID":"c1"}]}}`, "indexClassID"},
+		{`{"selector":{"docType":"lab","owner":"o"}}`, ""},
+		{`{"selector":{"docType":"lab","$or":[{"classID":"c1"},{"classID":"c2"}]}}`, ""},
+		{`{"selector":{"docType":"lab","name":{"$gt":null}},"sort":[{"docType":"desc"},{"name":"desc"}]}`, "indexName"},
+		{`{"selector":{"docType":"lab","name":{"$gt":null}},"sort":[{"name":"asc"}]}`, ""},
+		{`{"selector":{"docType":"lab","classID":"c1"},"use_index":"_design/indexClassIDDoc"}`, "indexClassID"},
+		{`{"selector":{"docType":"lab","classID":"c1"},"use_index":["indexNameDoc","indexName"]}`, ""},
+	}
+
+	for _, test := range tests {
+		index, err := CoveringIndex(indexes, test.query)
+		if err != nil {
+			t.Errorf("%s: %v", test.query, err)
+			continue
+		}
+
+		got := ""
+		if index != nil {
+			got = index.Name
+		}
+		if got != test.want {
+			t.Errorf("%s: got index %q, want %q", test.query, got, test.want)
+		}
+	}
+}
+
+func TestRequireIndexes(t *testing.T) {
+	ledger := queryLedger()
+
+	_, err := ledger.Begin(alice, "Query").GetQueryResult(`{"selector":{"docType":"lab","name":"Routing"}}`)
+	if err != nil {
+		t.Fatalf("queries fail without required indexes: %v", err)
+	}
+
+	ledger.RequireIndexes(
+		Index{Name: "indexClassID", DDoc: "indexClassIDDoc", Fields: []string{"docType", "classID"}},
+		Index{Name: "indexOwner", DDoc: "indexOwnerDoc", Fields: []string{"docType", "owner"}},
+	)
+	if unused := ledger.UnusedIndexes(); !reflect.DeepEqual(unused, []string{"indexClassID", "indexOwner"}) {
+		t.Errorf("got unused indexes %v before any query", unused)
+	}
+
+	_, err = ledger.Begin(alice, "Query").GetQueryResult(`{"selector":{"docType":"lab","classID":"c1"}}`)
+	if err != nil {
+		t.Errorf("covered query failed: %v", err)
+	}
+
+	_, _, err = ledger.Begin(alice, "Query").GetQueryResultWithPagination(`{"selector":{"docType":"lab","name":"Routing"}}`, 10, "")
+	if err == nil || !strings.Contains(err.Error(), "no index covers") {
+		t.Errorf("got %v running a query without index", err)
+	}
+
+	if unused := ledger.UnusedIndexes(); !reflect.DeepEqual(unused, []string{"indexOwner"}) {
+		t.Errorf("got unused indexes %v", unused)
+	}
+}
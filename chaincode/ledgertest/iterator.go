@@ -0,0 +1,70 @@
+package ledgertest
+
+import (
+	"errors"
+
+	"github.com/hyperledger/fabric-protos-go/ledger/queryresult"
+)
+
+// errIteratorDone is returned by Next once every result is consumed
+var errIteratorDone = errors.New("no more results")
+
+// stateIterator iterates over a snapshot of key-values
+type stateIterator struct {
+	kvs    []*queryresult.KV
+	closed bool
+}
+
+func newStateIterator(kvs []*queryresult.KV) *stateIterator {
+	return &stateIterator{kvs: kvs}
+}
+
+// HasNext tells whether there are results left
+func (it *stateIterator) HasNext() bool {
+	return !it.closed && len(it.kvs) > 0
+}
+
+// Next returns the next key-value
+func (it *stateIterator) Next() (*queryresult.KV, error) {
+	if !it.HasNext() {
+		return nil, errIteratorDone
+	}
+	kv := it.kvs[0]
+	it.kvs = it.kvs[1:]
+
+	return kv, nil
+}
+
+// Close releases the iterator
+func (it *stateIterator) Close() error {
+	it.closed = true
+	return nil
+}
+
+// historyIterator iterates over the modifications of a key, newest first
+type historyIterator struct {
+	modifications []*queryresult.KeyModification
+	closed        bool
+}
+
+// HasNext tells whether there are modifications left
+func (it *historyIterator) HasNext() bool {
+	return !it.closed && len(it.modifications) > 0
+}
+
+// Next returns the next modification
+func (it *historyIterator) Next() (*queryresult.KeyModification, error) {
+	if !it.HasNext() {
+		return nil, errIteratorDone
+	}
+	modification := it.modifications[0]
+	it.modifications = it.modifications[1:]
+
+	return modification, nil
+}
+
+// Close releases the iterator
+func (it *historyIterator) Close() error {
+	it.closed = true
+	return nil
+}
@@ -0,0 +1,285 @@
+// Package ledgertest runs chaincode offline against an in-memory ledger. A
+// Tx implements both contractapi.TransactionContextInterface and
+// shim.ChaincodeStubInterface, so transaction functions can be called
+// directly with it.
+//
+// Transactions behave like on a peer: reads see the state committed before
+// the transaction started, never its own writes, and the writes are only
+// applied when the transaction commits. Rich queries support a subset of
+// CouchDB selectors, see GetQueryResult, and can be required to be covered
+// by the CouchDB indexes of the chaincode, see RequireIndexes.
+package ledgertest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/hyperledger/fabric-protos-go/ledger/queryresult"
+)
+
+// Channel is the channel ID transactions run on
+const Channel = "mychannel"
+
+// Event is a chaincode event of a committed transaction
+type Event struct {
+	TxID    string
+	Name    string
+	Payload []byte
+}
+
+// Ledger is the world state, private data and history of one chaincode
+type Ledger struct {
+	mu          sync.Mutex
+	now         time.Time
+	txs         int
+	state       map[string][]byte
+	history     map[string][]*queryresult.KeyModification
+	private     map[string]map[string][]byte
+	collections map[string]bool
+	indexes     []Index
+	usedIndexes map[string]bool
+	events      []Event
+}
+
+// NewLedger returns an empty ledger. Its clock starts at 2022-10-01 12:00
+// UTC and only moves with SetTime and Advance.
+func NewLedger() *Ledger {
+	return &Ledger{
+		now:         time.Date(2022, 10, 1, 12, 0, 0, 0, time.UTC),
+		state:       make(map[string][]byte),
+		history:     make(map[string][]*queryresult.KeyModification),
+		private:     make(map[string]map[string][]byte),
+		collections: make(map[string]bool),
+		usedIndexes: make(map[string]bool),
+	}
+}
+
+// SetTime sets the timestamp of the next transactions
+func (l *Ledger) SetTime(now time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.now = now
+}
+
+// Advance moves the clock forward by d
+func (l *Ledger) Advance(d time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.now = l.now.Add(d)
+}
+
+// AddCollections declares private data collections. Once any collection is
+// declared, using another one fails like on a peer without it in the
+// collection config.
+func (l *Ledger) AddCollections(names ...string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, name := range names {
+		l.collections[name] = true
+	}
+}
+
+// TxOption configures a transaction
+type TxOption func(*Tx)
+
+// WithArgs sets the arguments returned by GetArgs and
+// GetFunctionAndParameters
+func WithArgs(args ...string) TxOption {
+	return func(tx *Tx) {
+		tx.args = args
+	}
+}
+
+// WithTransient sets the transient map of the transaction
+func WithTransient(transient map[string][]byte) TxOption {
+	return func(tx *Tx) {
+		tx.transient = transient
+	}
+}
+
+// Begin starts a transaction of identity calling function. Nothing it
+// writes is visible until Commit.
+func (l *Ledger) Begin(identity *Identity, function string, options ...TxOption) *Tx {
+	l.mu.Lock()
+	l.txs++
+	digest := sha256.Sum256([]byte(fmt.Sprintf("tx%d", l.txs)))
+	now := l.now
+	l.mu.Unlock()
+
+	tx := &Tx{
+		ledger:    l,
+		identity:  identity,
+		id:        hex.EncodeToString(digest[:]),
+		timestamp: &timestamp.Timestamp{Seconds: now.Unix(), Nanos: int32(now.Nanosecond())},
+		function:  function,
+		transient: map[string][]byte{},
+		writes:    make(map[string][]byte),
+		private:   make(map[string]map[string][]byte),
+	}
+	for _, option := range options {
+		option(tx)
+	}
+
+	return tx
+}
+
+// Run runs fn as a transaction of identity calling function and commits it
+// when fn succeeds. The error of fn is returned as is.
+func (l *Ledger) Run(identity *Identity, function string, fn func(ctx contractapi.TransactionContextInterface) error, options ...TxOption) error {
+	tx := l.Begin(identity, function, options...)
+
+	err := fn(tx)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Call runs fn as the transaction function of a contract like the contract
+// API does: the function is named <contract>:<function> and the
+// BeforeTransaction of the contract runs first.
+func (l *Ledger) Call(identity *Identity, contract contractapi.ContractInterface, function string, fn func(ctx contractapi.TransactionContextInterface) error, options ...TxOption) error {
+	if contract.GetName() != "" {
+		function = contract.GetName() + ":" + function
+	}
+
+	return l.Run(identity, function, func(ctx contractapi.TransactionContextInterface) error {
+		if before, ok := contract.GetBeforeTransaction().(func(contractapi.TransactionContextInterface) error); ok {
+			err := before(ctx)
+			if err != nil {
+				return err
+			}
+		}
+
+		return fn(ctx)
+	}, options...)
+}
+
+// GetState returns the committed value of a key, nil when it does not exist
+func (l *Ledger) GetState(key string) []byte {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.state[key]
+}
+
+// PutState writes a key outside of any transaction, to set up a test
+func (l *Ledger) PutState(key string, value []byte) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.state[key] = value
+}
+
+// GetPrivateData returns the committed value of a private key
+func (l *Ledger) GetPrivateData(collection, key string) []byte {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.private[collection][key]
+}
+
+// CompositeKeys returns the attributes of every committed composite key of
+// objectType, in key order
+func (l *Ledger) CompositeKeys(objectType string) [][]string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	prefix := compositeKeyNamespace + objectType + compositeKeyNamespace
+
+	var keys [][]string
+	for _, key := range sortedKeys(l.state) {
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		_, attributes := splitCompositeKey(key)
+		keys = append(keys, attributes)
+	}
+
+	return keys
+}
+
+// Events returns the events of the committed transactions, oldest first
+func (l *Ledger) Events() []Event {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return append([]Event(nil), l.events...)
+}
+
+// LastEvent returns the event of the last committed transaction that set
+// one, nil when there is none
+func (l *Ledger) LastEvent() *Event {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if len(l.events) == 0 {
+		return nil
+	}
+	event := l.events[len(l.events)-1]
+
+	return &event
+}
+
+// commit applies the writes of a transaction
+func (l *Ledger) commit(tx *Tx) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, key := range sortedKeys(tx.writes) {
+		value := tx.writes[key]
+		if value == nil {
+			delete(l.state, key)
+		} else {
+			l.state[key] = value
+		}
+
+		// history is kept newest first, like GetHistoryForKey returns it
+		modification := &queryresult.KeyModification{
+			TxId:      tx.id,
+			Value:     value,
+			Timestamp: tx.timestamp,
+			IsDelete:  value == nil,
+		}
+		l.history[key] = append([]*queryresult.KeyModification{modification}, l.history[key]...)
+	}
+
+	for collection, writes := range tx.private {
+		if l.private[collection] == nil {
+			l.private[collection] = make(map[string][]byte)
+		}
+		for key, value := range writes {
+			if value == nil {
+				delete(l.private[collection], key)
+			} else {
+				l.private[collection][key] = value
+			}
+		}
+	}
+
+	if tx.event != nil {
+		l.events = append(l.events, *tx.event)
+	}
+}
+
+// sortedKeys returns the keys of a key-value map in order
+func sortedKeys(m map[string][]byte) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
@@ -0,0 +1,307 @@
+package ledgertest
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-chaincode-go/pkg/cid"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+var (
+	_ shim.ChaincodeStubInterface             = (*Tx)(nil)
+	_ contractapi.TransactionContextInterface = (*Tx)(nil)
+	_ cid.ClientIdentity                      = (*Identity)(nil)
+)
+
+var alice = NewIdentity("Org1MSP", "alice", map[string]string{"labplatform.role": "instructor"})
+
+// keys drains an iterator and returns its keys
+func keys(t *testing.T, it shim.StateQueryIteratorInterface) []string {
+	t.Helper()
+	defer it.Close()
+
+	var res []string
+	for it.HasNext() {
+		kv, err := it.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		res = append(res, kv.Key)
+	}
+
+	return res
+}
+
+func TestReadsSeeCommittedStateOnly(t *testing.T) {
+	ledger := NewLedger()
+
+	tx := ledger.Begin(alice, "Put")
+	err := tx.PutState("a", []byte("1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	value, _ := tx.GetState("a")
+	if value != nil {
+		t.Errorf("read own write %q before commit", value)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = tx.Commit(); err == nil {
+		t.Error("committed twice")
+	}
+
+	value, _ = ledger.Begin(alice, "Get").GetState("a")
+	if string(value) != "1" {
+		t.Errorf("got %q after commit, want 1", value)
+	}
+}
+
+func TestRunDiscardsFailedTransactions(t *testing.T) {
+	ledger := NewLedger()
+
+	err := ledger.Run(alice, "Put", func(ctx contractapi.TransactionContextInterface) error {
+		ctx.GetStub().PutState("a", []byte("1"))
+		return errIteratorDone
+	})
+	if err != errIteratorDone {
+		t.Fatalf("got %v, want the error of the function", err)
+	}
+	if ledger.GetState("a") != nil {
+		t.Error("failed transaction was committed")
+	}
+}
+
+func TestDeleteAndHistory(t *testing.T) {
+	ledger := NewLedger()
+
+	for _, value := range []string{"1", "2", ""} {
+		err := ledger.Run(alice, "Put", func(ctx contractapi.TransactionContextInterface) error {
+			return ctx.GetStub().PutState("a", []byte(value))
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ledger.Advance(time.Minute)
+	}
+
+	if ledger.GetState("a") != nil {
+		t.Error("empty value did not delete the key")
+	}
+
+	it, err := ledger.Begin(alice, "History").GetHistoryForKey("a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var values []string
+	var deletes []bool
+	var seconds []int64
+	for it.HasNext() {
+		modification, _ := it.Next()
+		values = append(values, string(modification.Value))
+		deletes = append(deletes, modification.IsDelete)
+		seconds = append(seconds, modification.Timestamp.Seconds)
+	}
+
+	if !reflect.DeepEqual(values, []string{"", "2", "1"}) || !reflect.DeepEqual(deletes, []bool{true, false, false}) {
+		t.Errorf("got history %q %v, want newest first", values, deletes)
+	}
+	if seconds[0]-seconds[2] != 120 {
+		t.Errorf("got timestamps %v, want a minute apart", seconds)
+	}
+}
+
+func TestCompositeKeys(t *testing.T) {
+	ledger := NewLedger()
+	tx := ledger.Begin(alice, "Index")
+
+	for _, attributes := range [][]string{{"c1", "l1"}, {"c1", "l2"}, {"c2", "l3"}} {
+		key, err := tx.CreateCompositeKey("classID~labID", attributes)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tx.PutState(key, []byte{0x00})
+	}
+	tx.PutState("simple", []byte("{}"))
+	tx.Commit()
+
+	got := ledger.CompositeKeys("classID~labID")
+	want := [][]string{{"c1", "l1"}, {"c1", "l2"}, {"c2", "l3"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	tx = ledger.Begin(alice, "Query")
+	it, err := tx.GetStateByPartialCompositeKey("classID~labID", []string{"c1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var labs []string
+	for _, key := range keys(t, it) {
+		objectType, attributes, _ := tx.SplitCompositeKey(key)
+		if objectType != "classID~labID" {
+			t.Errorf("got object type %s", objectType)
+		}
+		labs = append(labs, attributes[1])
+	}
+	if !reflect.DeepEqual(labs, []string{"l1", "l2"}) {
+		t.Errorf("got labs %v of c1", labs)
+	}
+
+	_, err = tx.CreateCompositeKey("type", []string{"a\x00b"})
+	if err == nil {
+		t.Error("accepted a null character in an attribute")
+	}
+
+	// range queries skip composite keys
+	it, _ = tx.GetStateByRange("", "")
+	if got := keys(t, it); !reflect.DeepEqual(got, []string{"simple"}) {
+		t.Errorf("range got %q, want only simple keys", got)
+	}
+	if _, err = tx.GetStateByRange("\x00type", ""); err == nil {
+		t.Error("range accepted a composite start key")
+	}
+}
+
+func TestRangePagination(t *testing.T) {
+	ledger := NewLedger()
+	for _, key := range []string{"a", "b", "c", "d", "e"} {
+		ledger.PutState(key, []byte(`{}`))
+	}
+
+	var pages [][]string
+	bookmark := ""
+	for {
+		tx := ledger.Begin(alice, "Page")
+		it, metadata, err := tx.GetStateByRangeWithPagination("b", "", 2, bookmark)
+		if err != nil {
+			t.Fatal(err)
+		}
+		page := keys(t, it)
+		if int(metadata.FetchedRecordsCount) != len(page) {
+			t.Errorf("got count %d for %d records", metadata.FetchedRecordsCount, len(page))
+		}
+		pages = append(pages, page)
+
+		if err = tx.PutState("z", []byte("1")); err == nil || !strings.Contains(err.Error(), "paginated query") {
+			t.Errorf("got %v writing after a paginated query", err)
+		}
+
+		bookmark = metadata.Bookmark
+		if bookmark == "" {
+			break
+		}
+	}
+
+	want := [][]string{{"b", "c"}, {"d", "e"}}
+	if !reflect.DeepEqual(pages, want) {
+		t.Errorf("got pages %q, want %q", pages, want)
+	}
+}
+
+func TestPrivateData(t *testing.T) {
+	ledger := NewLedger()
+	ledger.AddCollections("Org1MSPPrivateCollection")
+
+	err := ledger.Run(alice, "Put", func(ctx contractapi.TransactionContextInterface) error {
+		transient, _ := ctx.GetStub().GetTransient()
+		return ctx.GetStub().PutPrivateData("Org1MSPPrivateCollection", "a", transient["artifact"])
+	}, WithTransient(map[string][]byte{"artifact": []byte("secret")}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tx := ledger.Begin(alice, "Get")
+	value, _ := tx.GetPrivateData("Org1MSPPrivateCollection", "a")
+	if string(value) != "secret" {
+		t.Errorf("got %q", value)
+	}
+	if ledger.GetState("a") != nil {
+		t.Error("private data leaked into the world state")
+	}
+
+	hash, _ := tx.GetPrivateDataHash("Org1MSPPrivateCollection", "a")
+	digest := sha256.Sum256([]byte("secret"))
+	if !bytes.Equal(hash, digest[:]) {
+		t.Errorf("got hash %x", hash)
+	}
+
+	_, err = tx.GetPrivateData("Org2MSPPrivateCollection", "a")
+	if err == nil {
+		t.Error("read from an undeclared collection")
+	}
+}
+
+func TestEvents(t *testing.T) {
+	ledger := NewLedger()
+
+	err := ledger.Run(alice, "Emit", func(ctx contractapi.TransactionContextInterface) error {
+		ctx.GetStub().SetEvent("First", []byte("1"))
+		return ctx.GetStub().SetEvent("Second", []byte("2"))
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	events := ledger.Events()
+	if len(events) != 1 || events[0].Name != "Second" || string(events[0].Payload) != "2" {
+		t.Errorf("got events %+v, want only the last one set", events)
+	}
+	if ledger.LastEvent().TxID == "" {
+		t.Error("event has no transaction ID")
+	}
+}
+
+func TestIdentity(t *testing.T) {
+	tx := NewLedger().Begin(alice, "class:CreateClass", WithArgs("c1", "Networks"))
+
+	id, _ := tx.GetClientIdentity().GetID()
+	decoded, _ := base64.StdEncoding.DecodeString(id)
+	if string(decoded) != "x509::CN=alice,OU=client::CN=ca.Org1MSP" {
+		t.Errorf("got ID %s", decoded)
+	}
+
+	value, found, _ := tx.GetClientIdentity().GetAttributeValue("labplatform.role")
+	if !found || value != "instructor" {
+		t.Errorf("got attribute %q %v", value, found)
+	}
+	if err := tx.GetClientIdentity().AssertAttributeValue("labplatform.role", "admin"); err == nil {
+		t.Error("asserted the wrong attribute value")
+	}
+
+	function, params := tx.GetFunctionAndParameters()
+	if function != "class:CreateClass" || !reflect.DeepEqual(params, []string{"c1", "Networks"}) {
+		t.Errorf("got %s %v", function, params)
+	}
+}
+
+func TestCallRunsBeforeTransaction(t *testing.T) {
+	contract := new(contractapi.Contract)
+	contract.Name = "class"
+
+	var called string
+	contract.BeforeTransaction = func(ctx contractapi.TransactionContextInterface) error {
+		called, _ = ctx.GetStub().GetFunctionAndParameters()
+		return nil
+	}
+
+	err := NewLedger().Call(alice, contract, "ReadClass", func(ctx contractapi.TransactionContextInterface) error {
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if called != "class:ReadClass" {
+		t.Errorf("before transaction saw %q", called)
+	}
+}
@@ -0,0 +1,452 @@
+package ledgertest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/hyperledger/fabric-protos-go/ledger/queryresult"
+)
+
+// query is the subset of a CouchDB query GetQueryResult understands.
+//
+// Selectors support the combination operators $and, $or, $nor and $not, the
+// condition operators $eq, $ne, $gt, $gte, $lt, $lte, $exists, $in, $nin and
+// $regex, implicit equality and nested fields, either dotted or as nested
+// objects. Values compare with the CouchDB collation: null, false, true,
+// numbers, strings, arrays, objects. Only JSON objects are matched, other
+// values are skipped like CouchDB stores them as attachments.
+type query struct {
+	Selector map[string]interface{} `json:"selector"`
+	Sort     []interface{}          `json:"sort"`
+	Limit    int                    `json:"limit"`
+	Skip     int                    `json:"skip"`
+	UseIndex interface{}            `json:"use_index"`
+}
+
+// sortField is a field of the sort of a query
+type sortField struct {
+	path       []string
+	descending bool
+}
+
+// document is a JSON value of the state being matched
+type document struct {
+	kv    *queryresult.KV
+	value map[string]interface{}
+}
+
+// runQuery returns the key-values of values matching a query, sorted, in key
+// order otherwise
+func runQuery(values map[string][]byte, mu *sync.Mutex, queryString string) ([]*queryresult.KV, error) {
+	var q query
+	decoder := json.NewDecoder(strings.NewReader(queryString))
+	decoder.DisallowUnknownFields()
+	err := decoder.Decode(&q)
+	if err != nil {
+		return nil, fmt.Errorf("invalid query %s: %v", queryString, err)
+	}
+	if q.Selector == nil {
+		return nil, fmt.Errorf("invalid query %s: selector is required", queryString)
+	}
+	if q.Limit < 0 || q.Skip < 0 {
+		return nil, fmt.Errorf("invalid query %s: limit and skip must not be negative", queryString)
+	}
+
+	sortFields, err := parseSort(q.Sort)
+	if err != nil {
+		return nil, fmt.Errorf("invalid query %s: %v", queryString, err)
+	}
+
+	// check the selector once so that errors don't depend on the state
+	err = validateSelector(q.Selector)
+	if err != nil {
+		return nil, fmt.Errorf("invalid query %s: %v", queryString, err)
+	}
+
+	mu.Lock()
+	var documents []document
+	for _, key := range sortedKeys(values) {
+		var value map[string]interface{}
+		if json.Unmarshal(values[key], &value) != nil || value == nil {
+			continue
+		}
+		documents = append(documents, document{
+			kv:    &queryresult.KV{Namespace: Channel, Key: key, Value: values[key]},
+			value: value,
+		})
+	}
+	mu.Unlock()
+
+	var matches []document
+	for _, doc := range documents {
+		if matchSelector(doc.value, q.Selector) {
+			matches = append(matches, doc)
+		}
+	}
+
+	if len(sortFields) > 0 {
+		sort.SliceStable(matches, func(i, j int) bool {
+			for _, field := range sortFields {
+				a, _ := lookup(matches[i].value, field.path)
+				b, _ := lookup(matches[j].value, field.path)
+				c := collate(a, b)
+				if c == 0 {
+					continue
+				}
+				if field.descending {
+					return c > 0
+				}
+				return c < 0
+			}
+			return false
+		})
+	}
+
+	if q.Skip > len(matches) {
+		q.Skip = len(matches)
+	}
+	matches = matches[q.Skip:]
+	if q.Limit > 0 && len(matches) > q.Limit {
+		matches = matches[:q.Limit]
+	}
+
+	kvs := make([]*queryresult.KV, 0, len(matches))
+	for _, doc := range matches {
+		kvs = append(kvs, doc.kv)
+	}
+
+	return kvs, nil
+}
+
+// parseSort parses a sort, either field names or {field: direction} objects
+func parseSort(sortValue []interface{}) ([]sortField, error) {
+	var fields []sortField
+	for _, item := range sortValue {
+		switch item := item.(type) {
+		case string:
+			fields = append(fields, sortField{path: strings.Split(item, ".")})
+		case map[string]interface{}:
+			if len(item) != 1 {
+				return nil, fmt.Errorf("sort object must have exactly one field: %v", item)
+			}
+			for name, direction := range item {
+				switch direction {
+				case "asc":
+					fields = append(fields, sortField{path: strings.Split(name, ".")})
+				case "desc":
+					fields = append(fields, sortField{path: strings.Split(name, "."), descending: true})
+				default:
+					return nil, fmt.Errorf("sort direction of %s must be asc or desc", name)
+				}
+			}
+		default:
+			return nil, fmt.Errorf("invalid sort %v", item)
+		}
+	}
+
+	return fields, nil
+}
+
+// validateSelector fails for unsupported operators and malformed operands
+func validateSelector(selector map[string]interface{}) error {
+	for name, value := range selector {
+		if strings.HasPrefix(name, "$") {
+			err := validateCombination(name, value)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		err := validateCondition(value)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// validateCombination validates a combination operator and its selectors
+func validateCombination(operator string, value interface{}) error {
+	switch operator {
+	case "$and", "$or", "$nor":
+		selectors, ok := value.([]interface{})
+		if !ok {
+			return fmt.Errorf("%s takes an array of selectors", operator)
+		}
+		for _, selector := range selectors {
+			object, ok := selector.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("%s takes an array of selectors", operator)
+			}
+			err := validateSelector(object)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	case "$not":
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("$not takes a selector")
+		}
+		return validateSelector(object)
+	default:
+		return fmt.Errorf("unsupported operator %s", operator)
+	}
+}
+
+// validateCondition validates the condition on a field, either a value, a
+// nested selector or operators
+func validateCondition(value interface{}) error {
+	object, ok := value.(map[string]interface{})
+	if !ok || !isOperatorObject(object) {
+		if ok {
+			return validateSelector(object)
+		}
+		return nil
+	}
+
+	for operator, operand := range object {
+		switch operator {
+		case "$eq", "$ne", "$gt", "$gte", "$lt", "$lte":
+		case "$exists":
+			if _, ok := operand.(bool); !ok {
+				return fmt.Errorf("$exists takes a boolean")
+			}
+		case "$in", "$nin":
+			if _, ok := operand.([]interface{}); !ok {
+				return fmt.Errorf("%s takes an array", operator)
+			}
+		case "$regex":
+			pattern, ok := operand.(string)
+			if !ok {
+				return fmt.Errorf("$regex takes a string")
+			}
+			_, err := regexp.Compile(pattern)
+			if err != nil {
+				return fmt.Errorf("invalid $regex %s: %v", pattern, err)
+			}
+		case "$not":
+			err := validateCondition(operand)
+			if err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("unsupported operator %s", operator)
+		}
+	}
+
+	return nil
+}
+
+// isOperatorObject tells whether every field of an object is an operator
+func isOperatorObject(object map[string]interface{}) bool {
+	if len(object) == 0 {
+		return false
+	}
+	for name := range object {
+		if !strings.HasPrefix(name, "$") {
+			return false
+		}
+	}
+
+	return true
+}
+
+// matchSelector tells whether a document matches a validated selector
+func matchSelector(doc map[string]interface{}, selector map[string]interface{}) bool {
+	for name, value := range selector {
+		if strings.HasPrefix(name, "$") {
+			if !matchCombination(doc, name, value) {
+				return false
+			}
+			continue
+		}
+
+		fieldValue, found := lookup(doc, strings.Split(name, "."))
+		if !matchCondition(fieldValue, found, value) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// matchCombination applies a combination operator
+func matchCombination(doc map[string]interface{}, operator string, value interface{}) bool {
+	if operator == "$not" {
+		return !matchSelector(doc, value.(map[string]interface{}))
+	}
+
+	matched := 0
+	selectors := value.([]interface{})
+	for _, selector := range selectors {
+		if matchSelector(doc, selector.(map[string]interface{})) {
+			matched++
+		}
+	}
+
+	switch operator {
+	case "$and":
+		return matched == len(selectors)
+	case "$or":
+		return matched > 0
+	default:
+		return matched == 0
+	}
+}
+
+// matchCondition tells whether the value of a field matches a condition.
+// Like in CouchDB, a missing field only matches $exists false.
+func matchCondition(fieldValue interface{}, found bool, condition interface{}) bool {
+	object, ok := condition.(map[string]interface{})
+	if ok && !isOperatorObject(object) {
+		nested, isObject := fieldValue.(map[string]interface{})
+		return found && isObject && matchSelector(nested, object)
+	}
+	if !ok {
+		return found && collate(fieldValue, condition) == 0
+	}
+
+	for operator, operand := range object {
+		if !matchOperator(fieldValue, found, operator, operand) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// matchOperator applies a condition operator to the value of a field
+func matchOperator(fieldValue interface{}, found bool, operator string, operand interface{}) bool {
+	if operator == "$exists" {
+		return found == operand.(bool)
+	}
+	if operator == "$not" {
+		return found && !matchCondition(fieldValue, found, operand)
+	}
+	if !found {
+		return false
+	}
+
+	switch operator {
+	case "$eq":
+		return collate(fieldValue, operand) == 0
+	case "$ne":
+		return collate(fieldValue, operand) != 0
+	case "$gt":
+		return collate(fieldValue, operand) > 0
+	case "$gte":
+		return collate(fieldValue, operand) >= 0
+	case "$lt":
+		return collate(fieldValue, operand) < 0
+	case "$lte":
+		return collate(fieldValue, operand) <= 0
+	case "$in", "$nin":
+		in := false
+		for _, item := range operand.([]interface{}) {
+			if collate(fieldValue, item) == 0 {
+				in = true
+				break
+			}
+		}
+		return in == (operator == "$in")
+	case "$regex":
+		s, ok := fieldValue.(string)
+		return ok && regexp.MustCompile(operand.(string)).MatchString(s)
+	default:
+		return false
+	}
+}
+
+// lookup returns the value at a path of nested fields
+func lookup(doc map[string]interface{}, path []string) (interface{}, bool) {
+	var value interface{} = doc
+	for _, name := range path {
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		value, ok = object[name]
+		if !ok {
+			return nil, false
+		}
+	}
+
+	return value, true
+}
+
+// collationRank orders JSON types like CouchDB does
+func collationRank(value interface{}) int {
+	switch value := value.(type) {
+	case nil:
+		return 0
+	case bool:
+		if value {
+			return 2
+		}
+		return 1
+	case float64:
+		return 3
+	case string:
+		return 4
+	case []interface{}:
+		return 5
+	default:
+		return 6
+	}
+}
+
+// collate compares two JSON values with the CouchDB collation
+func collate(a, b interface{}) int {
+	rankA, rankB := collationRank(a), collationRank(b)
+	if rankA != rankB {
+		if rankA < rankB {
+			return -1
+		}
+		return 1
+	}
+
+	switch a := a.(type) {
+	case float64:
+		b := b.(float64)
+		switch {
+		case a < b:
+			return -1
+		case a > b:
+			return 1
+		}
+		return 0
+	case string:
+		return strings.Compare(a, b.(string))
+	case []interface{}:
+		b := b.([]interface{})
+		for i := 0; i < len(a) && i < len(b); i++ {
+			c := collate(a[i], b[i])
+			if c != 0 {
+				return c
+			}
+		}
+		switch {
+		case len(a) < len(b):
+			return -1
+		case len(a) > len(b):
+			return 1
+		}
+		return 0
+	case map[string]interface{}:
+		// objects only need equality, compare their canonical encoding
+		aBytes, _ := json.Marshal(a)
+		bBytes, _ := json.Marshal(b)
+		return bytes.Compare(aBytes, bBytes)
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,111 @@
+package ledgertest
+
+import (
+	"reflect"
+	"testing"
+)
+
+func queryLedger() *Ledger {
+	ledger := NewLedger()
+	ledger.PutState("lab1", []byte(`{"docType":"lab","classID":"c1","name":"Routing","quota":{"cpu":2},"tags":["net"]}`))
+	ledger.PutState("lab2", []byte(`{"docType":"lab","classID":"c1","name":"Switching","quota":{"cpu":4}}`))
+	ledger.PutState("lab3", []byte(`{"docType":"lab","classID":"c2","name":"Firewalls","quota":{"cpu":1},"late":true}`))
+	ledger.PutState("inst1", []byte(`{"docType":"instance","classID":"c1","labID":"lab1"}`))
+	ledger.PutState("\x00classID~labID\x00c1\x00lab1\x00", []byte{0x00})
+
+	return ledger
+}
+
+func TestQuerySelectors(t *testing.T) {
+	ledger := queryLedger()
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{`{"selector":{"docType":"lab","classID":"c1"}}`, []string{"lab1", "lab2"}},
+		{`{"selector":{"docType":{"$eq":"instance"}}}`, []string{"inst1"}},
+		{`{"selector":{"docType":"lab","classID":{"$ne":"c1"}}}`, []string{"lab3"}},
+		{`{"selector":{"quota.cpu":{"$gte":2}}}`, []string{"lab1", "lab2"}},
+		{`{"selector":{"quota":{"cpu":{"$lt":2}}}}`, []string{"lab3"}},
+		{`{"selector":{"late":{"$exists":true}}}`, []string{"lab3"}},
+		{`{"selector":{"docType":"lab","late":{"$exists":false}}}`, []string{"lab1", "lab2"}},
+		{`{"selector":{"name":{"$in":["Routing","Firewalls"]}}}`, []string{"lab1", "lab3"}},
+		{`{"selector":{"docType":"lab","name":{"$nin":["Routing"]}}}`, []string{"lab2", "lab3"}},
+		{`{"selector":{"name":{"$regex":"^S"}}}`, []string{"lab2"}},
+		{`{"selector":{"$or":[{"classID":"c2"},{"docType":"instance"}]}}`, []string{"inst1", "lab3"}},
+		{`{"selector":{"$and":[{"docType":"lab"},{"quota.cpu":{"$gt":1}}]}}`, []string{"lab1", "lab2"}},
+		{`{"selector":{"docType":"lab","$nor":[{"classID":"c1"}]}}`, []string{"lab3"}},
+		{`{"selector":{"docType":"lab","$not":{"classID":"c1"}}}`, []string{"lab3"}},
+		{`{"selector":{"docType":"lab","name":{"$not":{"$eq":"Routing"}}}}`, []string{"lab2", "lab3"}},
+		{`{"selector":{"tags":["net"]}}`, []string{"lab1"}},
+		{`{"selector":{"missing":{"$ne":"x"}}}`, nil},
+		{`{"selector":{"docType":"lab"},"sort":[{"name":"asc"}]}`, []string{"lab3", "lab1", "lab2"}},
+		{`{"selector":{"docType":"lab"},"sort":[{"quota.cpu":"desc"}],"limit":2}`, []string{"lab2", "lab1"}},
+		{`{"selector":{"docType":"lab"},"sort":["classID",{"name":"desc"}],"skip":1}`, []string{"lab1", "lab3"}},
+		{`{"selector":{"docType":"lab"},"use_index":["_design/indexLabDoc","indexLab"]}`, []string{"lab1", "lab2", "lab3"}},
+	}
+
+	for _, test := range tests {
+		it, err := ledger.Begin(alice, "Query").GetQueryResult(test.query)
+		if err != nil {
+			t.Errorf("%s: %v", test.query, err)
+			continue
+		}
+		got := keys(t, it)
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got %q, want %q", test.query, got, test.want)
+		}
+	}
+}
+
+func TestQueryErrors(t *testing.T) {
+	tx := queryLedger().Begin(alice, "Query")
+
+	for _, query := range []string{
+		`not json`,
+		`{}`,
+		`{"selector":{"docType":"lab"},"fields":["name"]}`,
+		`{"selector":{"name":{"$elemMatch":{"a":1}}}}`,
+		`{"selector":{"$or":{"classID":"c1"}}}`,
+		`{"selector":{"name":{"$regex":"("}}}`,
+		`{"selector":{"name":{"$in":"Routing"}}}`,
+		`{"selector":{"docType":"lab"},"sort":[{"name":"up"}]}`,
+	} {
+		_, err := tx.GetQueryResult(query)
+		if err == nil {
+			t.Errorf("%s: no error", query)
+		}
+	}
+}
+
+func TestQueryPagination(t *testing.T) {
+	ledger := queryLedger()
+	query := `{"selector":{"docType":"lab"},"sort":[{"name":"desc"}]}`
+
+	var got []string
+	bookmark := ""
+	pages := 0
+	for {
+		it, metadata, err := ledger.Begin(alice, "Query").GetQueryResultWithPagination(query, 2, bookmark)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, keys(t, it)...)
+		pages++
+
+		bookmark = metadata.Bookmark
+		if bookmark == "" {
+			break
+		}
+	}
+
+	if pages != 2 || !reflect.DeepEqual(got, []string{"lab2", "lab1", "lab3"}) {
+		t.Errorf("got %q in %d pages", got, pages)
+	}
+
+	_, _, err := ledger.Begin(alice, "Query").GetQueryResultWithPagination(query, 2, "not a bookmark")
+	if err == nil {
+		t.Error("accepted an invalid bookmark")
+	}
+}
@@ -0,0 +1,602 @@
+package ledgertest
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/hyperledger/fabric-chaincode-go/pkg/cid"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-protos-go/ledger/queryresult"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+)
+
+// Key namespaces of the shim. Composite keys start with a null character,
+// simple keys can't, and range queries with an empty start key start after
+// the composite keys.
+const (
+	compositeKeyNamespace = "\x00"
+	emptyKeySubstitute    = "\x01"
+	maxUnicodeRune        = string(utf8.MaxRune)
+)
+
+// errNotSupported is returned by the stub functions that need a real peer
+var errNotSupported = errors.New("not supported by the in-memory ledger")
+
+// Tx is a transaction on a Ledger. It is the transaction context and the
+// stub at the same time.
+type Tx struct {
+	ledger    *Ledger
+	identity  *Identity
+	id        string
+	timestamp *timestamp.Timestamp
+	function  string
+	args      []string
+	transient map[string][]byte
+	event     *Event
+	paginated bool
+	committed bool
+
+	writes     map[string][]byte
+	private    map[string]map[string][]byte
+	validation map[string][]byte
+}
+
+// GetStub returns the transaction itself
+func (tx *Tx) GetStub() shim.ChaincodeStubInterface {
+	return tx
+}
+
+// GetClientIdentity returns the identity running the transaction
+func (tx *Tx) GetClientIdentity() cid.ClientIdentity {
+	return tx.identity
+}
+
+// ID returns the transaction ID
+func (tx *Tx) ID() string {
+	return tx.id
+}
+
+// Commit applies the writes and the event of the transaction to the ledger.
+// A transaction can only be committed once.
+func (tx *Tx) Commit() error {
+	if tx.committed {
+		return fmt.Errorf("transaction %s is already committed", tx.id)
+	}
+	tx.committed = true
+
+	tx.ledger.commit(tx)
+
+	return nil
+}
+
+// GetArgs returns the function name and the arguments
+func (tx *Tx) GetArgs() [][]byte {
+	args := [][]byte{[]byte(tx.function)}
+	for _, arg := range tx.args {
+		args = append(args, []byte(arg))
+	}
+
+	return args
+}
+
+// GetStringArgs returns the function name and the arguments
+func (tx *Tx) GetStringArgs() []string {
+	return append([]string{tx.function}, tx.args...)
+}
+
+// GetFunctionAndParameters returns the function name and the arguments
+func (tx *Tx) GetFunctionAndParameters() (string, []string) {
+	return tx.function, append([]string(nil), tx.args...)
+}
+
+// GetArgsSlice returns the concatenated arguments
+func (tx *Tx) GetArgsSlice() ([]byte, error) {
+	var res []byte
+	for _, arg := range tx.GetArgs() {
+		res = append(res, arg...)
+	}
+
+	return res, nil
+}
+
+// GetTxID returns the transaction ID
+func (tx *Tx) GetTxID() string {
+	return tx.id
+}
+
+// GetChannelID returns Channel
+func (tx *Tx) GetChannelID() string {
+	return Channel
+}
+
+// InvokeChaincode is not supported
+func (tx *Tx) InvokeChaincode(chaincodeName string, args [][]byte, channel string) pb.Response {
+	return shim.Error(errNotSupported.Error())
+}
+
+// GetState returns the committed value of a key
+func (tx *Tx) GetState(key string) ([]byte, error) {
+	return tx.ledger.GetState(key), nil
+}
+
+// PutState writes a key when the transaction commits. An empty value
+// deletes the key.
+func (tx *Tx) PutState(key string, value []byte) error {
+	if key == "" {
+		return errors.New("key must not be an empty string")
+	}
+	if !utf8.ValidString(key) {
+		return fmt.Errorf("key %q is not a valid utf8 string", key)
+	}
+	if tx.paginated {
+		return fmt.Errorf("txid [%s]: transaction has already performed a paginated query. Writes are not allowed", tx.id)
+	}
+	if len(value) == 0 {
+		value = nil
+	}
+
+	tx.writes[key] = append([]byte(nil), value...)
+	if value == nil {
+		tx.writes[key] = nil
+	}
+
+	return nil
+}
+
+// DelState deletes a key when the transaction commits
+func (tx *Tx) DelState(key string) error {
+	return tx.PutState(key, nil)
+}
+
+// SetStateValidationParameter records the endorsement policy of a key, it is
+// not enforced
+func (tx *Tx) SetStateValidationParameter(key string, ep []byte) error {
+	if tx.validation == nil {
+		tx.validation = make(map[string][]byte)
+	}
+	tx.validation[key] = ep
+
+	return nil
+}
+
+// GetStateValidationParameter returns the endorsement policy set for a key
+// by this transaction
+func (tx *Tx) GetStateValidationParameter(key string) ([]byte, error) {
+	return tx.validation[key], nil
+}
+
+// GetStateByRange returns the simple keys in [startKey, endKey). Empty
+// bounds are open.
+func (tx *Tx) GetStateByRange(startKey, endKey string) (shim.StateQueryIteratorInterface, error) {
+	err := validateSimpleKeys(startKey, endKey)
+	if err != nil {
+		return nil, err
+	}
+	if startKey == "" {
+		startKey = emptyKeySubstitute
+	}
+
+	return newStateIterator(tx.rangeOf(tx.ledger.state, startKey, endKey, 0)), nil
+}
+
+// GetStateByRangeWithPagination returns a page of the simple keys in
+// [startKey, endKey). The bookmark is the key the next page starts at, empty
+// after the last page.
+func (tx *Tx) GetStateByRangeWithPagination(startKey, endKey string, pageSize int32, bookmark string) (shim.StateQueryIteratorInterface, *pb.QueryResponseMetadata, error) {
+	err := validateSimpleKeys(startKey, endKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	if startKey == "" {
+		startKey = emptyKeySubstitute
+	}
+
+	return tx.page(startKey, endKey, pageSize, bookmark)
+}
+
+// GetStateByPartialCompositeKey returns the composite keys of objectType
+// starting with keys
+func (tx *Tx) GetStateByPartialCompositeKey(objectType string, keys []string) (shim.StateQueryIteratorInterface, error) {
+	startKey, endKey, err := partialCompositeKeyRange(objectType, keys)
+	if err != nil {
+		return nil, err
+	}
+
+	return newStateIterator(tx.rangeOf(tx.ledger.state, startKey, endKey, 0)), nil
+}
+
+// GetStateByPartialCompositeKeyWithPagination returns a page of the
+// composite keys of objectType starting with keys
+func (tx *Tx) GetStateByPartialCompositeKeyWithPagination(objectType string, keys []string, pageSize int32, bookmark string) (shim.StateQueryIteratorInterface, *pb.QueryResponseMetadata, error) {
+	startKey, endKey, err := partialCompositeKeyRange(objectType, keys)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return tx.page(startKey, endKey, pageSize, bookmark)
+}
+
+// page returns a page of the keys in [startKey, endKey) starting at bookmark
+func (tx *Tx) page(startKey, endKey string, pageSize int32, bookmark string) (shim.StateQueryIteratorInterface, *pb.QueryResponseMetadata, error) {
+	if pageSize < 0 {
+		return nil, nil, fmt.Errorf("pagesize must be greater than zero")
+	}
+	tx.paginated = true
+
+	if bookmark != "" && bookmark > startKey {
+		startKey = bookmark
+	}
+
+	kvs := tx.rangeOf(tx.ledger.state, startKey, endKey, 0)
+	next := ""
+	if pageSize > 0 && len(kvs) > int(pageSize) {
+		next = kvs[pageSize].Key
+		kvs = kvs[:pageSize]
+	}
+
+	metadata := &pb.QueryResponseMetadata{FetchedRecordsCount: int32(len(kvs)), Bookmark: next}
+
+	return newStateIterator(kvs), metadata, nil
+}
+
+// CreateCompositeKey joins objectType and attributes into a composite key
+func (tx *Tx) CreateCompositeKey(objectType string, attributes []string) (string, error) {
+	return createCompositeKey(objectType, attributes)
+}
+
+// SplitCompositeKey splits a composite key into its object type and
+// attributes
+func (tx *Tx) SplitCompositeKey(compositeKey string) (string, []string, error) {
+	objectType, attributes := splitCompositeKey(compositeKey)
+	return objectType, attributes, nil
+}
+
+// GetQueryResult runs a CouchDB query on the committed JSON values. The
+// supported subset is described in query.go. Once indexes are required, the
+// query fails unless one of them covers it.
+func (tx *Tx) GetQueryResult(query string) (shim.StateQueryIteratorInterface, error) {
+	err := tx.ledger.checkIndexes(query)
+	if err != nil {
+		return nil, err
+	}
+
+	kvs, err := runQuery(tx.ledger.state, &tx.ledger.mu, query)
+	if err != nil {
+		return nil, err
+	}
+
+	return newStateIterator(kvs), nil
+}
+
+// GetQueryResultWithPagination runs a CouchDB query and returns a page of
+// its results. The bookmark is opaque, empty after the last page.
+func (tx *Tx) GetQueryResultWithPagination(query string, pageSize int32, bookmark string) (shim.StateQueryIteratorInterface, *pb.QueryResponseMetadata, error) {
+	if pageSize < 0 {
+		return nil, nil, fmt.Errorf("pagesize must be greater than zero")
+	}
+	tx.paginated = true
+
+	err := tx.ledger.checkIndexes(query)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	kvs, err := runQuery(tx.ledger.state, &tx.ledger.mu, query)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	offset := 0
+	if bookmark != "" {
+		offset, err = strconv.Atoi(bookmark)
+		if err != nil || offset < 0 {
+			return nil, nil, fmt.Errorf("invalid bookmark %q", bookmark)
+		}
+	}
+	if offset > len(kvs) {
+		offset = len(kvs)
+	}
+	kvs = kvs[offset:]
+
+	next := ""
+	if pageSize > 0 && len(kvs) > int(pageSize) {
+		kvs = kvs[:pageSize]
+		next = strconv.Itoa(offset + int(pageSize))
+	}
+
+	metadata := &pb.QueryResponseMetadata{FetchedRecordsCount: int32(len(kvs)), Bookmark: next}
+
+	return newStateIterator(kvs), metadata, nil
+}
+
+// GetHistoryForKey returns the committed modifications of a key, newest
+// first
+func (tx *Tx) GetHistoryForKey(key string) (shim.HistoryQueryIteratorInterface, error) {
+	tx.ledger.mu.Lock()
+	defer tx.ledger.mu.Unlock()
+
+	return &historyIterator{modifications: append([]*queryresult.KeyModification(nil), tx.ledger.history[key]...)}, nil
+}
+
+// GetPrivateData returns the committed value of a private key
+func (tx *Tx) GetPrivateData(collection, key string) ([]byte, error) {
+	err := tx.checkCollection(collection)
+	if err != nil {
+		return nil, err
+	}
+
+	return tx.ledger.GetPrivateData(collection, key), nil
+}
+
+// GetPrivateDataHash returns the SHA-256 digest of the committed value of a
+// private key, nil when it does not exist
+func (tx *Tx) GetPrivateDataHash(collection, key string) ([]byte, error) {
+	err := tx.checkCollection(collection)
+	if err != nil {
+		return nil, err
+	}
+
+	value := tx.ledger.GetPrivateData(collection, key)
+	if value == nil {
+		return nil, nil
+	}
+	digest := sha256.Sum256(value)
+
+	return digest[:], nil
+}
+
+// PutPrivateData writes a private key when the transaction commits
+func (tx *Tx) PutPrivateData(collection string, key string, value []byte) error {
+	err := tx.checkCollection(collection)
+	if err != nil {
+		return err
+	}
+	if key == "" {
+		return errors.New("key must not be an empty string")
+	}
+	if len(value) == 0 {
+		return errors.New("value must not be empty")
+	}
+
+	if tx.private[collection] == nil {
+		tx.private[collection] = make(map[string][]byte)
+	}
+	tx.private[collection][key] = append([]byte(nil), value...)
+
+	return nil
+}
+
+// DelPrivateData deletes a private key when the transaction commits
+func (tx *Tx) DelPrivateData(collection, key string) error {
+	err := tx.checkCollection(collection)
+	if err != nil {
+		return err
+	}
+
+	if tx.private[collection] == nil {
+		tx.private[collection] = make(map[string][]byte)
+	}
+	tx.private[collection][key] = nil
+
+	return nil
+}
+
+// SetPrivateDataValidationParameter is not supported
+func (tx *Tx) SetPrivateDataValidationParameter(collection, key string, ep []byte) error {
+	return errNotSupported
+}
+
+// GetPrivateDataValidationParameter is not supported
+func (tx *Tx) GetPrivateDataValidationParameter(collection, key string) ([]byte, error) {
+	return nil, errNotSupported
+}
+
+// GetPrivateDataByRange returns the simple private keys in [startKey,
+// endKey)
+func (tx *Tx) GetPrivateDataByRange(collection, startKey, endKey string) (shim.StateQueryIteratorInterface, error) {
+	err := tx.checkCollection(collection)
+	if err != nil {
+		return nil, err
+	}
+	err = validateSimpleKeys(startKey, endKey)
+	if err != nil {
+		return nil, err
+	}
+	if startKey == "" {
+		startKey = emptyKeySubstitute
+	}
+
+	return newStateIterator(tx.rangeOf(tx.ledger.private[collection], startKey, endKey, 0)), nil
+}
+
+// GetPrivateDataByPartialCompositeKey returns the private composite keys of
+// objectType starting with keys
+func (tx *Tx) GetPrivateDataByPartialCompositeKey(collection, objectType string, keys []string) (shim.StateQueryIteratorInterface, error) {
+	err := tx.checkCollection(collection)
+	if err != nil {
+		return nil, err
+	}
+	startKey, endKey, err := partialCompositeKeyRange(objectType, keys)
+	if err != nil {
+		return nil, err
+	}
+
+	return newStateIterator(tx.rangeOf(tx.ledger.private[collection], startKey, endKey, 0)), nil
+}
+
+// GetPrivateDataQueryResult runs a CouchDB query on the private JSON values
+// of a collection
+func (tx *Tx) GetPrivateDataQueryResult(collection, query string) (shim.StateQueryIteratorInterface, error) {
+	err := tx.checkCollection(collection)
+	if err != nil {
+		return nil, err
+	}
+
+	tx.ledger.mu.Lock()
+	values := tx.ledger.private[collection]
+	tx.ledger.mu.Unlock()
+
+	kvs, err := runQuery(values, &tx.ledger.mu, query)
+	if err != nil {
+		return nil, err
+	}
+
+	return newStateIterator(kvs), nil
+}
+
+// GetCreator is not supported, use GetClientIdentity
+func (tx *Tx) GetCreator() ([]byte, error) {
+	return nil, errNotSupported
+}
+
+// GetTransient returns the transient map
+func (tx *Tx) GetTransient() (map[string][]byte, error) {
+	return tx.transient, nil
+}
+
+// GetBinding is not supported
+func (tx *Tx) GetBinding() ([]byte, error) {
+	return nil, errNotSupported
+}
+
+// GetDecorations returns no decorations
+func (tx *Tx) GetDecorations() map[string][]byte {
+	return map[string][]byte{}
+}
+
+// GetSignedProposal is not supported
+func (tx *Tx) GetSignedProposal() (*pb.SignedProposal, error) {
+	return nil, errNotSupported
+}
+
+// GetTxTimestamp returns the ledger time the transaction began at
+func (tx *Tx) GetTxTimestamp() (*timestamp.Timestamp, error) {
+	return tx.timestamp, nil
+}
+
+// SetEvent sets the event of the transaction. Like on a peer only the last
+// event set is kept.
+func (tx *Tx) SetEvent(name string, payload []byte) error {
+	if name == "" {
+		return errors.New("event name can not be empty string")
+	}
+	tx.event = &Event{TxID: tx.id, Name: name, Payload: payload}
+
+	return nil
+}
+
+// checkCollection fails for collections missing from the declared ones
+func (tx *Tx) checkCollection(collection string) error {
+	if collection == "" {
+		return errors.New("collection must not be an empty string")
+	}
+
+	tx.ledger.mu.Lock()
+	defer tx.ledger.mu.Unlock()
+
+	if len(tx.ledger.collections) > 0 && !tx.ledger.collections[collection] {
+		return fmt.Errorf("collection %s could not be found", collection)
+	}
+
+	return nil
+}
+
+// rangeOf returns the key-values of values in [startKey, endKey), at most
+// limit of them unless limit is 0. An empty endKey is open.
+func (tx *Tx) rangeOf(values map[string][]byte, startKey, endKey string, limit int) []*queryresult.KV {
+	tx.ledger.mu.Lock()
+	defer tx.ledger.mu.Unlock()
+
+	var kvs []*queryresult.KV
+	for _, key := range sortedKeys(values) {
+		if key < startKey || (endKey != "" && key >= endKey) {
+			continue
+		}
+		kvs = append(kvs, &queryresult.KV{Namespace: Channel, Key: key, Value: values[key]})
+		if limit > 0 && len(kvs) == limit {
+			break
+		}
+	}
+
+	return kvs
+}
+
+// validateSimpleKeys fails for composite keys, range queries only cover
+// simple keys
+func validateSimpleKeys(keys ...string) error {
+	for _, key := range keys {
+		if strings.HasPrefix(key, compositeKeyNamespace) {
+			return fmt.Errorf("first character of the key [%s] contains a null character which is not allowed", key)
+		}
+	}
+
+	return nil
+}
+
+// createCompositeKey builds a composite key like the shim does
+func createCompositeKey(objectType string, attributes []string) (string, error) {
+	err := validateCompositeKeyAttribute(objectType)
+	if err != nil {
+		return "", err
+	}
+
+	key := compositeKeyNamespace + objectType + compositeKeyNamespace
+	for _, attribute := range attributes {
+		err = validateCompositeKeyAttribute(attribute)
+		if err != nil {
+			return "", err
+		}
+		key += attribute + compositeKeyNamespace
+	}
+
+	return key, nil
+}
+
+// validateCompositeKeyAttribute rejects the characters used to delimit
+// composite keys
+func validateCompositeKeyAttribute(attribute string) error {
+	if !utf8.ValidString(attribute) {
+		return fmt.Errorf("not a valid utf8 string: [%x]", attribute)
+	}
+	for index, r := range attribute {
+		if r == 0 || r == utf8.MaxRune {
+			return fmt.Errorf("input contains unicode %#U starting at position [%d]. %#U and %#U are not allowed in the input attribute of a composite key",
+				r, index, rune(0), utf8.MaxRune)
+		}
+	}
+
+	return nil
+}
+
+// splitCompositeKey splits a composite key like the shim does
+func splitCompositeKey(compositeKey string) (string, []string) {
+	var components []string
+	start := 1
+	for i := 1; i < len(compositeKey); i++ {
+		if compositeKey[i] == 0 {
+			components = append(components, compositeKey[start:i])
+			start = i + 1
+		}
+	}
+	if len(components) == 0 {
+		return "", nil
+	}
+
+	return components[0], components[1:]
+}
+
+// partialCompositeKeyRange returns the key range of the composite keys of
+// objectType starting with keys
+func partialCompositeKeyRange(objectType string, keys []string) (string, string, error) {
+	partialKey, err := createCompositeKey(objectType, keys)
+	if err != nil {
+		return "", "", err
+	}
+
+	return partialKey, partialKey + maxUnicodeRune, nil
+}
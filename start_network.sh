@@ -13,7 +13,7 @@ docker kill cliDigiBank cliMagnetoCorp logspout || true
 ./network.sh down
 ./network.sh up createChannel -ca -s couchdb
 
-./network.sh deployCC -ccn instance -ccp ../labplatform/chaincode/instance/ -ccl go -ccep "OR('Org1MSP.peer','Org2MSP.peer')"
+./network.sh deployCC -ccn labplatform -ccp ../labplatform/chaincode/labplatform/ -ccl go -cccg ../labplatform/chaincode/labplatform/collections_config.json -ccep "OR('Org1MSP.peer','Org2MSP.peer')"
 
 cp ${PWD}/../test-network/organizations/peerOrganizations/org1.example.com/users/User1@org1.example.com/msp/signcerts/* ${PWD}/../test-network/organizations/peerOrganizations/org1.example.com/users/User1@org1.example.com/msp/signcerts/User1@org1.example.com-cert.pem
 cp ${PWD}/../test-network/organizations/peerOrganizations/org1.example.com/users/User1@org1.example.com/msp/keystore/* ${PWD}/../test-network/organizations/peerOrganizations/org1.example.com/users/User1@org1.example.com/msp/keystore/priv_sk